@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/github"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/tagging"
+)
+
+// Create the rest and graphql clients, they authenticate as the GitHub App installation when GITHUB_APP_ID is set,
+// otherwise they rotate the requests over the GITHUB_TOKEN pool. GITHUB_API_URL points them to GitHub Enterprise Server.
+func newGithubClients() (*github.Client, *github.GraphQLClient, error) {
+	options := github.Options{BaseURL: config.GitHubAPIURL}
+
+	if config.GitHubAppId == "" {
+		options.Tokens = config.GitHubTokens
+
+		return github.NewClientWithOptions(options), github.NewGraphQLClientWithOptions(options), nil
+	}
+
+	appId, err := strconv.ParseInt(config.GitHubAppId, 10, 64)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid GITHUB_APP_ID: %v", err)
+	}
+
+	installationId, err := strconv.ParseInt(config.GitHubInstallationId, 10, 64)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid GITHUB_APP_INSTALLATION_ID: %v", err)
+	}
+
+	options.App, err = github.NewAppAuth(appId, installationId, config.GitHubAppKeyPath)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options.App.UseOptions(options)
+
+	return github.NewClientWithOptions(options), github.NewGraphQLClientWithOptions(options), nil
+}
+
+// Load the mapping from GitHub topics to tags, it is nil when TOPIC_TAGS_FILE is not set.
+func loadTopicTags() (tagging.Mapping, error) {
+	if config.TopicTagsFile == "" {
+		return nil, nil
+	}
+
+	return tagging.LoadMapping(config.TopicTagsFile)
+}
+
+// Create the tagger of the mapping, repositories are not tagged from topics without a mapping.
+func newTagger(mapping tagging.Mapping, repositories *global.Repositories) *tagging.Tagger {
+	if mapping == nil {
+		return nil
+	}
+
+	return tagging.NewTagger(mapping, repositories.TagRepo, repositories.GhRepositoryRepo)
+}
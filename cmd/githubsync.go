@@ -16,6 +16,7 @@ import (
 	"github.com/liweiyi88/trendshift-backend/config"
 	"github.com/liweiyi88/trendshift-backend/database"
 	"github.com/liweiyi88/trendshift-backend/github"
+	"github.com/liweiyi88/trendshift-backend/global"
 	"github.com/liweiyi88/trendshift-backend/model"
 	"github.com/liweiyi88/trendshift-backend/model/opt"
 	"github.com/spf13/cobra"
@@ -24,15 +25,17 @@ import (
 var start string
 var end string
 var limit int
+var syncGraphQL bool
 
 // If run as cronjob, a suggested command to avoid sending too many requests to GitHub is
-// `sync [repository|developer] --end=-2d --limit=500` and run it hourly.
+// `sync [repository|developer] --end=-2d --limit=500` and run it hourly, the scheduler command can run the same job in process.
 func init() {
 	rootCmd.AddCommand(gihtubSyncCmd)
 
 	gihtubSyncCmd.Flags().StringVarP(&start, "start", "s", "", "--start \"2023-01-06 14:35:00\" ")
 	gihtubSyncCmd.Flags().StringVarP(&end, "end", "e", "", "--end \"2023-10-06 14:35:00\", --end=-2d or --end=2h, `d` for days, `h` for hours ")
 	gihtubSyncCmd.Flags().IntVarP(&limit, "limit", "l", 0, "--limit=100")
+	gihtubSyncCmd.Flags().BoolVar(&syncGraphQL, "graphql", false, "fetch the details in batches of 100 with the GitHub GraphQL api")
 }
 
 var gihtubSyncCmd = &cobra.Command{
@@ -43,9 +46,22 @@ var gihtubSyncCmd = &cobra.Command{
 		config.Init()
 
 		action := args[0]
+		gh, graphQL, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		topicTags, err := loadTopicTags()
+
+		if err != nil {
+			slog.Error("failed to load topic tags", slog.Any("error", err))
+			return
+		}
+
 		ctx, stop := context.WithCancel(context.Background())
 		db := database.GetInstance(ctx)
-		gh := github.NewClient(config.GitHubToken)
 
 		defer func() {
 			err := db.Close()
@@ -85,8 +101,23 @@ var gihtubSyncCmd = &cobra.Command{
 
 		repositoryRepo := model.NewGhRepositoryRepo(db)
 		developerRepo := model.NewDeveloperRepo(db)
-		handler := github.NewSyncHandler(db, repositoryRepo, developerRepo, gh)
-		err = handler.Handle(ctx, action, opt.Start(start), opt.End(endDateTime), opt.Limit(limit))
+		gh.SetETagStore(model.NewGithubEtagRepo(db))
+		readmes := github.NewReadmeFetcher(gh, model.NewRepositoryReadmeRepo(db))
+		releases := github.NewReleaseFetcher(gh, model.NewReleaseRepo(db))
+		handler := github.NewSyncHandler(db, repositoryRepo, developerRepo, gh).WithTagger(newTagger(topicTags, global.InitRepositories(db))).WithReadmes(readmes).WithReleases(releases)
+
+		tokens := gh.Tokens()
+
+		if syncGraphQL {
+			handler.WithDetails(graphQL)
+			tokens = graphQL.Tokens()
+		}
+
+		jobType, arguments := commandJob(cmd, args)
+		err = runJob(ctx, db, jobType, arguments, []string{"sync:" + action}, func(ctx context.Context) error {
+			return handler.Handle(ctx, action, opt.Start(start), opt.End(endDateTime), opt.Limit(limit))
+		})
+		tokens.LogUsage()
 
 		if err != nil {
 			slog.Error("failed to handle sync action", slog.Any("error", err))
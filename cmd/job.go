@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"log/slog"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/joblock"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var lockWait, lockNoWait bool
+
+func init() {
+	for _, cmd := range []*cobra.Command{scrapeCmd, scrapeReparseCmd, scrapeImportCmd, linkCmd, gihtubSyncCmd, searchCmd} {
+		addLockFlags(cmd)
+	}
+}
+
+// Commands that mutate data take a job lock, so the same job never runs in two processes at the same time.
+func addLockFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&lockWait, "wait", false, "wait for the job lock when the job is running in another process")
+	cmd.Flags().BoolVar(&lockNoWait, "no-wait", false, "exit when the job is running in another process (default)")
+	cmd.MarkFlagsMutuallyExclusive("wait", "no-wait")
+}
+
+// Run the job while holding the job locks and record the run, a job locked by another process is recorded as skipped unless --wait is set.
+func runJob(ctx context.Context, db database.DB, jobType, arguments string, names []string, job func(ctx context.Context) error) error {
+	repositories := global.InitRepositories(db)
+	locker := joblock.NewLocker(repositories.JobLockRepo)
+	recorder := jobrun.NewRecorder(repositories.JobRunRepo)
+
+	return recorder.Record(ctx, jobType, arguments, func(ctx context.Context) error {
+		err := locker.Run(ctx, names, lockWait && !lockNoWait, job)
+
+		if errors.Is(err, joblock.ErrLocked) {
+			slog.Warn("skip the job as it is running in another process, use --wait to wait for it", slog.Any("error", err))
+			return fmt.Errorf("%w: %v", jobrun.ErrSkipped, err)
+		}
+
+		return err
+	})
+}
+
+// Flags whose values are never recorded, the arguments of the job runs are served by the admin api.
+var sensitiveFlags = []string{"password", "token", "secret", "key"}
+
+// The type and arguments of the command to record, e.g. "scrape" and "repository --since=weekly".
+func commandJob(cmd *cobra.Command, args []string) (string, string) {
+	arguments := slices.Clone(args)
+
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+
+		if slices.Contains(sensitiveFlags, flag.Name) {
+			value = "***"
+		}
+
+		arguments = append(arguments, fmt.Sprintf("--%s=%s", flag.Name, value))
+	})
+
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" "), strings.Join(arguments, " ")
+}
+
+// Lock names of the scrape, reparse and import commands, an empty type means both types.
+func scrapeLockNames(scrapeType string) []string {
+	if scrapeType == "" {
+		return []string{"scrape:repository", "scrape:developer"}
+	}
+
+	return []string{"scrape:" + scrapeType}
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/liweiyi88/trendshift-backend/scheduler"
+	"github.com/spf13/cobra"
+)
+
+func TestCommandJob(t *testing.T) {
+	root := &cobra.Command{Use: "trendshift"}
+	parent := &cobra.Command{Use: "scrape"}
+	child := &cobra.Command{Use: "reparse"}
+
+	root.AddCommand(parent)
+	parent.AddCommand(child)
+
+	var from, to, password string
+	child.Flags().StringVar(&from, "from", "", "")
+	child.Flags().StringVar(&to, "to", "", "")
+	child.Flags().StringVar(&password, "password", "", "")
+
+	if err := child.Flags().Parse([]string{"--from", "2024-01-06", "--password", "secret123"}); err != nil {
+		t.Fatal(err)
+	}
+
+	jobType, arguments := commandJob(child, []string{"repository"})
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{jobType, "scrape reparse"},
+		{arguments, "repository --from=2024-01-06 --password=***"},
+		{strings.Contains(arguments, "secret123"), false},
+	}
+
+	jobType, arguments = commandJob(parent, nil)
+
+	expcts = append(expcts, []struct {
+		actual any
+		want   any
+	}{
+		{jobType, "scrape"},
+		{arguments, ""},
+		{schedulerJobArguments(scheduler.JobConfig{Name: "sync-repository", Task: "sync", Action: "repository", End: "-2d", Limit: 500}), "repository --end=-2d --limit=500 --scheduler-job=sync-repository"},
+	}...)
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
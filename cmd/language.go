@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"log/slog"
+
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/spf13/cobra"
+)
+
+var languageName, languageSlug string
+var languageExpectedRows int
+
+func init() {
+	rootCmd.AddCommand(languageAddCmd)
+	rootCmd.AddCommand(languageListCmd)
+	rootCmd.AddCommand(languageDisableCmd)
+
+	languageAddCmd.Flags().StringVarP(&languageName, "name", "n", "", "display name, e.g. --name Kotlin")
+	languageAddCmd.Flags().StringVarP(&languageSlug, "slug", "s", "", "slug used in the GitHub trending url, e.g. --slug kotlin")
+	languageAddCmd.Flags().IntVar(&languageExpectedRows, "expected-rows", 0, "minimum number of rows expected on the trending page, a scrape with fewer rows is reported as unhealthy")
+
+	languageAddCmd.MarkFlagRequired("name")
+	languageAddCmd.MarkFlagRequired("slug")
+}
+
+// Run the language command with the language repository and close the db connection afterwards.
+func runLanguageCmd(run func(ctx context.Context, languageRepo *model.LanguageRepo)) {
+	config.Init()
+
+	ctx, stop := context.WithCancel(context.Background())
+	db := database.GetInstance(ctx)
+
+	defer func() {
+		err := db.Close()
+
+		if err != nil {
+			slog.Error("failed to close db", slog.Any("error", err))
+		}
+
+		stop()
+	}()
+
+	appSignal := make(chan os.Signal, 3)
+	signal.Notify(appSignal, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-appSignal
+		stop()
+	}()
+
+	repositories := global.InitRepositories(db)
+
+	run(ctx, repositories.LanguageRepo)
+}
+
+var languageAddCmd = &cobra.Command{
+	Use:   "language:add",
+	Short: "Add a programming language to scrape, or enable it again if it exists",
+	Run: func(cmd *cobra.Command, args []string) {
+		runLanguageCmd(func(ctx context.Context, languageRepo *model.LanguageRepo) {
+			language, err := languageRepo.FindBySlug(ctx, languageSlug)
+
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				log.Fatalf("failed to find language: %v", err)
+			}
+
+			language.Name, language.Slug, language.Enabled = languageName, languageSlug, true
+
+			if language.Id == 0 || cmd.Flags().Changed("expected-rows") {
+				language.ExpectedRows = languageExpectedRows
+			}
+
+			if language.Id > 0 {
+				err = languageRepo.Update(ctx, language)
+			} else {
+				_, err = languageRepo.Save(ctx, language)
+			}
+
+			if err != nil {
+				log.Fatalf("failed to save language: %v", err)
+			}
+		})
+	},
+}
+
+var languageListCmd = &cobra.Command{
+	Use:   "language:list",
+	Short: "List all programming languages",
+	Run: func(cmd *cobra.Command, args []string) {
+		runLanguageCmd(func(ctx context.Context, languageRepo *model.LanguageRepo) {
+			languages, err := languageRepo.FindAll(ctx)
+
+			if err != nil {
+				log.Fatalf("failed to find languages: %v", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tSLUG\tENABLED\tEXPECTED ROWS")
+
+			for _, language := range languages {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%d\n", language.Id, language.Name, language.Slug, language.Enabled, language.ExpectedRows)
+			}
+
+			w.Flush()
+		})
+	},
+}
+
+var languageDisableCmd = &cobra.Command{
+	Use:   "language:disable [slug]",
+	Short: "Stop scraping a programming language",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runLanguageCmd(func(ctx context.Context, languageRepo *model.LanguageRepo) {
+			language, err := languageRepo.FindBySlug(ctx, args[0])
+
+			if err != nil {
+				log.Fatalf("failed to find language %s: %v", args[0], err)
+			}
+
+			language.Enabled = false
+
+			if err := languageRepo.Update(ctx, language); err != nil {
+				log.Fatalf("failed to disable language: %v", err)
+			}
+		})
+	},
+}
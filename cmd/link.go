@@ -19,8 +19,14 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var linkGraphQL bool
+var linkBackfill bool
+
 func init() {
 	rootCmd.AddCommand(linkCmd)
+
+	linkCmd.Flags().BoolVar(&linkGraphQL, "graphql", false, "fetch the details in batches of 100 with the GitHub GraphQL api")
+	linkCmd.Flags().BoolVar(&linkBackfill, "backfill", false, "backfill the star history of the newly linked repositories from their stargazers")
 }
 
 var linkCmd = &cobra.Command{
@@ -31,9 +37,29 @@ var linkCmd = &cobra.Command{
 		config.Init()
 
 		action := args[0]
+		client, graphQL, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		topicTags, err := loadTopicTags()
+
+		if err != nil {
+			slog.Error("failed to load topic tags", slog.Any("error", err))
+			return
+		}
+
+		var gh github.Details = client
+		tokens := client.Tokens()
+
+		if linkGraphQL {
+			gh, tokens = graphQL, graphQL.Tokens()
+		}
+
 		ctx, stop := context.WithCancel(context.Background())
 		db := database.GetInstance(ctx)
-		gh := github.NewClient(config.GitHubToken)
 
 		defer func() {
 			err := db.Close()
@@ -59,19 +85,28 @@ var linkCmd = &cobra.Command{
 
 		repositories := global.InitRepositories(db)
 		search := search.NewSearch()
-		githubFetcher := trending.NewGithubFetcher(gh, search, *repositories)
+		readmes := github.NewReadmeFetcher(client, repositories.RepositoryReadmeRepo)
+		githubFetcher := trending.NewGithubFetcher(gh, search, *repositories).WithTagger(newTagger(topicTags, repositories)).WithReadmes(readmes)
+
+		if linkBackfill {
+			githubFetcher.WithBackfill(github.NewStarBackfiller(client, repositories.MetricRepo, 0))
+		}
 
-		var err error
+		var fetch func(ctx context.Context) error
 
 		if action == "repository" {
-			err = githubFetcher.FetchRepositories(ctx)
+			fetch = githubFetcher.FetchRepositories
 		} else if action == "developer" {
-			err = githubFetcher.FetchDevelopers(ctx)
+			fetch = githubFetcher.FetchDevelopers
 		} else {
 			slog.Error("invalid action, expected repository or developer")
 			return
 		}
 
+		jobType, arguments := commandJob(cmd, args)
+		err = runJob(ctx, db, jobType, arguments, []string{"link:" + action}, fetch)
+		tokens.LogUsage()
+
 		if err != nil {
 			slog.Error("failed to handle sync action", slog.Any("error", err))
 			sentry.CaptureException(err)
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"log/slog"
+
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(locksCmd)
+	locksCmd.AddCommand(locksListCmd)
+	locksCmd.AddCommand(locksReleaseCmd)
+}
+
+var locksCmd = &cobra.Command{
+	Use:   "locks",
+	Short: "List or release job locks",
+}
+
+func runLocksCmd(run func(ctx context.Context, jobLockRepo *model.JobLockRepo)) {
+	config.Init()
+
+	ctx, stop := context.WithCancel(context.Background())
+	db := database.GetInstance(ctx)
+
+	defer func() {
+		err := db.Close()
+
+		if err != nil {
+			slog.Error("failed to close db", slog.Any("error", err))
+		}
+
+		stop()
+	}()
+
+	appSignal := make(chan os.Signal, 3)
+	signal.Notify(appSignal, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-appSignal
+		stop()
+	}()
+
+	run(ctx, model.NewJobLockRepo(db))
+}
+
+var locksListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List job locks and their owners",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLocksCmd(func(ctx context.Context, jobLockRepo *model.JobLockRepo) {
+			locks, err := jobLockRepo.FindAll(ctx)
+
+			if err != nil {
+				log.Fatalf("failed to find job locks: %v", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tOWNER\tACQUIRED AT\tHEARTBEAT AT\tEXPIRES AT\tEXPIRED")
+
+			for _, lock := range locks {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\n",
+					lock.Name,
+					lock.Owner,
+					lock.AcquiredAt.Format(time.DateTime),
+					lock.HeartbeatAt.Format(time.DateTime),
+					lock.ExpiresAt.Format(time.DateTime),
+					lock.Expired,
+				)
+			}
+
+			w.Flush()
+		})
+	},
+}
+
+var locksReleaseCmd = &cobra.Command{
+	Use:   "release [name]",
+	Short: "Release a job lock, e.g. the lock of a crashed process, regardless of its owner",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runLocksCmd(func(ctx context.Context, jobLockRepo *model.JobLockRepo) {
+			released, err := jobLockRepo.ForceRelease(ctx, args[0])
+
+			if err != nil {
+				log.Fatalf("failed to release job lock: %v", err)
+			}
+
+			if !released {
+				slog.Warn("job lock not found", slog.String("lock", args[0]))
+				return
+			}
+
+			slog.Info("job lock released", slog.String("lock", args[0]))
+		})
+	},
+}
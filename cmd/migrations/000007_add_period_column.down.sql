@@ -0,0 +1,9 @@
+DELETE FROM trending_repositories WHERE `period` <> 'daily';
+ALTER TABLE trending_repositories DROP CONSTRAINT `full_name`;
+ALTER TABLE trending_repositories ADD UNIQUE (`full_name`, `language`, `trend_date`, `rank`);
+ALTER TABLE trending_repositories DROP COLUMN `period`;
+
+DELETE FROM trending_developers WHERE `period` <> 'daily';
+ALTER TABLE trending_developers DROP CONSTRAINT `username`;
+ALTER TABLE trending_developers ADD UNIQUE (`username`, `language`, `trend_date`, `rank`);
+ALTER TABLE trending_developers DROP COLUMN `period`;
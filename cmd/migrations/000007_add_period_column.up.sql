@@ -0,0 +1,11 @@
+ALTER TABLE trending_repositories
+ADD `period` varchar(20) NOT NULL DEFAULT 'daily';
+
+ALTER TABLE trending_repositories DROP CONSTRAINT `full_name`;
+ALTER TABLE trending_repositories ADD UNIQUE (`full_name`, `language`, `trend_date`, `rank`, `period`);
+
+ALTER TABLE trending_developers
+ADD `period` varchar(20) NOT NULL DEFAULT 'daily';
+
+ALTER TABLE trending_developers DROP CONSTRAINT `username`;
+ALTER TABLE trending_developers ADD UNIQUE (`username`, `language`, `trend_date`, `rank`, `period`);
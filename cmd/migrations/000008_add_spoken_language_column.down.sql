@@ -0,0 +1,4 @@
+DELETE FROM trending_repositories WHERE `spoken_language` IS NOT NULL;
+ALTER TABLE trending_repositories DROP CONSTRAINT `full_name`;
+ALTER TABLE trending_repositories ADD UNIQUE (`full_name`, `language`, `trend_date`, `rank`, `period`);
+ALTER TABLE trending_repositories DROP COLUMN `spoken_language`;
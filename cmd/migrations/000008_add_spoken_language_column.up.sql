@@ -0,0 +1,5 @@
+ALTER TABLE trending_repositories
+ADD `spoken_language` varchar(20) DEFAULT NULL;
+
+ALTER TABLE trending_repositories DROP CONSTRAINT `full_name`;
+ALTER TABLE trending_repositories ADD UNIQUE (`full_name`, `language`, `spoken_language`, `trend_date`, `rank`, `period`);
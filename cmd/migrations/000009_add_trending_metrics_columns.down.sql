@@ -0,0 +1,6 @@
+ALTER TABLE trending_repositories
+DROP COLUMN `stars_today`,
+DROP COLUMN `stars`,
+DROP COLUMN `forks`,
+DROP COLUMN `repository_language`,
+DROP COLUMN `built_by`;
@@ -0,0 +1,6 @@
+ALTER TABLE trending_repositories
+ADD `stars_today` INT NOT NULL DEFAULT 0,
+ADD `stars` INT NOT NULL DEFAULT 0,
+ADD `forks` INT NOT NULL DEFAULT 0,
+ADD `repository_language` varchar(255) DEFAULT NULL,
+ADD `built_by` JSON DEFAULT NULL;
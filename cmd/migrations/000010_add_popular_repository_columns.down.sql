@@ -0,0 +1,5 @@
+ALTER TABLE trending_developers
+DROP FOREIGN KEY `FK_PQRMXVWZKHBNDTLA`,
+DROP KEY `IDX_7C1B2E4A9D3F6A10`,
+DROP COLUMN `repo_full_name`,
+DROP COLUMN `repository_id`;
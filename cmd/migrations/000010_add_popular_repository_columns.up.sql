@@ -0,0 +1,5 @@
+ALTER TABLE trending_developers
+ADD `repo_full_name` varchar(255) DEFAULT NULL,
+ADD `repository_id` INT DEFAULT NULL,
+ADD KEY `IDX_7C1B2E4A9D3F6A10` (`repository_id`),
+ADD CONSTRAINT `FK_PQRMXVWZKHBNDTLA` FOREIGN KEY (`repository_id`) REFERENCES `repositories` (`id`);
@@ -0,0 +1 @@
+DROP TABLE scrape_runs;
\ No newline at end of file
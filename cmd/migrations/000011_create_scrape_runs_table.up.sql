@@ -0,0 +1,14 @@
+CREATE TABLE scrape_runs (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `type` varchar(20) NOT NULL,
+    `language` varchar(255) NOT NULL DEFAULT '',
+    `spoken_language` varchar(20) NOT NULL DEFAULT '',
+    `period` varchar(20) NOT NULL DEFAULT 'daily',
+    `rows` INT NOT NULL DEFAULT 0,
+    `parsed` INT NOT NULL DEFAULT 0,
+    `status` varchar(20) NOT NULL,
+    `error` varchar(1024) DEFAULT NULL,
+    `scraped_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `IDX_5D3A1F7C9E2B4A86` (`scraped_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
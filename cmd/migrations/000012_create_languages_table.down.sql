@@ -0,0 +1 @@
+DROP TABLE languages;
\ No newline at end of file
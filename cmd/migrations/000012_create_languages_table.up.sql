@@ -0,0 +1,25 @@
+CREATE TABLE languages (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `name` varchar(255) NOT NULL,
+    `slug` varchar(255) NOT NULL,
+    `enabled` TINYINT(1) NOT NULL DEFAULT 1,
+    `created_at` datetime NOT NULL,
+    `updated_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE (`slug`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT INTO languages (`name`, `slug`, `enabled`, `created_at`, `updated_at`) VALUES
+('JavaScript', 'javascript', 1, NOW(), NOW()),
+('Python', 'python', 1, NOW(), NOW()),
+('Go', 'go', 1, NOW(), NOW()),
+('Java', 'java', 1, NOW(), NOW()),
+('PHP', 'php', 1, NOW(), NOW()),
+('C++', 'c++', 1, NOW(), NOW()),
+('C', 'c', 1, NOW(), NOW()),
+('TypeScript', 'typescript', 1, NOW(), NOW()),
+('Ruby', 'ruby', 1, NOW(), NOW()),
+('C#', 'c#', 1, NOW(), NOW()),
+('Rust', 'rust', 1, NOW(), NOW()),
+('Dart', 'dart', 1, NOW(), NOW()),
+('Swift', 'swift', 1, NOW(), NOW());
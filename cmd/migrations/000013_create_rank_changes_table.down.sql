@@ -0,0 +1 @@
+DROP TABLE rank_changes;
\ No newline at end of file
@@ -0,0 +1,16 @@
+CREATE TABLE rank_changes (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `type` varchar(20) NOT NULL,
+    `name` varchar(255) NOT NULL,
+    `language` varchar(255) DEFAULT NULL,
+    `spoken_language` varchar(20) DEFAULT NULL,
+    `period` varchar(20) NOT NULL DEFAULT 'daily',
+    `change` varchar(20) NOT NULL,
+    `previous_rank` INT DEFAULT NULL,
+    `rank` INT DEFAULT NULL,
+    `trend_date` date NOT NULL,
+    `created_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `IDX_0B6E2D9A4C7F3E15` (`type`, `name`),
+    KEY `IDX_8F1C5A3E7D2B9C64` (`trend_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
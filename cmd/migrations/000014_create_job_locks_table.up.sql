@@ -0,0 +1,8 @@
+CREATE TABLE job_locks (
+    `name` varchar(255) NOT NULL,
+    `owner` varchar(255) NOT NULL,
+    `acquired_at` datetime NOT NULL,
+    `heartbeat_at` datetime NOT NULL,
+    `expires_at` datetime NOT NULL,
+    PRIMARY KEY (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,17 @@
+CREATE TABLE job_runs (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `type` varchar(50) NOT NULL,
+    `arguments` varchar(1000) NOT NULL DEFAULT '',
+    `status` varchar(20) NOT NULL,
+    `scraped` INT NOT NULL DEFAULT 0,
+    `inserted` INT NOT NULL DEFAULT 0,
+    `updated` INT NOT NULL DEFAULT 0,
+    `linked` INT NOT NULL DEFAULT 0,
+    `failed` INT NOT NULL DEFAULT 0,
+    `error` text DEFAULT NULL,
+    `started_at` datetime NOT NULL,
+    `finished_at` datetime DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    KEY `IDX_3E9A7C1F5B2D8046` (`type`, `started_at`),
+    KEY `IDX_C4B8162E9F3A7D05` (`started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1 @@
+DROP TABLE github_etags;
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE github_etags (
+    `resource` varchar(255) NOT NULL,
+    `etag` varchar(255) NOT NULL DEFAULT '',
+    `last_modified` varchar(100) NOT NULL DEFAULT '',
+    `updated_at` datetime NOT NULL,
+    PRIMARY KEY (`resource`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
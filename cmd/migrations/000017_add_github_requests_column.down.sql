@@ -0,0 +1,2 @@
+ALTER TABLE job_runs
+DROP COLUMN `github_requests`;
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE job_runs
+ADD `github_requests` JSON DEFAULT NULL;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE repositories
+DROP COLUMN `topics`;
+
+ALTER TABLE repositories_tags
+DROP COLUMN `source`;
\ No newline at end of file
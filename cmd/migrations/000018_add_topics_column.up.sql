@@ -0,0 +1,9 @@
+ALTER TABLE repositories
+ADD `topics` JSON DEFAULT NULL;
+
+ALTER TABLE repositories_tags
+ADD `source` varchar(20) NOT NULL DEFAULT 'manual';
+
+-- Repositories with a stored etag are answered with 304 and would keep NULL topics, so they are fetched in full once.
+DELETE FROM github_etags
+WHERE `resource` LIKE '/repos/%' AND `resource` NOT LIKE '/repos/%/%/%';
\ No newline at end of file
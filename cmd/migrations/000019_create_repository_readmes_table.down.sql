@@ -0,0 +1 @@
+DROP TABLE repository_readmes;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE repository_readmes (
+    `repository_id` INT NOT NULL,
+    `sha` varchar(40) NOT NULL,
+    `excerpt` text NOT NULL,
+    `image_url` varchar(2048) DEFAULT NULL,
+    `updated_at` datetime NOT NULL,
+    PRIMARY KEY (`repository_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
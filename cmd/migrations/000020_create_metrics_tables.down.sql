@@ -0,0 +1,3 @@
+DROP TABLE repository_metrics;
+
+DROP TABLE developer_metrics;
\ No newline at end of file
@@ -0,0 +1,18 @@
+CREATE TABLE repository_metrics (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `repository_id` INT NOT NULL,
+    `stars` INT NOT NULL,
+    `forks` INT NOT NULL,
+    `recorded_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `IDX_5C2E8A1F9B3D7E46` (`repository_id`, `recorded_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE developer_metrics (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `developer_id` INT NOT NULL,
+    `followers` INT NOT NULL,
+    `recorded_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    KEY `IDX_A7D3F19C4E6B2805` (`developer_id`, `recorded_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
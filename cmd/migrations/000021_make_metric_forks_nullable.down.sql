@@ -0,0 +1,4 @@
+DELETE FROM repository_metrics WHERE `forks` IS NULL;
+
+ALTER TABLE repository_metrics
+MODIFY `forks` INT NOT NULL;
\ No newline at end of file
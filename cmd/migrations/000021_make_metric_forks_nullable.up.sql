@@ -0,0 +1,2 @@
+ALTER TABLE repository_metrics
+MODIFY `forks` INT DEFAULT NULL;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE releases;
\ No newline at end of file
@@ -0,0 +1,13 @@
+CREATE TABLE releases (
+    `id` INT NOT NULL AUTO_INCREMENT,
+    `repository_id` INT NOT NULL,
+    `version` varchar(255) NOT NULL,
+    `name` varchar(255) DEFAULT NULL,
+    `prerelease` tinyint(1) NOT NULL DEFAULT 0,
+    `source` varchar(20) NOT NULL DEFAULT 'release',
+    `published_at` datetime NOT NULL,
+    `created_at` datetime NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE (`repository_id`, `version`),
+    KEY `IDX_C4A8E2F61B7D9305` (`repository_id`, `published_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
\ No newline at end of file
@@ -0,0 +1,10 @@
+ALTER TABLE repositories
+DROP COLUMN `license`,
+DROP COLUMN `archived`,
+DROP COLUMN `fork`,
+DROP COLUMN `parent`,
+DROP COLUMN `open_issues`,
+DROP COLUMN `subscribers`,
+DROP COLUMN `size`,
+DROP COLUMN `pushed_at`,
+DROP COLUMN `github_created_at`;
\ No newline at end of file
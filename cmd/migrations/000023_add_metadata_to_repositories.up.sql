@@ -0,0 +1,14 @@
+ALTER TABLE repositories
+ADD `license` varchar(100) DEFAULT NULL,
+ADD `archived` tinyint(1) NOT NULL DEFAULT 0,
+ADD `fork` tinyint(1) NOT NULL DEFAULT 0,
+ADD `parent` varchar(255) DEFAULT NULL,
+ADD `open_issues` int NOT NULL DEFAULT 0,
+ADD `subscribers` int NOT NULL DEFAULT 0,
+ADD `size` int NOT NULL DEFAULT 0,
+ADD `pushed_at` datetime DEFAULT NULL,
+ADD `github_created_at` datetime DEFAULT NULL;
+
+-- Repositories with a stored etag are answered with 304 and would keep the defaults, so they are fetched in full once.
+DELETE FROM github_etags
+WHERE `resource` LIKE '/repos/%' AND `resource` NOT LIKE '/repos/%/%/%';
\ No newline at end of file
@@ -0,0 +1,2 @@
+ALTER TABLE trending_developers
+DROP COLUMN `repo_unavailable`;
\ No newline at end of file
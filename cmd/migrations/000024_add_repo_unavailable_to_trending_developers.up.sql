@@ -0,0 +1,2 @@
+ALTER TABLE trending_developers
+ADD `repo_unavailable` tinyint(1) NOT NULL DEFAULT 0;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE trending_repositories
+DROP COLUMN `unavailable`;
+
+ALTER TABLE trending_developers
+DROP COLUMN `unavailable`;
\ No newline at end of file
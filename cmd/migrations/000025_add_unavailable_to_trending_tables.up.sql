@@ -0,0 +1,5 @@
+ALTER TABLE trending_repositories
+ADD `unavailable` tinyint(1) NOT NULL DEFAULT 0;
+
+ALTER TABLE trending_developers
+ADD `unavailable` tinyint(1) NOT NULL DEFAULT 0;
\ No newline at end of file
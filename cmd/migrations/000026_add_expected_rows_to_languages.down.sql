@@ -0,0 +1,2 @@
+ALTER TABLE languages
+DROP COLUMN `expected_rows`;
\ No newline at end of file
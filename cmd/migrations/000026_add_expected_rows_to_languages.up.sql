@@ -0,0 +1,19 @@
+ALTER TABLE languages
+ADD `expected_rows` int NOT NULL DEFAULT 0;
+
+UPDATE languages SET `expected_rows` = CASE `slug`
+    WHEN 'javascript' THEN 15
+    WHEN 'python' THEN 15
+    WHEN 'go' THEN 10
+    WHEN 'java' THEN 10
+    WHEN 'php' THEN 5
+    WHEN 'c++' THEN 10
+    WHEN 'c' THEN 5
+    WHEN 'typescript' THEN 15
+    WHEN 'ruby' THEN 5
+    WHEN 'c#' THEN 5
+    WHEN 'rust' THEN 10
+    WHEN 'dart' THEN 1
+    WHEN 'swift' THEN 3
+    ELSE `expected_rows`
+END;
\ No newline at end of file
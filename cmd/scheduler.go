@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/github"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/scheduler"
+	"github.com/liweiyi88/trendshift-backend/scrape"
+	"github.com/liweiyi88/trendshift-backend/search"
+	"github.com/liweiyi88/trendshift-backend/trending"
+	"github.com/spf13/cobra"
+)
+
+var schedulerConfig string
+
+func init() {
+	rootCmd.AddCommand(schedulerCmd)
+
+	schedulerCmd.Flags().StringVarP(&schedulerConfig, "config", "c", "scheduler.json", "--config scheduler.json, see scheduler.example.json")
+}
+
+var schedulerCmd = &cobra.Command{
+	Use:   "scheduler",
+	Short: "Run scrape, link and sync jobs on the schedule of the config file until it is stopped",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config.Init()
+
+		schedule, err := scheduler.LoadConfig(schedulerConfig)
+
+		if err != nil {
+			slog.Error("failed to load scheduler config", slog.Any("error", err))
+			return
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		db := database.GetInstance(ctx)
+
+		defer func() {
+			err := db.Close()
+
+			if err != nil {
+				slog.Error("failed to close db", slog.Any("error", err))
+				sentry.CaptureException(err)
+			}
+
+			stop()
+			sentry.Flush(2 * time.Second)
+		}()
+
+		repositories := global.InitRepositories(db)
+		searchEngine := search.NewSearch()
+		gh, graphQL, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		topicTags, err := loadTopicTags()
+
+		if err != nil {
+			slog.Error("failed to load topic tags", slog.Any("error", err))
+			return
+		}
+
+		gh.SetETagStore(repositories.GithubEtagRepo)
+
+		tagger := newTagger(topicTags, repositories)
+		readmes := github.NewReadmeFetcher(gh, repositories.RepositoryReadmeRepo)
+		releases := github.NewReleaseFetcher(gh, repositories.ReleaseRepo)
+
+		tasks := &schedulerTasks{
+			scrapeHandler:      scrape.NewScrapeHandler(repositories, searchEngine, gh).WithTagger(tagger).WithReadmes(readmes),
+			githubFetcher:      trending.NewGithubFetcher(gh, searchEngine, *repositories).WithTagger(tagger).WithReadmes(readmes),
+			graphQLFetcher:     trending.NewGithubFetcher(graphQL, searchEngine, *repositories).WithTagger(tagger).WithReadmes(readmes),
+			syncHandler:        github.NewSyncHandler(db, repositories.GhRepositoryRepo, repositories.DeveloperRepo, gh).WithTagger(tagger).WithReadmes(readmes).WithReleases(releases),
+			graphQLSyncHandler: github.NewSyncHandler(db, repositories.GhRepositoryRepo, repositories.DeveloperRepo, gh).WithDetails(graphQL).WithTagger(tagger).WithReadmes(readmes).WithReleases(releases),
+			searchHandler:      search.NewSearchHandler(db, searchEngine),
+			backfiller:         github.NewStarBackfiller(gh, repositories.MetricRepo, 0),
+		}
+
+		jobs := make([]*scheduler.Job, 0, len(schedule.Jobs))
+
+		for _, jobConfig := range schedule.Jobs {
+			task, err := tasks.task(jobConfig)
+
+			if err != nil {
+				slog.Error("invalid scheduler job", slog.String("job", jobConfig.Name), slog.Any("error", err))
+				return
+			}
+
+			names, arguments := schedulerLockNames(jobConfig), schedulerJobArguments(jobConfig)
+
+			jobs = append(jobs, &scheduler.Job{
+				Name:       jobConfig.Name,
+				Interval:   time.Duration(jobConfig.Interval),
+				Jitter:     time.Duration(jobConfig.Jitter),
+				RunOnStart: jobConfig.RunOnStart,
+				// Take the same job locks and record the run as the commands do, so a job is skipped while it runs in another process.
+				Task: func(ctx context.Context) error {
+					return runJob(ctx, db, jobConfig.Task, arguments, names, task)
+				},
+			})
+		}
+
+		if err := scheduler.New(time.Duration(schedule.ShutdownTimeout), jobs...).Run(ctx); err != nil {
+			slog.Error("failed to run scheduler", slog.Any("error", err))
+			sentry.CaptureException(err)
+		}
+	},
+}
+
+// The handlers shared by the scheduled jobs, so they run in process as the scrape, link, sync and search commands do.
+// Jobs with the graphql option fetch the details in batches with the graphql handlers.
+type schedulerTasks struct {
+	scrapeHandler      *scrape.ScrapeHandler
+	githubFetcher      *trending.GithubFetcher
+	graphQLFetcher     *trending.GithubFetcher
+	syncHandler        *github.SyncHandler
+	graphQLSyncHandler *github.SyncHandler
+	searchHandler      *search.SearchHandler
+	backfiller         *github.StarBackfiller
+}
+
+func (st *schedulerTasks) task(job scheduler.JobConfig) (scheduler.Task, error) {
+	switch job.Task {
+	case scheduler.TaskScrape:
+		period := model.PeriodOrDefault(job.Since)
+
+		return func(ctx context.Context) error {
+			return st.scrapeHandler.Handle(ctx, job.Action, opt.Period(period))
+		}, nil
+	case scheduler.TaskLink:
+		fetcher := st.githubFetcher
+
+		if job.GraphQL {
+			fetcher = st.graphQLFetcher
+		}
+
+		// The fetchers are shared by the jobs, a job with the backfill option links with its own copy.
+		if job.Backfill {
+			backfilled := *fetcher
+			fetcher = backfilled.WithBackfill(st.backfiller)
+		}
+
+		if job.Action == "developer" {
+			return fetcher.FetchDevelopers, nil
+		}
+
+		return fetcher.FetchRepositories, nil
+	case scheduler.TaskSync:
+		if _, err := parseEndDateTimeOption(job.End); err != nil {
+			return nil, fmt.Errorf("invalid end option: %v", err)
+		}
+
+		handler := st.syncHandler
+
+		if job.GraphQL {
+			handler = st.graphQLSyncHandler
+		}
+
+		return func(ctx context.Context) error {
+			// A relative end like -2d is resolved on every run.
+			endDateTime, err := parseEndDateTimeOption(job.End)
+
+			if err != nil {
+				return err
+			}
+
+			return handler.Handle(ctx, job.Action, opt.End(endDateTime), opt.Limit(job.Limit))
+		}, nil
+	case scheduler.TaskSearch:
+		return func(ctx context.Context) error {
+			return st.searchHandler.Handle(ctx, job.Action)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported task: %s", job.Task)
+	}
+}
+
+func schedulerLockNames(job scheduler.JobConfig) []string {
+	switch job.Task {
+	case scheduler.TaskScrape:
+		return scrapeLockNames(job.Action)
+	case scheduler.TaskSearch:
+		return []string{"search"}
+	default:
+		return []string{job.Task + ":" + job.Action}
+	}
+}
+
+// The arguments of a scheduled job in the same format as the command arguments.
+func schedulerJobArguments(job scheduler.JobConfig) string {
+	arguments := []string{job.Action}
+
+	if job.Since != "" {
+		arguments = append(arguments, "--since="+job.Since)
+	}
+
+	if job.End != "" {
+		arguments = append(arguments, "--end="+job.End)
+	}
+
+	if job.Limit > 0 {
+		arguments = append(arguments, fmt.Sprintf("--limit=%d", job.Limit))
+	}
+
+	return strings.Join(append(arguments, "--scheduler-job="+job.Name), " ")
+}
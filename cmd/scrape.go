@@ -16,13 +16,19 @@ import (
 	"github.com/liweiyi88/trendshift-backend/database"
 	"github.com/liweiyi88/trendshift-backend/github"
 	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
 	"github.com/liweiyi88/trendshift-backend/scrape"
 	"github.com/liweiyi88/trendshift-backend/search"
 	"github.com/spf13/cobra"
 )
 
+var since string
+
 func init() {
 	rootCmd.AddCommand(scrapeCmd)
+
+	scrapeCmd.Flags().StringVar(&since, "since", model.DailyPeriod, "--since daily, --since weekly or --since monthly")
 }
 
 var scrapeCmd = &cobra.Command{
@@ -33,12 +39,31 @@ var scrapeCmd = &cobra.Command{
 		action := args[0]
 		config.Init()
 
+		if !model.IsValidPeriod(since) {
+			slog.Error("invalid since option, expected daily, weekly or monthly", slog.String("since", since))
+			return
+		}
+
+		gh, _, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		topicTags, err := loadTopicTags()
+
+		if err != nil {
+			slog.Error("failed to load topic tags", slog.Any("error", err))
+			return
+		}
+
 		search := search.NewSearch()
 		ctx, stop := context.WithCancel(context.Background())
 		db := database.GetInstance(ctx)
 		repositories := global.InitRepositories(db)
-		gh := github.NewClient(config.GitHubToken)
-		handler := scrape.NewScrapeHandler(repositories, search, gh)
+		readmes := github.NewReadmeFetcher(gh, repositories.RepositoryReadmeRepo)
+		handler := scrape.NewScrapeHandler(repositories, search, gh).WithTagger(newTagger(topicTags, repositories)).WithReadmes(readmes)
 
 		defer func() {
 			err := db.Close()
@@ -60,7 +85,10 @@ var scrapeCmd = &cobra.Command{
 			stop()
 		}()
 
-		err := handler.Handle(ctx, action)
+		jobType, arguments := commandJob(cmd, args)
+		err = runJob(ctx, db, jobType, arguments, scrapeLockNames(action), func(ctx context.Context) error {
+			return handler.Handle(ctx, action, opt.Period(since))
+		})
 		if err != nil {
 			slog.Error("failed to handle action", slog.Any("error", err))
 			sentry.CaptureException(err)
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/spf13/cobra"
+)
+
+var healthDays int
+
+func init() {
+	scrapeCmd.AddCommand(scrapeHealthCmd)
+
+	scrapeHealthCmd.Flags().IntVar(&healthDays, "days", config.ScrapeHealthDays, "--days 7, number of days of scrape runs to compare with")
+}
+
+var scrapeHealthCmd = &cobra.Command{
+	Use:   "health",
+	Short: "Check the latest scrape of every language against the expected rows and recent history.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		config.Init()
+
+		ctx, stop := context.WithCancel(context.Background())
+		db := database.GetInstance(ctx)
+
+		defer func() {
+			err := db.Close()
+
+			if err != nil {
+				slog.Error("failed to close db", slog.Any("error", err))
+				sentry.CaptureException(err)
+			}
+
+			stop()
+			sentry.Flush(2 * time.Second)
+		}()
+
+		appSignal := make(chan os.Signal, 3)
+		signal.Notify(appSignal, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			<-appSignal
+			stop()
+		}()
+
+		repositories := global.InitRepositories(db)
+
+		runs, err := repositories.ScrapeRunRepo.FindSince(ctx, time.Now().AddDate(0, 0, -healthDays))
+
+		if err != nil {
+			slog.Error("failed to find scrape runs", slog.Any("error", err))
+			sentry.CaptureException(err)
+			return
+		}
+
+		languages, err := repositories.LanguageRepo.FindAll(ctx)
+
+		if err != nil {
+			slog.Error("failed to find languages", slog.Any("error", err))
+			sentry.CaptureException(err)
+			return
+		}
+
+		healths := model.CheckScrapeHealth(runs, languages)
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TYPE\tPERIOD\tLANGUAGE\tSPOKEN LANGUAGE\tSTATUS\tROWS\tEXPECTED\tAVERAGE\tSCRAPED AT\tISSUES")
+
+		for _, health := range healths {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%.1f\t%s\t%s\n",
+				health.Type,
+				health.Period,
+				displayLanguage(health.Language),
+				displayLanguage(health.SpokenLanguage),
+				health.Status,
+				health.Rows,
+				health.ExpectedRows,
+				health.AverageRows,
+				health.ScrapedAt.Format(time.DateTime),
+				strings.Join(health.Issues, "; "))
+
+			if !health.Healthy {
+				sentry.CaptureMessage(fmt.Sprintf("unhealthy %s %s scrape for language: %s, spoken language: %s, issues: %s",
+					health.Period, health.Type, displayLanguage(health.Language), displayLanguage(health.SpokenLanguage), strings.Join(health.Issues, "; ")))
+			}
+		}
+
+		w.Flush()
+	},
+}
+
+// The empty language means all languages on the GitHub trending page.
+func displayLanguage(language string) string {
+	if language == "" {
+		return "all"
+	}
+
+	return language
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/github"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/scrape"
+	"github.com/liweiyi88/trendshift-backend/search"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	scrapeCmd.AddCommand(scrapeImportCmd)
+}
+
+var scrapeImportCmd = &cobra.Command{
+	Use:   "import [dir]",
+	Short: "Import saved GitHub trending pages (.html, .htm or .html.gz) from a directory and link them with GitHub.",
+	Long: `Import saved GitHub trending pages from a directory and link them with GitHub.
+
+The type, language, spoken language and period are inferred from the og:url or canonical url of the page,
+web archive urls are supported. Otherwise they are inferred from the file name, e.g. trending-developers-go-weekly-2021-03-04.html.
+The trend date is inferred from the file name, the web archive timestamp or the file modification time.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := args[0]
+		config.Init()
+
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			slog.Error("invalid directory to import", slog.String("dir", dir))
+			return
+		}
+
+		gh, _, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		topicTags, err := loadTopicTags()
+
+		if err != nil {
+			slog.Error("failed to load topic tags", slog.Any("error", err))
+			return
+		}
+
+		ctx, stop := context.WithCancel(context.Background())
+		db := database.GetInstance(ctx)
+		repositories := global.InitRepositories(db)
+		readmes := github.NewReadmeFetcher(gh, repositories.RepositoryReadmeRepo)
+		handler := scrape.NewScrapeHandler(repositories, search.NewSearch(), gh).WithTagger(newTagger(topicTags, repositories)).WithReadmes(readmes)
+
+		defer func() {
+			err := db.Close()
+
+			if err != nil {
+				slog.Error("failed to close db", slog.Any("error", err))
+				sentry.CaptureException(err)
+			}
+
+			stop()
+			sentry.Flush(2 * time.Second)
+		}()
+
+		appSignal := make(chan os.Signal, 3)
+		signal.Notify(appSignal, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			<-appSignal
+			stop()
+		}()
+
+		// Import links the imported pages with GitHub afterwards, so it takes the link locks too.
+		names := append(scrapeLockNames(""), "link:repository", "link:developer")
+
+		jobType, arguments := commandJob(cmd, args)
+		err = runJob(ctx, db, jobType, arguments, names, func(ctx context.Context) error {
+			return handler.Import(ctx, dir)
+		})
+
+		if err != nil {
+			slog.Error("failed to import trending pages", slog.Any("error", err))
+			sentry.CaptureException(err)
+		}
+	},
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"log/slog"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/scrape"
+	"github.com/liweiyi88/trendshift-backend/search"
+	"github.com/spf13/cobra"
+)
+
+var reparseFrom, reparseTo, reparseDir string
+
+func init() {
+	scrapeCmd.AddCommand(scrapeReparseCmd)
+
+	scrapeReparseCmd.Flags().StringVar(&reparseFrom, "from", "", "--from 2024-01-06 or --from \"2024-01-06 14:35:00\"")
+	scrapeReparseCmd.Flags().StringVar(&reparseTo, "to", "", "--to 2024-01-31, a date includes the whole day")
+	scrapeReparseCmd.Flags().StringVar(&reparseDir, "dir", "", "archive directory, defaults to SCRAPE_ARCHIVE_DIR")
+
+	scrapeReparseCmd.MarkFlagRequired("from")
+	scrapeReparseCmd.MarkFlagRequired("to")
+}
+
+// Parse a date or a date time in the local time zone, a date as the end of a range includes the whole day.
+func parseReparseTime(value string, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation(time.DateTime, value, time.Local); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+
+	if err != nil {
+		return t, fmt.Errorf("invalid time: %s, expected format: 2024-01-06 or \"2024-01-06 14:35:00\"", value)
+	}
+
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+var scrapeReparseCmd = &cobra.Command{
+	Use:   "reparse [repository|developer]",
+	Short: "Rebuild trending repositories or trending developers from the archived trending pages without visiting GitHub.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		config.Init()
+
+		var scrapeType string
+
+		if len(args) > 0 {
+			scrapeType = args[0]
+		}
+
+		if reparseDir == "" {
+			reparseDir = config.ScrapeArchiveDir
+		}
+
+		if reparseDir == "" {
+			slog.Error("archive directory is not set, use --dir or SCRAPE_ARCHIVE_DIR")
+			return
+		}
+
+		from, err := parseReparseTime(reparseFrom, false)
+
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+
+		to, err := parseReparseTime(reparseTo, true)
+
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+
+		gh, _, err := newGithubClients()
+
+		if err != nil {
+			slog.Error("failed to create GitHub client", slog.Any("error", err))
+			return
+		}
+
+		ctx, stop := context.WithCancel(context.Background())
+		db := database.GetInstance(ctx)
+		repositories := global.InitRepositories(db)
+		handler := scrape.NewScrapeHandler(repositories, search.NewSearch(), gh)
+
+		defer func() {
+			err := db.Close()
+
+			if err != nil {
+				slog.Error("failed to close db", slog.Any("error", err))
+				sentry.CaptureException(err)
+			}
+
+			stop()
+			sentry.Flush(2 * time.Second)
+		}()
+
+		appSignal := make(chan os.Signal, 3)
+		signal.Notify(appSignal, os.Interrupt, syscall.SIGTERM)
+
+		go func() {
+			<-appSignal
+			stop()
+		}()
+
+		jobType, arguments := commandJob(cmd, args)
+		err = runJob(ctx, db, jobType, arguments, scrapeLockNames(scrapeType), func(ctx context.Context) error {
+			return handler.Reparse(ctx, scrapeType, reparseDir, from, to)
+		})
+
+		if err != nil {
+			slog.Error("failed to re-parse archived pages", slog.Any("error", err))
+			sentry.CaptureException(err)
+		}
+	},
+}
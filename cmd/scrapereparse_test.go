@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseReparseTime(t *testing.T) {
+	from, err := parseReparseTime("2024-01-06", false)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if want := time.Date(2024, 1, 6, 0, 0, 0, 0, time.Local); !from.Equal(want) {
+		t.Errorf("expected: %v but got: %v", want, from)
+	}
+
+	to, err := parseReparseTime("2024-01-31", true)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if want := time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local); !to.Equal(want) {
+		t.Errorf("expected the end date to include the whole day: %v but got: %v", want, to)
+	}
+
+	to, err = parseReparseTime("2024-01-31 14:35:00", true)
+
+	if err != nil {
+		t.Error(err)
+	}
+
+	if want := time.Date(2024, 1, 31, 14, 35, 0, 0, time.Local); !to.Equal(want) {
+		t.Errorf("expected: %v but got: %v", want, to)
+	}
+
+	if _, err = parseReparseTime("31/01/2024", false); err == nil {
+		t.Error("expected invalid parse error but got nil")
+	}
+}
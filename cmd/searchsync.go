@@ -50,7 +50,10 @@ var searchCmd = &cobra.Command{
 			stop()
 		}()
 
-		err := handler.Handle(ctx, action)
+		jobType, arguments := commandJob(cmd, args)
+		err := runJob(ctx, db, jobType, arguments, []string{"search"}, func(ctx context.Context) error {
+			return handler.Handle(ctx, action)
+		})
 
 		if err != nil {
 			slog.Error("failed to handle action", slog.Any("error", err))
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -11,17 +12,27 @@ import (
 
 const JWTMaxAge = 60 * time.Minute
 
-var LanguageToScrape = []string{"", "javascript", "python", "go", "java", "php", "c++", "c", "typescript", "ruby", "c#", "rust", "dart", "swift"}
+// Spoken language codes used by the trending repositories page, the empty string means any spoken language.
+var SpokenLanguageToScrape = []string{"", "zh", "en", "ja", "es"}
+
+// Number of days of scrape runs used to check the scrape health.
+const ScrapeHealthDays = 7
 
 var (
 	DatabaseDSN          string
-	GitHubToken          string
+	GitHubTokens         []string
+	GitHubAPIURL         string
+	GitHubAppId          string
+	GitHubInstallationId string
+	GitHubAppKeyPath     string
 	GinMode              string
 	SignIngKey           string
 	AlgoliasearchAppId   string
 	AlgoliasearchApiKey  string
 	MeilisearchMasterKey string
 	MeilisearchHost      string
+	ScrapeArchiveDir     string
+	TopicTagsFile        string
 )
 
 func Init() {
@@ -29,11 +40,17 @@ func Init() {
 	godotenv.Load(".env")
 
 	DatabaseDSN = os.Getenv("DATABASE_DSN")
-	GitHubToken = os.Getenv("GITHUB_TOKEN")
+	GitHubTokens = githubTokens(os.Getenv("GITHUB_TOKEN"), os.Getenv("GITHUB_TOKEN_FILE"))
+	GitHubAPIURL = os.Getenv("GITHUB_API_URL")
+	GitHubAppId = os.Getenv("GITHUB_APP_ID")
+	GitHubInstallationId = os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	GitHubAppKeyPath = os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH")
 	GinMode = os.Getenv("GIN_MODE")
 	SignIngKey = os.Getenv("SIGNING_KEY")
 	MeilisearchMasterKey = os.Getenv("MEILISEARCH_MASTER_KEY")
 	MeilisearchHost = os.Getenv("MEILISEARCH_HOST")
+	ScrapeArchiveDir = os.Getenv("SCRAPE_ARCHIVE_DIR")
+	TopicTagsFile = os.Getenv("TOPIC_TAGS_FILE")
 
 	AlgoliasearchAppId = os.Getenv("ALGOLIASEARCH_APPID")
 	AlgoliasearchApiKey = os.Getenv("ALGOLIASEARCH_APIKEY")
@@ -48,3 +65,33 @@ func Init() {
 		log.Fatalf("sentry.Init: %s", err)
 	}
 }
+
+// The GitHub tokens of the pool, GITHUB_TOKEN can be a comma separated list and GITHUB_TOKEN_FILE
+// can be a file with a token per line, lines starting with # are ignored.
+func githubTokens(tokenList, tokenFile string) []string {
+	tokens := make([]string, 0)
+
+	for _, token := range strings.Split(tokenList, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	if tokenFile == "" {
+		return tokens
+	}
+
+	content, err := os.ReadFile(tokenFile)
+
+	if err != nil {
+		log.Fatalf("failed to read GITHUB_TOKEN_FILE: %v", err)
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+		}
+	}
+
+	return tokens
+}
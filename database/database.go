@@ -23,6 +23,11 @@ type DB interface {
 	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
 }
 
+// Execer is satisfied by both the db and a transaction, so a query can run inside or outside of a transaction.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
 func GetInstance(ctx context.Context) *sql.DB {
 	once.Do(func() {
 		var err error
@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// GitHub accepts app JWTs that expire in no more than 10 minutes, the issued time is set in the past to allow for clock drift.
+	// see https://docs.github.com/en/apps/creating-github-apps/authenticating-with-a-github-app/generating-a-json-web-token-jwt-for-a-github-app
+	appJWTMaxAge    = 9 * time.Minute
+	appJWTClockSkew = time.Minute
+
+	// Installation tokens expire after an hour, they are refreshed a few minutes earlier so a request never sends an expired token.
+	installationTokenRefreshBefore = 5 * time.Minute
+)
+
+// AppAuth authenticates as a GitHub App installation, it exchanges the app JWT for installation access tokens and caches them until they are about to expire.
+type AppAuth struct {
+	AppId          int64
+	InstallationId int64
+
+	key *rsa.PrivateKey
+	api apiConfig
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// Create the app auth with the private key file downloaded from the app settings.
+func NewAppAuth(appId, installationId int64, keyPath string) (*AppAuth, error) {
+	pem, err := os.ReadFile(keyPath)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitHub App private key: %v", err)
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %v", err)
+	}
+
+	return &AppAuth{
+		AppId:          appId,
+		InstallationId: installationId,
+		key:            key,
+	}, nil
+}
+
+// Build the JWT that authenticates as the app, it is only used to request installation tokens.
+func (app *AppAuth) jwt(now time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iat": now.Add(-appJWTClockSkew).Unix(),
+		"exp": now.Add(appJWTMaxAge).Unix(),
+		"iss": strconv.FormatInt(app.AppId, 10),
+	})
+
+	return token.SignedString(app.key)
+}
+
+type installationToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Get the cached installation token, a new token is requested when the cached one is about to expire.
+func (app *AppAuth) Token(ctx context.Context) (string, error) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	now := time.Now()
+
+	if app.token != "" && now.Add(installationTokenRefreshBefore).Before(app.expiresAt) {
+		return app.token, nil
+	}
+
+	appJWT, err := app.jwt(now)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to sign GitHub App JWT: %v", err)
+	}
+
+	url := app.api.url(fmt.Sprintf("/app/installations/%d/access_tokens", app.InstallationId))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+appJWT)
+
+	res, err := app.api.do(req)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to request installation token: %v", err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read installation token response: %v", err)
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("installation token request is not successful, get status code: %d, body: %s", res.StatusCode, string(body))
+	}
+
+	var token installationToken
+
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("failed to decode installation token: %v", err)
+	}
+
+	app.token, app.expiresAt = token.Token, token.ExpiresAt
+
+	return app.token, nil
+}
+
+// Request the installation tokens with the api settings of the options, e.g. the base url of GitHub Enterprise Server.
+// It is set once before the clients are created with the same options.
+func (app *AppAuth) UseOptions(options Options) {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.api = newAPIConfig(options)
+}
+
+// Drop the cached installation token, e.g. after it was rejected, so the next request gets a new one.
+func (app *AppAuth) expire() {
+	app.mu.Lock()
+	defer app.mu.Unlock()
+
+	app.token = ""
+}
@@ -0,0 +1,184 @@
+package github
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// A fake GitHub token endpoint, it checks the app JWT and issues installation tokens ghs_1, ghs_2 and so on.
+type fakeTokenEndpoint struct {
+	t         *testing.T
+	key       *rsa.PrivateKey
+	issued    atomic.Int32
+	expiresIn time.Duration
+}
+
+func (f *fakeTokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	appJWT := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	token, err := jwt.Parse(appJWT, func(token *jwt.Token) (any, error) {
+		return &f.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{"RS256"}), jwt.WithIssuer("12345"))
+
+	if err != nil || !token.Valid {
+		f.t.Errorf("expected a valid app JWT but got error: %v", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"token":      fmt.Sprintf("ghs_%d", f.issued.Add(1)),
+		"expires_at": time.Now().Add(f.expiresIn).UTC().Format(time.RFC3339),
+	})
+}
+
+// Write a new private key in the pem format that GitHub App settings provide.
+func writeTestKey(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "app.private-key.pem")
+	content := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return key, path
+}
+
+func newTestAppAuth(t *testing.T, expiresIn time.Duration, api http.HandlerFunc) (*AppAuth, *fakeTokenEndpoint, string) {
+	t.Helper()
+
+	key, path := writeTestKey(t)
+	endpoint := &fakeTokenEndpoint{t: t, key: key, expiresIn: expiresIn}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/app/") {
+			endpoint.ServeHTTP(w, r)
+			return
+		}
+
+		api(w, r)
+	}))
+
+	t.Cleanup(server.Close)
+
+	app, err := NewAppAuth(12345, 42, path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app.UseOptions(Options{BaseURL: server.URL})
+
+	return app, endpoint, server.URL
+}
+
+func TestAppAuthCachesAndRefreshesTokens(t *testing.T) {
+	app, endpoint, _ := newTestAppAuth(t, time.Hour, nil)
+	ctx := context.Background()
+
+	first, firstErr := app.Token(ctx)
+	cached, cachedErr := app.Token(ctx)
+
+	// A token that expires within the refresh window is replaced before it is sent.
+	app.expiresAt = time.Now().Add(installationTokenRefreshBefore - time.Second)
+	refreshed, refreshedErr := app.Token(ctx)
+
+	app.expire()
+	renewed, renewedErr := app.Token(ctx)
+
+	expcts := []expectation{
+		{firstErr, nil},
+		{first, "ghs_1"},
+		{cachedErr, nil},
+		{cached, "ghs_1"},
+		{refreshedErr, nil},
+		{refreshed, "ghs_2"},
+		{renewedErr, nil},
+		{renewed, "ghs_3"},
+		{endpoint.issued.Load(), int32(3)},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestNewAppAuthInvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.pem")
+
+	if err := os.WriteFile(path, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewAppAuth(12345, 42, path); err == nil {
+		t.Error("expected an invalid key to be rejected")
+	}
+
+	if _, err := NewAppAuth(12345, 42, filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected a missing key file to be rejected")
+	}
+}
+
+func TestAppClientSendsInstallationToken(t *testing.T) {
+	var requests atomic.Int32
+
+	app, endpoint, baseURL := newTestAppAuth(t, time.Hour, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		// The first installation token is revoked before it expires.
+		if r.Header.Get("Authorization") != "Bearer ghs_2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"login": "liweiyi88"}`))
+	})
+
+	client := NewClientWithOptions(Options{App: app, BaseURL: baseURL})
+
+	developer, err := client.GetDeveloper(context.Background(), "liweiyi88")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usage := client.Tokens().Usage()
+
+	expcts := []expectation{
+		{developer.Username, "liweiyi88"},
+		{requests.Load(), int32(2)},
+		{endpoint.issued.Load(), int32(2)},
+		{usage[0].Token, "app installation 42"},
+		{usage[0].Revoked, false},
+	}
+
+	assertExpects(t, expcts)
+}
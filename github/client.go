@@ -2,154 +2,384 @@ package github
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"log/slog"
 
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
 )
 
 var ErrNotFound = errors.New("not found on GitHub")
 var ErrAccessBlocked = errors.New("repository access blocked")
+var ErrRateLimited = errors.New("GitHub rate limit exceeded")
+var ErrNotModified = errors.New("not modified on GitHub")
+
+const apiBaseURL = "https://api.github.com"
+
+const (
+	maxRetries = 3
+
+	// GitHub suggests waiting at least one minute when a secondary rate limit response has no retry-after header.
+	// see https://docs.github.com/en/rest/using-the-rest-api/rate-limits-for-the-rest-api?apiVersion=2022-11-28#exceeding-the-rate-limit
+	defaultSecondaryRateLimitWait = time.Minute
+	serverErrorBackoff            = time.Second
+)
+
+// The rate limit of the token as reported by the latest response.
+type Quota struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	Known     bool // false until the first response is received.
+}
+
+// ETagStore keeps the validators of fetched resources for conditional requests, see model.GithubEtagRepo.
+type ETagStore interface {
+	Find(ctx context.Context, resource string) (model.GithubEtag, error)
+	Save(ctx context.Context, etag model.GithubEtag) error
+}
 
 // GitHub rest api client
 type Client struct {
-	Token string // the personal acesss token, if set, the common rate limit is 5000 reqs/hour, otherwise, it will be 60 reqs/hour.
+	api   apiConfig
+	etags ETagStore
+	sleep func(ctx context.Context, d time.Duration) error
+
+	once   sync.Once
+	tokens *TokenPool
+}
+
+// Create a client that rotates the requests over the personal access tokens, the common rate limit is 5000 reqs/hour per token.
+// Without a token, the rate limit will be 60 reqs/hour.
+func NewClient(tokens ...string) *Client {
+	return NewClientWithOptions(Options{Tokens: tokens})
 }
 
-func NewClient(token string) *Client {
+func NewClientWithOptions(options Options) *Client {
 	return &Client{
-		Token: token,
+		api:    newAPIConfig(options),
+		tokens: newOptionsTokenPool(options),
 	}
 }
 
-func (ghClient *Client) GetDeveloper(ctx context.Context, username string) (model.Developer, error) {
-	url := fmt.Sprintf("%s/%s", "https://api.github.com/users", username)
+// The token pool of the options, it uses the installation tokens of the app auth when set.
+func newOptionsTokenPool(options Options) *TokenPool {
+	if options.App == nil {
+		return NewTokenPool(options.Tokens...)
+	}
 
-	var developer model.Developer
+	return NewAppTokenPool(options.App)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return developer, err
-	}
+// The token pool of the client, a zero value client sends anonymous requests.
+func (ghClient *Client) Tokens() *TokenPool {
+	ghClient.once.Do(func() {
+		if ghClient.tokens == nil {
+			ghClient.tokens = NewTokenPool()
+		}
+	})
 
-	req.Header.Set("Accept", "application/vnd.github+json")
+	return ghClient.tokens
+}
 
-	if strings.TrimSpace(ghClient.Token) != "" {
-		req.Header.Set("Authorization", "Bearer "+ghClient.Token)
+// Keep the validators of fetched resources, so unchanged resources can be fetched with conditional requests.
+func (ghClient *Client) SetETagStore(store ETagStore) {
+	ghClient.etags = store
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
 
-	client := &http.Client{}
-	res, err := client.Do(req)
+func (ghClient *Client) wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
 
-	if err != nil {
-		return developer, fmt.Errorf("failed to send get developer request %v", err)
+	if ghClient.sleep != nil {
+		return ghClient.sleep(ctx, d)
 	}
 
-	defer func() {
-		err := res.Body.Close()
-		if err != nil {
-			slog.Any("failed to close response body when fetch developer:", err)
-		}
-	}()
+	return sleep(ctx, d)
+}
+
+// Get the combined quota of the tokens, it is shared by all goroutines using the client.
+func (ghClient *Client) Quota() Quota {
+	return ghClient.Tokens().Quota()
+}
+
+// Check whether the quota allows to send the given number of requests before the rate limit resets.
+// It is true when the quota is unknown or has been reset.
+func (ghClient *Client) HasQuota(requests int) bool {
+	return ghClient.Tokens().HasQuota(requests)
+}
 
-	body, err := io.ReadAll(res.Body)
+// Pick the healthiest token, it waits until the rate limit resets when the quota of every token is used up,
+// so goroutines do not keep sending requests that will be rejected.
+func waitForToken(ctx context.Context, tokens *TokenPool, wait func(ctx context.Context, d time.Duration) error) (*poolToken, error) {
+	token, d, err := tokens.pick()
 
-	if err != nil {
-		return developer, fmt.Errorf("failed to read response body: %v", err)
+	if err != nil || d <= 0 {
+		return token, err
 	}
 
-	err = json.Unmarshal(body, &developer)
+	slog.Warn("GitHub quota used up, waiting for the rate limit to reset", slog.String("token", token.label), slog.Duration("wait", d))
 
-	if err != nil {
-		return developer, fmt.Errorf("failed to decode developer body err: %v, received: %s, status code: %s", err, string(body), res.Status)
+	if err := wait(ctx, d); err != nil {
+		return nil, err
 	}
 
-	slog.Info(fmt.Sprintf("fetching %s", developer.Username), slog.Group("github",
-		slog.String("X-Ratelimit-Limit", res.Header.Get("X-Ratelimit-Limit")),
-		slog.String("X-Ratelimit-Remaining", res.Header.Get("X-Ratelimit-Remaining")),
-		slog.String("X-Ratelimit-Reset", res.Header.Get("X-Ratelimit-Reset")),
-	))
+	tokens.forget(token)
 
-	if res.StatusCode != http.StatusOK {
-		if res.StatusCode == http.StatusNotFound {
-			return developer, ErrNotFound
+	return token, nil
+}
+
+// Tell a rate limit response apart from an access blocked response, it returns how long to wait before retrying.
+func rateLimitWait(res *http.Response, body []byte) (time.Duration, bool) {
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
 		}
+	}
 
-		if res.StatusCode == http.StatusUnavailableForLegalReasons || res.StatusCode == http.StatusForbidden {
-			return developer, ErrAccessBlocked
+	if res.Header.Get("X-Ratelimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(res.Header.Get("X-Ratelimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0)) + time.Second, true
 		}
+	}
 
-		return developer, fmt.Errorf("request %s is not successful, get status code: %d, body: %s", url, res.StatusCode, string(body))
+	if res.StatusCode == http.StatusTooManyRequests || strings.Contains(strings.ToLower(string(body)), "rate limit") {
+		return defaultSecondaryRateLimitWait, true
 	}
 
-	return developer, nil
+	return 0, false
 }
 
-func (ghClient *Client) GetRepository(ctx context.Context, fullName string) (model.GhRepository, error) {
-	url := fmt.Sprintf("%s/%s", "https://api.github.com/repos", fullName)
+const defaultMediaType = "application/vnd.github+json"
 
-	var ghRepository model.GhRepository
+// Send a GET request to the rest api and decode the response into v.
+// It waits and retries when the rate limit is exceeded and retries with backoff on server errors.
+// A conditional request returns ErrNotModified when the resource has not changed since it was fetched,
+// a 304 response does not count against the rate limit.
+// The validators of the response are returned rather than saved, the caller saves them with saveETag once the resource is stored,
+// otherwise a resource that failed to store would be answered with 304 on the next request.
+func (ghClient *Client) get(ctx context.Context, path string, v any, conditional bool) (model.GithubEtag, error) {
+	return ghClient.getMediaType(ctx, path, defaultMediaType, v, conditional)
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return ghRepository, err
-	}
+// Send a GET request that accepts a custom media type, e.g. application/vnd.github.star+json for the starred time of stargazers.
+func (ghClient *Client) getMediaType(ctx context.Context, path string, mediaType string, v any, conditional bool) (model.GithubEtag, error) {
+	url := ghClient.api.url(path)
+
+	var validators model.GithubEtag
 
-	req.Header.Set("Accept", "application/vnd.github+json")
+	if conditional && ghClient.etags != nil {
+		var err error
+		validators, err = ghClient.etags.Find(ctx, path)
 
-	if strings.TrimSpace(ghClient.Token) != "" {
-		req.Header.Set("Authorization", "Bearer "+ghClient.Token)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			slog.Error("failed to find github etag", slog.String("resource", path), slog.Any("error", err))
+		}
 	}
 
-	client := &http.Client{}
-	res, err := client.Do(req)
+	tokens := ghClient.Tokens()
 
-	if err != nil {
-		return ghRepository, fmt.Errorf("failed to send get repository request %v", err)
-	}
+	for attempt := 0; ; attempt++ {
+		token, err := waitForToken(ctx, tokens, ghClient.wait)
 
-	defer func() {
-		err := res.Body.Close()
 		if err != nil {
-			slog.Any("failed to close response body when fetch repository:", err)
+			return model.GithubEtag{}, err
 		}
-	}()
 
-	body, err := io.ReadAll(res.Body)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return model.GithubEtag{}, err
+		}
 
-	if err != nil {
-		return ghRepository, fmt.Errorf("failed to read response body: %v", err)
-	}
+		req.Header.Set("Accept", mediaType)
 
-	err = json.Unmarshal(body, &ghRepository)
+		credential, err := token.credential(ctx)
 
-	if err != nil {
-		return ghRepository, fmt.Errorf("failed to decode repository body: %v", err)
-	}
+		if err != nil {
+			return model.GithubEtag{}, err
+		}
 
-	slog.Info(fmt.Sprintf("fetching %s", ghRepository.FullName), slog.Group("github",
-		slog.String("X-Ratelimit-Limit", res.Header.Get("X-Ratelimit-Limit")),
-		slog.String("X-Ratelimit-Remaining", res.Header.Get("X-Ratelimit-Remaining")),
-		slog.String("X-Ratelimit-Reset", res.Header.Get("X-Ratelimit-Reset")),
-	))
+		if credential != "" {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		}
+
+		if validators.ETag != "" {
+			req.Header.Set("If-None-Match", validators.ETag)
+		}
+
+		if validators.LastModified != "" {
+			req.Header.Set("If-Modified-Since", validators.LastModified)
+		}
+
+		res, err := ghClient.api.do(req)
+
+		if err != nil {
+			return model.GithubEtag{}, fmt.Errorf("failed to send get request %s: %w", url, err)
+		}
+
+		body, err := io.ReadAll(res.Body)
+
+		if closeErr := res.Body.Close(); closeErr != nil {
+			slog.Error("failed to close response body", slog.String("url", url), slog.Any("error", closeErr))
+		}
+
+		if err != nil {
+			return model.GithubEtag{}, fmt.Errorf("failed to read response body: %v", err)
+		}
+
+		tokens.record(token, res.Header)
+		jobrun.Count(ctx).Requested(token.label)
+
+		slog.Info(fmt.Sprintf("fetching %s", path), slog.String("token", token.label), slog.Group("github",
+			slog.String("X-Ratelimit-Limit", res.Header.Get("X-Ratelimit-Limit")),
+			slog.String("X-Ratelimit-Remaining", res.Header.Get("X-Ratelimit-Remaining")),
+			slog.String("X-Ratelimit-Reset", res.Header.Get("X-Ratelimit-Reset")),
+		))
+
+		if res.StatusCode == http.StatusOK {
+			if err := json.Unmarshal(body, v); err != nil {
+				return model.GithubEtag{}, fmt.Errorf("failed to decode body of %s: %v, received: %s", url, err, string(body))
+			}
+
+			return model.GithubEtag{
+				Resource:     path,
+				ETag:         res.Header.Get("ETag"),
+				LastModified: res.Header.Get("Last-Modified"),
+			}, nil
+		}
+
+		if res.StatusCode == http.StatusNotModified {
+			return model.GithubEtag{}, ErrNotModified
+		}
 
-	if res.StatusCode != http.StatusOK {
 		if res.StatusCode == http.StatusNotFound {
-			return ghRepository, ErrNotFound
+			return model.GithubEtag{}, ErrNotFound
+		}
+
+		if res.StatusCode == http.StatusUnauthorized && retryUnauthorized(tokens, token, &attempt) {
+			continue
+		}
+
+		if wait, ok := rateLimitWait(res, body); ok {
+			if attempt >= maxRetries {
+				return model.GithubEtag{}, fmt.Errorf("%w, request: %s", ErrRateLimited, url)
+			}
+
+			if err := retryRateLimited(ctx, tokens, token, wait, ghClient.wait); err != nil {
+				return model.GithubEtag{}, err
+			}
+
+			continue
 		}
 
 		if res.StatusCode == http.StatusUnavailableForLegalReasons || res.StatusCode == http.StatusForbidden {
-			return ghRepository, ErrAccessBlocked
+			return model.GithubEtag{}, ErrAccessBlocked
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError && attempt < maxRetries {
+			backoff := serverErrorBackoff << attempt
+			slog.Warn("GitHub server error, retrying", slog.String("url", url), slog.Int("status", res.StatusCode), slog.Duration("backoff", backoff))
+
+			if err := ghClient.wait(ctx, backoff); err != nil {
+				return model.GithubEtag{}, err
+			}
+
+			continue
 		}
 
-		return ghRepository, fmt.Errorf("request %s is not successful, get status code: %d, body: %s", url, res.StatusCode, string(body))
+		return model.GithubEtag{}, fmt.Errorf("request %s is not successful, get status code: %d, body: %s", url, res.StatusCode, string(body))
+	}
+}
+
+// Retry a rate limited request with another token, it waits for the rate limit when no other token has quota left.
+func retryRateLimited(ctx context.Context, tokens *TokenPool, token *poolToken, d time.Duration, wait func(ctx context.Context, d time.Duration) error) error {
+	tokens.exhaust(token, d)
+
+	if tokens.hasOther(token) {
+		slog.Warn("GitHub rate limit exceeded, retrying with another token", slog.String("token", token.label), slog.Duration("reset", d))
+		return nil
+	}
+
+	slog.Warn("GitHub rate limit exceeded, waiting to retry", slog.String("token", token.label), slog.Duration("wait", d))
+
+	if err := wait(ctx, d); err != nil {
+		return err
 	}
 
-	return ghRepository, nil
+	// The quota of the exceeded window is stale after waiting, the next response tells the new quota.
+	tokens.forget(token)
+
+	return nil
+}
+
+// Save the validators returned by get, so the resource is requested conditionally next time.
+func (ghClient *Client) saveETag(ctx context.Context, etag model.GithubEtag) {
+	if ghClient.etags == nil || (etag.ETag == "" && etag.LastModified == "") {
+		return
+	}
+
+	if err := ghClient.etags.Save(ctx, etag); err != nil {
+		slog.Error("failed to save github etag", slog.String("resource", etag.Resource), slog.Any("error", err))
+	}
+}
+
+func (ghClient *Client) GetDeveloper(ctx context.Context, username string) (model.Developer, error) {
+	var developer model.Developer
+
+	_, err := ghClient.get(ctx, "/users/"+username, &developer, false)
+
+	return developer, err
+}
+
+// Get the developer unless it has not changed since it was fetched, then it returns ErrNotModified.
+func (ghClient *Client) GetDeveloperIfChanged(ctx context.Context, username string) (model.Developer, model.GithubEtag, error) {
+	var developer model.Developer
+
+	etag, err := ghClient.get(ctx, "/users/"+username, &developer, true)
+
+	return developer, etag, err
+}
+
+func (ghClient *Client) GetRepository(ctx context.Context, fullName string) (model.GhRepository, error) {
+	var ghRepository model.GhRepository
+
+	_, err := ghClient.get(ctx, "/repos/"+fullName, &ghRepository, false)
+
+	return ghRepository, err
+}
+
+// Get the repository unless it has not changed since it was fetched, then it returns ErrNotModified.
+func (ghClient *Client) GetRepositoryIfChanged(ctx context.Context, fullName string) (model.GhRepository, model.GithubEtag, error) {
+	var ghRepository model.GhRepository
+
+	etag, err := ghClient.get(ctx, "/repos/"+fullName, &ghRepository, true)
+
+	return ghRepository, etag, err
 }
@@ -2,13 +2,27 @@ package github
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/liweiyi88/trendshift-backend/model"
 )
 
 func TestGetDeveloper(t *testing.T) {
-	client := Client{}
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/users/liweiyi88" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{"id": 7248260, "login": "liweiyi88", "avatar_url": "https://avatars.githubusercontent.com/u/7248260?v=4"}`))
+	})
 
 	developer, err := client.GetDeveloper(context.Background(), "liweiyi88")
 	if err != nil {
@@ -36,7 +50,29 @@ func TestGetDeveloper(t *testing.T) {
 }
 
 func TestGetRepository(t *testing.T) {
-	client := Client{}
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/liweiyi88/onedump" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte(`{
+			"id": 540829453,
+			"full_name": "liweiyi88/onedump",
+			"language": "Go",
+			"topics": ["database", "backup"],
+			"owner": {"login": "liweiyi88", "avatar_url": "https://avatars.githubusercontent.com/u/7248260?v=4"},
+			"license": {"key": "mit", "spdx_id": "MIT"},
+			"archived": false,
+			"fork": true,
+			"parent": {"full_name": "upstream/onedump"},
+			"open_issues_count": 3,
+			"subscribers_count": 7,
+			"size": 1024,
+			"pushed_at": "2024-05-10T18:00:00Z",
+			"created_at": "2022-09-24T08:00:00Z"
+		}`))
+	})
 
 	ghRepo, err := client.GetRepository(context.Background(), "liweiyi88/onedump")
 	if err != nil {
@@ -52,6 +88,7 @@ func TestGetRepository(t *testing.T) {
 			AvatarUrl: "https://avatars.githubusercontent.com/u/7248260?v=4",
 		},
 		Language: "Go",
+		Topics:   model.Topics{"database", "backup"},
 	}
 
 	if ghRepo.FullName != expect.FullName {
@@ -69,4 +106,382 @@ func TestGetRepository(t *testing.T) {
 	if ghRepo.Owner.AvatarUrl != expect.Owner.AvatarUrl {
 		t.Errorf("expect: %v but got :%v", ghRepo, expect)
 	}
+
+	if !slices.Equal(ghRepo.Topics, expect.Topics) {
+		t.Errorf("expect: %v but got :%v", ghRepo, expect)
+	}
+
+	expcts := []expectation{
+		{ghRepo.License.SpdxId.String, "MIT"},
+		{ghRepo.Archived, false},
+		{ghRepo.Fork, true},
+		{ghRepo.Parent.FullName.String, "upstream/onedump"},
+		{ghRepo.OpenIssues, 3},
+		{ghRepo.Subscribers, 7},
+		{ghRepo.Size, 1024},
+		{ghRepo.PushedAt.Time.Format(time.DateOnly), "2024-05-10"},
+		{ghRepo.GhCreatedAt.Time.Format(time.DateOnly), "2022-09-24"},
+		{ghRepo.CreatedAt.IsZero(), true},
+	}
+
+	assertExpects(t, expcts)
+}
+
+// Create a client against a fake GitHub api, the waits are recorded instead of slept.
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *[]time.Duration) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	waits := make([]time.Duration, 0)
+
+	client := NewClientWithOptions(Options{Tokens: []string{"token"}, BaseURL: server.URL})
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	return client, &waits
+}
+
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (c *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestClientOptions(t *testing.T) {
+	var userAgent string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.Write([]byte(`{"login": "liweiyi88"}`))
+	}))
+
+	t.Cleanup(server.Close)
+
+	transport := &countingTransport{}
+	client := NewClientWithOptions(Options{BaseURL: server.URL + "/", Transport: transport, Timeout: time.Second, UserAgent: "trendshift-test"})
+
+	developer, err := client.GetDeveloper(context.Background(), "liweiyi88")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultClient := NewClient()
+	enterprise := NewGraphQLClientWithOptions(Options{BaseURL: "https://github.example.com/api/v3"})
+
+	expcts := []expectation{
+		{developer.Username, "liweiyi88"},
+		{userAgent, "trendshift-test"},
+		{transport.requests.Load(), int32(1)},
+		{client.api.client.Timeout, time.Second},
+		{defaultClient.api.client.Timeout, defaultTimeout},
+		{defaultClient.api.url("/users/liweiyi88"), "https://api.github.com/users/liweiyi88"},
+		{NewGraphQLClient().api.graphQLURL(), "https://api.github.com/graphql"},
+		{enterprise.api.graphQLURL(), "https://github.example.com/api/graphql"},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGetDeveloperCancelled(t *testing.T) {
+	received := make(chan struct{})
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(received)
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		<-received
+		cancel()
+	}()
+
+	if _, err := client.GetDeveloper(ctx, "liweiyi88"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the in-flight request to be cancelled but got: %v", err)
+	}
+}
+
+func TestGetRepositoryWaitsForPrimaryRateLimit(t *testing.T) {
+	var requests atomic.Int32
+	reset := time.Now().Add(30 * time.Second).Unix()
+
+	client, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("expected the token to be sent but got: %s", r.Header.Get("Authorization"))
+		}
+
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset, 10))
+
+		if requests.Add(1) == 1 {
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "API rate limit exceeded"}`))
+			return
+		}
+
+		w.Header().Set("X-Ratelimit-Remaining", "4999")
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(reset+3600, 10))
+		w.Write([]byte(`{"id": 540829453, "full_name": "liweiyi88/onedump", "language": "Go"}`))
+	})
+
+	ghRepo, err := client.GetRepository(context.Background(), "liweiyi88/onedump")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quota := client.Quota()
+
+	expcts := []expectation{
+		{ghRepo.FullName, "liweiyi88/onedump"},
+		{requests.Load(), int32(2)},
+		{len(*waits), 1},
+		{(*waits)[0] > 25*time.Second && (*waits)[0] <= 31*time.Second, true},
+		{quota.Known, true},
+		{quota.Limit, 5000},
+		{quota.Remaining, 4999},
+		{client.HasQuota(200), true},
+		{client.HasQuota(5000), false},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGetDeveloperWaitsForSecondaryRateLimit(t *testing.T) {
+	var requests atomic.Int32
+
+	client, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch requests.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "42")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "You have exceeded a secondary rate limit."}`))
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Write([]byte(`{"login": "liweiyi88"}`))
+		}
+	})
+
+	developer, err := client.GetDeveloper(context.Background(), "liweiyi88")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expcts := []expectation{
+		{developer.Username, "liweiyi88"},
+		{len(*waits), 2},
+		{(*waits)[0], 42 * time.Second},
+		{(*waits)[1], defaultSecondaryRateLimitWait},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGetRepositoryErrors(t *testing.T) {
+	client, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", "4000")
+
+		switch r.URL.Path {
+		case "/repos/owner/blocked":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message": "Repository access blocked"}`))
+		case "/repos/owner/dmca":
+			w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		case "/repos/owner/limited":
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+		case "/repos/owner/down":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx := context.Background()
+
+	_, blockedErr := client.GetRepository(ctx, "owner/blocked")
+	_, dmcaErr := client.GetRepository(ctx, "owner/dmca")
+	_, notFoundErr := client.GetRepository(ctx, "owner/missing")
+	_, limitedErr := client.GetRepository(ctx, "owner/limited")
+	rateLimitWaits := len(*waits)
+	_, downErr := client.GetRepository(ctx, "owner/down")
+
+	expcts := []expectation{
+		{errors.Is(blockedErr, ErrAccessBlocked), true},
+		{errors.Is(dmcaErr, ErrAccessBlocked), true},
+		{errors.Is(notFoundErr, ErrNotFound), true},
+		{errors.Is(limitedErr, ErrRateLimited), true},
+		{rateLimitWaits, maxRetries},
+		{downErr != nil && !errors.Is(downErr, ErrAccessBlocked), true},
+		{(*waits)[rateLimitWaits:][0], serverErrorBackoff},
+		{(*waits)[rateLimitWaits:][1], 2 * serverErrorBackoff},
+		{(*waits)[rateLimitWaits:][2], 4 * serverErrorBackoff},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGetRepositoryRetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Write([]byte(`{"full_name": "liweiyi88/onedump"}`))
+	})
+
+	ghRepo, err := client.GetRepository(context.Background(), "liweiyi88/onedump")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ghRepo.FullName != "liweiyi88/onedump" || requests.Load() != 3 {
+		t.Errorf("expected the repository after 3 requests but got: %s after %d requests", ghRepo.FullName, requests.Load())
+	}
+}
+
+func TestQuotaWaitsBeforeSending(t *testing.T) {
+	var requests atomic.Int32
+
+	client, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"login": "liweiyi88"}`))
+	})
+
+	client.Tokens().tokens[0].quota = Quota{Limit: 5000, Remaining: 0, Reset: time.Now().Add(time.Minute), Known: true}
+
+	if client.HasQuota(1) {
+		t.Error("expected no quota left")
+	}
+
+	if _, err := client.GetDeveloper(context.Background(), "liweiyi88"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*waits) != 1 || requests.Load() != 1 {
+		t.Errorf("expected to wait for the reset before sending the request, waits: %v, requests: %d", *waits, requests.Load())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client.sleep = nil
+	client.Tokens().tokens[0].quota = Quota{Limit: 5000, Remaining: 0, Reset: time.Now().Add(time.Hour), Known: true}
+
+	if _, err := client.GetDeveloper(ctx, "liweiyi88"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected waiting for the reset to stop with the context but got: %v", err)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 00:00:00 GMT")
+		w.Write([]byte(`{"full_name": "liweiyi88/onedump", "login": "liweiyi88"}`))
+	})
+
+	store := &memETagStore{etags: make(map[string]model.GithubEtag)}
+	client.SetETagStore(store)
+
+	ctx := context.Background()
+
+	first, etag, firstErr := client.GetRepositoryIfChanged(ctx, "liweiyi88/onedump")
+
+	// The validators are only saved once the caller stored the resource.
+	_, saved := store.etags["/repos/liweiyi88/onedump"]
+	client.saveETag(ctx, etag)
+
+	_, _, secondErr := client.GetRepositoryIfChanged(ctx, "liweiyi88/onedump")
+	unconditional, unconditionalErr := client.GetRepository(ctx, "liweiyi88/onedump")
+	_, _, developerErr := client.GetDeveloperIfChanged(ctx, "liweiyi88")
+
+	expcts := []expectation{
+		{firstErr, nil},
+		{first.FullName, "liweiyi88/onedump"},
+		{saved, false},
+		{store.etags["/repos/liweiyi88/onedump"].ETag, `"v1"`},
+		{store.etags["/repos/liweiyi88/onedump"].LastModified, "Mon, 01 Jan 2024 00:00:00 GMT"},
+		{errors.Is(secondErr, ErrNotModified), true},
+		{unconditionalErr, nil},
+		{unconditional.FullName, "liweiyi88/onedump"},
+		{developerErr, nil},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestSyncKeepsNoETagWhenUpdateFails(t *testing.T) {
+	var conditional atomic.Int32
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`{"full_name": "liweiyi88/onedump", "stargazers_count": 100}`))
+	})
+
+	store := &memETagStore{etags: make(map[string]model.GithubEtag)}
+	client.SetETagStore(store)
+
+	db := newFailingDB(t)
+	handler := NewSyncHandler(db, model.NewGhRepositoryRepo(db), model.NewDeveloperRepo(db), client)
+	repositories := []model.GhRepository{{Id: 1, FullName: "liweiyi88/onedump"}}
+	ctx := context.Background()
+
+	firstErr := handler.updateRepositories(ctx, repositories, &syncStats{})
+
+	// The repository is fetched again rather than answered with 304, as its update was not saved.
+	secondErr := handler.updateRepositories(ctx, repositories, &syncStats{})
+
+	_, saved := store.etags["/repos/liweiyi88/onedump"]
+
+	expcts := []expectation{
+		{firstErr != nil, true},
+		{secondErr != nil, true},
+		{saved, false},
+		{conditional.Load(), int32(0)},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestSyncStatsHitRate(t *testing.T) {
+	stats := &syncStats{}
+
+	if stats.hitRate() != 0 {
+		t.Errorf("expected no hit rate without requests but got: %v", stats.hitRate())
+	}
+
+	stats.requested.Add(4)
+	stats.notModified.Add(3)
+
+	if stats.hitRate() != 0.75 {
+		t.Errorf("expected hit rate 0.75 but got: %v", stats.hitRate())
+	}
 }
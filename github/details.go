@@ -0,0 +1,69 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"log/slog"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+	"golang.org/x/sync/errgroup"
+)
+
+// Details fetches the details of repositories and developers in batches, it is implemented by both the rest and the graphql client.
+// Repositories and developers that do not exist or are blocked on GitHub are missing from the result.
+type Details interface {
+	GetRepositories(ctx context.Context, fullNames []string) (map[string]model.GhRepository, error)
+	GetDevelopers(ctx context.Context, usernames []string) (map[string]model.Developer, error)
+}
+
+// Fetch each item with its own request, get returns false when the item does not exist or is blocked.
+func getEach[T any](ctx context.Context, names []string, get func(ctx context.Context, name string) (T, error)) (map[string]T, error) {
+	group, ctx := errgroup.WithContext(ctx)
+
+	// Follow the github best practice to avoid reaching secondary rate limit
+	// see https://docs.github.com/en/rest/guides/best-practices-for-using-the-rest-api?apiVersion=2022-11-28#dealing-with-secondary-rate-limits
+	limiter := time.NewTicker(20 * time.Millisecond)
+	defer limiter.Stop()
+
+	var mu sync.Mutex
+	items := make(map[string]T, len(names))
+
+	for _, name := range names {
+		<-limiter.C
+
+		group.Go(func() error {
+			item, err := get(ctx, name)
+
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrAccessBlocked) {
+				slog.Info(fmt.Sprintf("skip %s, error: %v", name, err))
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			items[name] = item
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	err := group.Wait()
+
+	return items, err
+}
+
+func (ghClient *Client) GetRepositories(ctx context.Context, fullNames []string) (map[string]model.GhRepository, error) {
+	return getEach(ctx, fullNames, ghClient.GetRepository)
+}
+
+func (ghClient *Client) GetDevelopers(ctx context.Context, usernames []string) (map[string]model.Developer, error) {
+	return getEach(ctx, usernames, ghClient.GetDeveloper)
+}
@@ -0,0 +1,436 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/liweiyi88/trendshift-backend/jobrun"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+	"github.com/liweiyi88/trendshift-backend/utils/sliceutils"
+)
+
+// GitHub allows up to 100 nodes per connection, the same limit keeps a batch query well below the query complexity limit.
+const graphQLBatchSize = 100
+
+const graphQLRepositoryFields = `databaseId nameWithOwner stargazerCount forkCount description homepageUrl
+	primaryLanguage { name } defaultBranchRef { name } owner { login avatarUrl }
+	repositoryTopics(first: 20) { nodes { topic { name } } }
+	licenseInfo { spdxId } isArchived isFork parent { nameWithOwner }
+	issues(states: OPEN) { totalCount } pullRequests(states: OPEN) { totalCount } watchers { totalCount }
+	diskUsage pushedAt createdAt`
+
+// Trending developers can be organizations, repositoryOwner resolves both users and organizations.
+const graphQLOwnerFields = `__typename
+	... on User { databaseId login name avatarUrl company websiteUrl location email bio twitterUsername
+		repositories(privacy: PUBLIC) { totalCount } gists(privacy: PUBLIC) { totalCount } followers { totalCount } following { totalCount } }
+	... on Organization { databaseId login name avatarUrl websiteUrl location email description twitterUsername
+		repositories(privacy: PUBLIC) { totalCount } }`
+
+// GitHub GraphQL api client, it fetches the details of up to 100 repositories or developers per query.
+// The graphql api has its own rate limit per token, so it rotates the requests over its own token pool.
+type GraphQLClient struct {
+	api    apiConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+	tokens *TokenPool
+}
+
+func NewGraphQLClient(tokens ...string) *GraphQLClient {
+	return NewGraphQLClientWithOptions(Options{Tokens: tokens})
+}
+
+func NewGraphQLClientWithOptions(options Options) *GraphQLClient {
+	return &GraphQLClient{
+		api:    newAPIConfig(options),
+		tokens: newOptionsTokenPool(options),
+	}
+}
+
+func (gc *GraphQLClient) Tokens() *TokenPool {
+	return gc.tokens
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []graphQLError             `json:"errors"`
+}
+
+type graphQLCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+type graphQLName struct {
+	Name string `json:"name"`
+}
+
+type graphQLRepository struct {
+	DatabaseId       int          `json:"databaseId"`
+	NameWithOwner    string       `json:"nameWithOwner"`
+	StargazerCount   int          `json:"stargazerCount"`
+	ForkCount        int          `json:"forkCount"`
+	Description      string       `json:"description"`
+	HomepageUrl      string       `json:"homepageUrl"`
+	PrimaryLanguage  *graphQLName `json:"primaryLanguage"`
+	DefaultBranchRef *graphQLName `json:"defaultBranchRef"`
+	Owner            struct {
+		Login     string `json:"login"`
+		AvatarUrl string `json:"avatarUrl"`
+	} `json:"owner"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic graphQLName `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+	LicenseInfo *struct {
+		SpdxId string `json:"spdxId"`
+	} `json:"licenseInfo"`
+	IsArchived bool `json:"isArchived"`
+	IsFork     bool `json:"isFork"`
+	Parent     *struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"parent"`
+	Issues       graphQLCount     `json:"issues"`
+	PullRequests graphQLCount     `json:"pullRequests"`
+	Watchers     graphQLCount     `json:"watchers"`
+	DiskUsage    int              `json:"diskUsage"`
+	PushedAt     dbutils.NullTime `json:"pushedAt"`
+	CreatedAt    dbutils.NullTime `json:"createdAt"`
+}
+
+type graphQLOwner struct {
+	Typename        string       `json:"__typename"`
+	DatabaseId      int          `json:"databaseId"`
+	Login           string       `json:"login"`
+	Name            string       `json:"name"`
+	AvatarUrl       string       `json:"avatarUrl"`
+	Company         string       `json:"company"`
+	WebsiteUrl      string       `json:"websiteUrl"`
+	Location        string       `json:"location"`
+	Email           string       `json:"email"`
+	Bio             string       `json:"bio"`
+	Description     string       `json:"description"`
+	TwitterUsername string       `json:"twitterUsername"`
+	Repositories    graphQLCount `json:"repositories"`
+	Gists           graphQLCount `json:"gists"`
+	Followers       graphQLCount `json:"followers"`
+	Following       graphQLCount `json:"following"`
+}
+
+func (r graphQLRepository) toRepository() model.GhRepository {
+	repository := model.GhRepository{
+		GhrId:       r.DatabaseId,
+		FullName:    r.NameWithOwner,
+		Owner:       model.Owner{Name: r.Owner.Login, AvatarUrl: r.Owner.AvatarUrl},
+		Forks:       r.ForkCount,
+		Stars:       r.StargazerCount,
+		Description: dbutils.NewNullString(r.Description),
+		Homepage:    dbutils.NewNullString(r.HomepageUrl),
+		Archived:    r.IsArchived,
+		Fork:        r.IsFork,
+		OpenIssues:  r.Issues.TotalCount + r.PullRequests.TotalCount, // the rest api counts open pull requests as issues.
+		Subscribers: r.Watchers.TotalCount,
+		Size:        r.DiskUsage,
+		PushedAt:    r.PushedAt,
+		GhCreatedAt: r.CreatedAt,
+	}
+
+	if r.LicenseInfo != nil {
+		repository.License.SpdxId = dbutils.NewNullString(r.LicenseInfo.SpdxId)
+	}
+
+	if r.Parent != nil {
+		repository.Parent.FullName = dbutils.NewNullString(r.Parent.NameWithOwner)
+	}
+
+	if r.PrimaryLanguage != nil {
+		repository.Language = r.PrimaryLanguage.Name
+	}
+
+	if r.DefaultBranchRef != nil {
+		repository.DefaultBranch = dbutils.NewNullString(r.DefaultBranchRef.Name)
+	}
+
+	repository.Topics = make(model.Topics, 0, len(r.RepositoryTopics.Nodes))
+
+	for _, node := range r.RepositoryTopics.Nodes {
+		repository.Topics = append(repository.Topics, node.Topic.Name)
+	}
+
+	return repository
+}
+
+func (o graphQLOwner) toDeveloper() model.Developer {
+	bio := o.Bio
+
+	if o.Typename == "Organization" {
+		bio = o.Description
+	}
+
+	return model.Developer{
+		GhId:            o.DatabaseId,
+		Username:        o.Login,
+		AvatarUrl:       o.AvatarUrl,
+		Name:            dbutils.NewNullString(o.Name),
+		Company:         dbutils.NewNullString(o.Company),
+		Blog:            dbutils.NewNullString(o.WebsiteUrl),
+		Location:        dbutils.NewNullString(o.Location),
+		Email:           dbutils.NewNullString(o.Email),
+		Bio:             dbutils.NewNullString(bio),
+		TwitterUsername: dbutils.NewNullString(o.TwitterUsername),
+		PublicRepos:     o.Repositories.TotalCount,
+		PublicGists:     o.Gists.TotalCount,
+		Followers:       o.Followers.TotalCount,
+		Following:       o.Following.TotalCount,
+	}
+}
+
+func (gc *GraphQLClient) wait(ctx context.Context, d time.Duration) error {
+	if gc.sleep != nil {
+		return gc.sleep(ctx, d)
+	}
+
+	return sleep(ctx, d)
+}
+
+// Send a query and return the data keyed by alias, the alias of a missing or blocked node is null.
+// It waits and retries when the rate limit is exceeded and retries with backoff on server errors.
+func (gc *GraphQLClient) query(ctx context.Context, query string, variables map[string]any) (map[string]json.RawMessage, error) {
+	payload, err := json.Marshal(graphQLRequest{Query: query, Variables: variables})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode graphql query: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := waitForToken(ctx, gc.tokens, gc.wait)
+
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, gc.api.graphQLURL(), bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+
+		credential, err := token.credential(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if credential != "" {
+			req.Header.Set("Authorization", "Bearer "+credential)
+		}
+
+		res, err := gc.api.do(req)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to send graphql request: %w", err)
+		}
+
+		body, err := io.ReadAll(res.Body)
+
+		if closeErr := res.Body.Close(); closeErr != nil {
+			slog.Error("failed to close graphql response body", slog.Any("error", closeErr))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read graphql response body: %v", err)
+		}
+
+		gc.tokens.record(token, res.Header)
+		jobrun.Count(ctx).Requested(token.label)
+
+		slog.Info("fetching graphql batch", slog.String("token", token.label), slog.Group("github",
+			slog.String("X-Ratelimit-Limit", res.Header.Get("X-Ratelimit-Limit")),
+			slog.String("X-Ratelimit-Remaining", res.Header.Get("X-Ratelimit-Remaining")),
+			slog.String("X-Ratelimit-Reset", res.Header.Get("X-Ratelimit-Reset")),
+		))
+
+		if res.StatusCode == http.StatusUnauthorized && retryUnauthorized(gc.tokens, token, &attempt) {
+			continue
+		}
+
+		var response graphQLResponse
+
+		if res.StatusCode == http.StatusOK {
+			if err := json.Unmarshal(body, &response); err != nil {
+				return nil, fmt.Errorf("failed to decode graphql response: %v, received: %s", err, string(body))
+			}
+		}
+
+		wait, rateLimited := rateLimitWait(res, body)
+
+		// GitHub answers 200 with a RATE_LIMITED error when the point budget of the graphql api is used up.
+		for _, graphQLErr := range response.Errors {
+			if graphQLErr.Type == "RATE_LIMITED" && !rateLimited {
+				wait, rateLimited = defaultSecondaryRateLimitWait, true
+			}
+		}
+
+		if rateLimited {
+			if attempt >= maxRetries {
+				return nil, fmt.Errorf("%w, graphql query", ErrRateLimited)
+			}
+
+			if err := retryRateLimited(ctx, gc.tokens, token, wait, gc.wait); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if res.StatusCode >= http.StatusInternalServerError && attempt < maxRetries {
+			backoff := serverErrorBackoff << attempt
+			slog.Warn("GitHub graphql server error, retrying", slog.Int("status", res.StatusCode), slog.Duration("backoff", backoff))
+
+			if err := gc.wait(ctx, backoff); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("graphql request is not successful, get status code: %d, body: %s", res.StatusCode, string(body))
+		}
+
+		// Missing and blocked nodes are reported as errors along with the data of the other nodes.
+		for _, graphQLErr := range response.Errors {
+			if graphQLErr.Type != "NOT_FOUND" && graphQLErr.Type != "FORBIDDEN" {
+				return nil, fmt.Errorf("graphql query failed, type: %s, error: %s", graphQLErr.Type, graphQLErr.Message)
+			}
+		}
+
+		return response.Data, nil
+	}
+}
+
+// Build a query that fetches a node per variable set with aliases, e.g. n0: repository(owner: $owner0, name: $name0) { ... }.
+func buildBatchQuery(field string, params []string, count int, fields string) string {
+	var declarations, selections strings.Builder
+
+	for i := range count {
+		arguments := make([]string, 0, len(params))
+
+		for _, param := range params {
+			fmt.Fprintf(&declarations, "$%s%d: String!, ", param, i)
+			arguments = append(arguments, fmt.Sprintf("%s: $%s%d", param, param, i))
+		}
+
+		fmt.Fprintf(&selections, "n%d: %s(%s) { %s }\n", i, field, strings.Join(arguments, ", "), fields)
+	}
+
+	return fmt.Sprintf("query(%s) {\n%s}", strings.TrimSuffix(declarations.String(), ", "), selections.String())
+}
+
+// Fetch repositories by full name, e.g. liweiyi88/onedump, the result is keyed by the given full names.
+// Repositories that do not exist or are blocked are missing from the result.
+func (gc *GraphQLClient) GetRepositories(ctx context.Context, fullNames []string) (map[string]model.GhRepository, error) {
+	repositories := make(map[string]model.GhRepository, len(fullNames))
+
+	valid := make([]string, 0, len(fullNames))
+
+	for _, fullName := range fullNames {
+		if owner, name, ok := strings.Cut(fullName, "/"); ok && owner != "" && name != "" {
+			valid = append(valid, fullName)
+		}
+	}
+
+	for _, batch := range sliceutils.Chunk[string](valid, graphQLBatchSize) {
+		variables := make(map[string]any, len(batch)*2)
+
+		for i, fullName := range batch {
+			owner, name, _ := strings.Cut(fullName, "/")
+			variables[fmt.Sprintf("owner%d", i)] = owner
+			variables[fmt.Sprintf("name%d", i)] = name
+		}
+
+		data, err := gc.query(ctx, buildBatchQuery("repository", []string{"owner", "name"}, len(batch), graphQLRepositoryFields), variables)
+
+		if err != nil {
+			return repositories, fmt.Errorf("failed to fetch repositories: %v", err)
+		}
+
+		for i, fullName := range batch {
+			raw, ok := data[fmt.Sprintf("n%d", i)]
+
+			if !ok {
+				continue
+			}
+
+			var repository *graphQLRepository
+
+			if err := json.Unmarshal(raw, &repository); err != nil {
+				return repositories, fmt.Errorf("failed to decode repository %s: %v", fullName, err)
+			}
+
+			if repository != nil {
+				repositories[fullName] = repository.toRepository()
+			}
+		}
+	}
+
+	return repositories, nil
+}
+
+// Fetch developers by username, the result is keyed by the given usernames.
+// Developers that do not exist are missing from the result.
+func (gc *GraphQLClient) GetDevelopers(ctx context.Context, usernames []string) (map[string]model.Developer, error) {
+	developers := make(map[string]model.Developer, len(usernames))
+
+	for _, batch := range sliceutils.Chunk[string](usernames, graphQLBatchSize) {
+		variables := make(map[string]any, len(batch))
+
+		for i, username := range batch {
+			variables[fmt.Sprintf("login%d", i)] = username
+		}
+
+		data, err := gc.query(ctx, buildBatchQuery("repositoryOwner", []string{"login"}, len(batch), graphQLOwnerFields), variables)
+
+		if err != nil {
+			return developers, fmt.Errorf("failed to fetch developers: %v", err)
+		}
+
+		for i, username := range batch {
+			raw, ok := data[fmt.Sprintf("n%d", i)]
+
+			if !ok {
+				continue
+			}
+
+			var owner *graphQLOwner
+
+			if err := json.Unmarshal(raw, &owner); err != nil {
+				return developers, fmt.Errorf("failed to decode developer %s: %v", username, err)
+			}
+
+			if owner != nil {
+				developers[username] = owner.toDeveloper()
+			}
+		}
+	}
+
+	return developers, nil
+}
@@ -0,0 +1,294 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// A fake GitHub graphql api, it answers every aliased node of a batch query with the known nodes and null for the others.
+type fakeGraphQL struct {
+	requests     atomic.Int32
+	rateLimited  atomic.Int32 // the number of requests to answer with a RATE_LIMITED error.
+	repositories map[string]map[string]any
+	owners       map[string]map[string]any
+}
+
+func (f *fakeGraphQL) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+
+	if r.URL.Path != "/graphql" || r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if f.rateLimited.Add(-1) >= 0 {
+		w.Write([]byte(`{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+		return
+	}
+
+	var request graphQLRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	data := make(map[string]any)
+	errs := make([]graphQLError, 0)
+
+	for i := 0; ; i++ {
+		alias := fmt.Sprintf("n%d", i)
+
+		if !strings.Contains(request.Query, alias+":") {
+			break
+		}
+
+		var node map[string]any
+		var name string
+
+		if login, ok := request.Variables[fmt.Sprintf("login%d", i)]; ok {
+			name = login.(string)
+			node = f.owners[name]
+		} else {
+			name = fmt.Sprintf("%s/%s", request.Variables[fmt.Sprintf("owner%d", i)], request.Variables[fmt.Sprintf("name%d", i)])
+			node = f.repositories[name]
+		}
+
+		if node == nil {
+			errs = append(errs, graphQLError{Type: "NOT_FOUND", Message: "Could not resolve " + name})
+		}
+
+		data[alias] = node
+	}
+
+	json.NewEncoder(w).Encode(map[string]any{"data": data, "errors": errs})
+}
+
+func newTestGraphQLClient(t *testing.T, fake *fakeGraphQL) (*GraphQLClient, *[]time.Duration) {
+	t.Helper()
+
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	waits := make([]time.Duration, 0)
+
+	client := NewGraphQLClientWithOptions(Options{Tokens: []string{"token"}, BaseURL: server.URL})
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+
+	return client, &waits
+}
+
+func TestBuildBatchQuery(t *testing.T) {
+	query := buildBatchQuery("repository", []string{"owner", "name"}, 2, "nameWithOwner")
+
+	want := "query($owner0: String!, $name0: String!, $owner1: String!, $name1: String!) {\n" +
+		"n0: repository(owner: $owner0, name: $name0) { nameWithOwner }\n" +
+		"n1: repository(owner: $owner1, name: $name1) { nameWithOwner }\n" +
+		"}"
+
+	if query != want {
+		t.Errorf("expect %s but got %s", want, query)
+	}
+}
+
+func TestGraphQLGetRepositories(t *testing.T) {
+	fake := &fakeGraphQL{repositories: make(map[string]map[string]any)}
+
+	fullNames := make([]string, 0)
+
+	for i := range 150 {
+		fullName := fmt.Sprintf("owner/repo%d", i)
+		fullNames = append(fullNames, fullName)
+
+		fake.repositories[fullName] = map[string]any{
+			"databaseId":       i + 1,
+			"nameWithOwner":    fullName,
+			"stargazerCount":   i * 10,
+			"forkCount":        i,
+			"description":      "description",
+			"homepageUrl":      "",
+			"primaryLanguage":  map[string]any{"name": "Go"},
+			"defaultBranchRef": map[string]any{"name": "main"},
+			"owner":            map[string]any{"login": "owner", "avatarUrl": "https://avatars.githubusercontent.com/u/1"},
+			"repositoryTopics": map[string]any{"nodes": []any{
+				map[string]any{"topic": map[string]any{"name": "llm"}},
+				map[string]any{"topic": map[string]any{"name": "go"}},
+			}},
+			"licenseInfo":  map[string]any{"spdxId": "Apache-2.0"},
+			"isArchived":   true,
+			"isFork":       false,
+			"parent":       nil,
+			"issues":       map[string]any{"totalCount": 4},
+			"pullRequests": map[string]any{"totalCount": 2},
+			"watchers":     map[string]any{"totalCount": 9},
+			"diskUsage":    2048,
+			"pushedAt":     "2024-05-10T18:00:00Z",
+			"createdAt":    "2022-09-24T08:00:00Z",
+		}
+	}
+
+	fake.repositories["owner/repo1"]["primaryLanguage"] = nil
+	fullNames = append(fullNames, "owner/missing", "invalid")
+
+	client, _ := newTestGraphQLClient(t, fake)
+
+	repositories, err := client.GetRepositories(context.Background(), fullNames)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, missing := repositories["owner/missing"]
+	_, invalid := repositories["invalid"]
+
+	expcts := []expectation{
+		{fake.requests.Load(), int32(2)},
+		{len(repositories), 150},
+		{missing, false},
+		{invalid, false},
+		{repositories["owner/repo2"].GhrId, 3},
+		{repositories["owner/repo2"].FullName, "owner/repo2"},
+		{repositories["owner/repo2"].Stars, 20},
+		{repositories["owner/repo2"].Forks, 2},
+		{repositories["owner/repo2"].Language, "Go"},
+		{repositories["owner/repo2"].Owner.Name, "owner"},
+		{repositories["owner/repo2"].Description.String, "description"},
+		{repositories["owner/repo2"].DefaultBranch.String, "main"},
+		{repositories["owner/repo2"].Homepage.Valid, false},
+		{repositories["owner/repo2"].Topics[0], "llm"},
+		{repositories["owner/repo2"].Topics[1], "go"},
+		{repositories["owner/repo2"].License.SpdxId.String, "Apache-2.0"},
+		{repositories["owner/repo2"].Archived, true},
+		{repositories["owner/repo2"].Fork, false},
+		{repositories["owner/repo2"].Parent.FullName.Valid, false},
+		{repositories["owner/repo2"].OpenIssues, 6},
+		{repositories["owner/repo2"].Subscribers, 9},
+		{repositories["owner/repo2"].Size, 2048},
+		{repositories["owner/repo2"].PushedAt.Time.Format(time.DateOnly), "2024-05-10"},
+		{repositories["owner/repo2"].GhCreatedAt.Time.Format(time.DateOnly), "2022-09-24"},
+		{repositories["owner/repo1"].Language, ""},
+		{repositories["owner/repo149"].GhrId, 150},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGraphQLGetDevelopers(t *testing.T) {
+	fake := &fakeGraphQL{owners: map[string]map[string]any{
+		"liweiyi88": {
+			"__typename":   "User",
+			"databaseId":   7248260,
+			"login":        "liweiyi88",
+			"name":         "Julian",
+			"bio":          "developer",
+			"websiteUrl":   "https://trendshift.io",
+			"repositories": map[string]any{"totalCount": 30},
+			"gists":        map[string]any{"totalCount": 2},
+			"followers":    map[string]any{"totalCount": 100},
+			"following":    map[string]any{"totalCount": 10},
+		},
+		"golang": {
+			"__typename":   "Organization",
+			"databaseId":   4314092,
+			"login":        "golang",
+			"description":  "The Go Programming Language",
+			"repositories": map[string]any{"totalCount": 60},
+		},
+	}}
+
+	client, _ := newTestGraphQLClient(t, fake)
+
+	developers, err := client.GetDevelopers(context.Background(), []string{"liweiyi88", "golang", "missing"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, missing := developers["missing"]
+
+	expcts := []expectation{
+		{fake.requests.Load(), int32(1)},
+		{len(developers), 2},
+		{missing, false},
+		{developers["liweiyi88"].GhId, 7248260},
+		{developers["liweiyi88"].Name.String, "Julian"},
+		{developers["liweiyi88"].Bio.String, "developer"},
+		{developers["liweiyi88"].Blog.String, "https://trendshift.io"},
+		{developers["liweiyi88"].PublicRepos, 30},
+		{developers["liweiyi88"].PublicGists, 2},
+		{developers["liweiyi88"].Followers, 100},
+		{developers["liweiyi88"].Following, 10},
+		{developers["golang"].Username, "golang"},
+		{developers["golang"].Bio.String, "The Go Programming Language"},
+		{developers["golang"].PublicRepos, 60},
+		{developers["golang"].Company.Valid, false},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestGraphQLWaitsForRateLimit(t *testing.T) {
+	fake := &fakeGraphQL{owners: map[string]map[string]any{
+		"liweiyi88": {"__typename": "User", "login": "liweiyi88"},
+	}}
+
+	fake.rateLimited.Store(1)
+
+	client, waits := newTestGraphQLClient(t, fake)
+
+	developers, err := client.GetDevelopers(context.Background(), []string{"liweiyi88"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expcts := []expectation{
+		{fake.requests.Load(), int32(2)},
+		{len(*waits), 1},
+		{(*waits)[0], defaultSecondaryRateLimitWait},
+		{developers["liweiyi88"].Username, "liweiyi88"},
+	}
+
+	assertExpects(t, expcts)
+
+	fake.rateLimited.Store(maxRetries + 1)
+
+	if _, err := client.GetDevelopers(context.Background(), []string{"liweiyi88"}); err == nil || !strings.Contains(err.Error(), ErrRateLimited.Error()) {
+		t.Errorf("expected rate limited error but got: %v", err)
+	}
+}
+
+func TestClientGetRepositoriesSkipsMissing(t *testing.T) {
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/blocked":
+			w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		case "/repos/owner/found":
+			w.Write([]byte(`{"full_name": "owner/found"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	var details Details = client
+
+	repositories, err := details.GetRepositories(context.Background(), []string{"owner/found", "owner/missing", "owner/blocked"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repositories) != 1 || repositories["owner/found"].FullName != "owner/found" {
+		t.Errorf("expected only owner/found but got: %v", repositories)
+	}
+}
@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+// The actual and wanted value of a test, the expectations of a test are checked together with assertExpects.
+type expectation struct {
+	actual any
+	want   any
+}
+
+func assertExpects(t *testing.T, expcts []expectation) {
+	t.Helper()
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+type memETagStore struct {
+	mu    sync.Mutex
+	etags map[string]model.GithubEtag
+}
+
+func (s *memETagStore) Find(ctx context.Context, resource string) (model.GithubEtag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag, ok := s.etags[resource]
+
+	if !ok {
+		return etag, sql.ErrNoRows
+	}
+
+	return etag, nil
+}
+
+func (s *memETagStore) Save(ctx context.Context, etag model.GithubEtag) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.etags[etag.Resource] = etag
+	return nil
+}
+
+var errDBDown = errors.New("db down")
+
+// A connector that can never connect, e.g. when the db is down.
+type failingConnector struct{}
+
+func (failingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return nil, errDBDown
+}
+
+func (c failingConnector) Driver() driver.Driver {
+	return c
+}
+
+func (failingConnector) Open(name string) (driver.Conn, error) {
+	return nil, errDBDown
+}
+
+// A db that fails every query with errDBDown, including the rows scanned from QueryRowContext.
+func newFailingDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := sql.OpenDB(failingConnector{})
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+type memReadmeStore struct {
+	readmes map[int]model.RepositoryReadme
+	saved   int
+}
+
+func (s *memReadmeStore) Find(ctx context.Context, repositoryId int) (model.RepositoryReadme, error) {
+	readme, ok := s.readmes[repositoryId]
+
+	if !ok {
+		return readme, sql.ErrNoRows
+	}
+
+	return readme, nil
+}
+
+func (s *memReadmeStore) Save(ctx context.Context, readme model.RepositoryReadme) error {
+	s.saved++
+	s.readmes[readme.RepositoryId] = readme
+	return nil
+}
+
+func (s *memReadmeStore) Delete(ctx context.Context, repositoryId int) error {
+	delete(s.readmes, repositoryId)
+	return nil
+}
+
+type memReleaseStore struct {
+	releases map[string]model.Release
+	saves    int
+	err      error // returned by Save when set.
+}
+
+func (s *memReleaseStore) FindVersions(ctx context.Context, repositoryId int) (map[string]bool, error) {
+	versions := make(map[string]bool)
+
+	for version := range s.releases {
+		versions[version] = true
+	}
+
+	return versions, nil
+}
+
+func (s *memReleaseStore) Save(ctx context.Context, releases []model.Release) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	s.saves++
+
+	for _, release := range releases {
+		s.releases[release.Version] = release
+	}
+
+	return nil
+}
+
+type memStarHistoryStore struct {
+	history map[int][]model.RepositoryMetric
+}
+
+func (s *memStarHistoryStore) SaveStarHistory(ctx context.Context, repositoryId int, history []model.RepositoryMetric) error {
+	s.history[repositoryId] = history
+	return nil
+}
@@ -0,0 +1,89 @@
+package github
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTimeout   = 30 * time.Second
+	defaultUserAgent = "trendshift-backend"
+)
+
+var defaultHTTPClient = &http.Client{Timeout: defaultTimeout}
+
+// Options of the rest and graphql clients, the zero value talks to api.github.com with anonymous requests.
+type Options struct {
+	Tokens []string // the personal access tokens to rotate the requests over.
+	App    *AppAuth // authenticates as a GitHub App installation instead of the tokens when set.
+
+	BaseURL    string            // the rest api url, e.g. https://github.example.com/api/v3 for GitHub Enterprise Server.
+	HTTPClient *http.Client      // sends the requests, Transport and Timeout are ignored when it is set.
+	Transport  http.RoundTripper // defaults to http.DefaultTransport.
+	Timeout    time.Duration     // the timeout of a single request, defaults to 30s.
+	UserAgent  string            // GitHub rejects requests without a user agent, defaults to trendshift-backend.
+}
+
+// The api settings shared by the rest client, the graphql client and the app auth.
+type apiConfig struct {
+	baseURL   string
+	client    *http.Client
+	userAgent string
+}
+
+func newAPIConfig(options Options) apiConfig {
+	client := options.HTTPClient
+
+	if client == nil {
+		timeout := options.Timeout
+
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+
+		client = &http.Client{Transport: options.Transport, Timeout: timeout}
+	}
+
+	return apiConfig{
+		baseURL:   strings.TrimSuffix(options.BaseURL, "/"),
+		client:    client,
+		userAgent: options.UserAgent,
+	}
+}
+
+func (api apiConfig) url(path string) string {
+	if api.baseURL == "" {
+		return apiBaseURL + path
+	}
+
+	return api.baseURL + path
+}
+
+// GitHub Enterprise Server serves the rest api under /api/v3 and the graphql api under /api/graphql.
+func (api apiConfig) graphQLURL() string {
+	if base, ok := strings.CutSuffix(api.baseURL, "/api/v3"); ok {
+		return base + "/api/graphql"
+	}
+
+	return api.url("/graphql")
+}
+
+// Send the request with the user agent, the request is cancelled with its context.
+func (api apiConfig) do(req *http.Request) (*http.Response, error) {
+	userAgent := api.userAgent
+
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	client := api.client
+
+	if client == nil {
+		client = defaultHTTPClient
+	}
+
+	return client.Do(req)
+}
@@ -0,0 +1,154 @@
+package github
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/liweiyi88/trendshift-backend/markdown"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// The README of a repository from the rest api, see https://docs.github.com/en/rest/repos/contents#get-a-repository-readme
+type Readme struct {
+	Path        string `json:"path"`
+	Sha         string `json:"sha"`
+	Content     string `json:"content"`
+	Encoding    string `json:"encoding"`
+	DownloadUrl string `json:"download_url"`
+}
+
+// Decode the content of the README, GitHub sends it base64 encoded.
+func (readme Readme) Text() (string, error) {
+	if readme.Encoding != "base64" {
+		return readme.Content, nil
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(readme.Content, "\n", ""))
+
+	if err != nil {
+		return "", fmt.Errorf("failed to decode readme content: %v", err)
+	}
+
+	return string(content), nil
+}
+
+// The url that relative images of the README are resolved against, it is the raw url of the directory of the README.
+func (readme Readme) baseURL() string {
+	if i := strings.LastIndex(readme.DownloadUrl, "/"); i >= 0 {
+		return readme.DownloadUrl[:i+1]
+	}
+
+	return ""
+}
+
+func (ghClient *Client) GetReadme(ctx context.Context, fullName string) (Readme, model.GithubEtag, error) {
+	var readme Readme
+
+	etag, err := ghClient.get(ctx, "/repos/"+fullName+"/readme", &readme, false)
+
+	return readme, etag, err
+}
+
+// Get the README unless it has not changed since it was fetched, then it returns ErrNotModified.
+func (ghClient *Client) GetReadmeIfChanged(ctx context.Context, fullName string) (Readme, model.GithubEtag, error) {
+	var readme Readme
+
+	etag, err := ghClient.get(ctx, "/repos/"+fullName+"/readme", &readme, true)
+
+	return readme, etag, err
+}
+
+// ReadmeStore keeps the extracted READMEs of repositories, see model.RepositoryReadmeRepo.
+type ReadmeStore interface {
+	Find(ctx context.Context, repositoryId int) (model.RepositoryReadme, error)
+	Save(ctx context.Context, readme model.RepositoryReadme) error
+	Delete(ctx context.Context, repositoryId int) error
+}
+
+// ReadmeFetcher fetches the READMEs of saved repositories and stores their excerpt and first image.
+type ReadmeFetcher struct {
+	client *Client
+	store  ReadmeStore
+}
+
+func NewReadmeFetcher(client *Client, store ReadmeStore) *ReadmeFetcher {
+	return &ReadmeFetcher{
+		client: client,
+		store:  store,
+	}
+}
+
+// Refresh the README of a saved repository, the content is only extracted again when the blob sha of the README changed.
+// A README that was fetched before is requested conditionally.
+func (rf *ReadmeFetcher) Refresh(ctx context.Context, repository model.GhRepository) error {
+	stored, err := rf.store.Find(ctx, repository.Id)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find readme of repository %s: %v", repository.FullName, err)
+	}
+
+	fetched := err == nil
+
+	var readme Readme
+	var etag model.GithubEtag
+
+	if fetched {
+		readme, etag, err = rf.client.GetReadmeIfChanged(ctx, repository.FullName)
+	} else {
+		readme, etag, err = rf.client.GetReadme(ctx, repository.FullName)
+	}
+
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+
+	if errors.Is(err, ErrNotFound) {
+		if fetched {
+			slog.Info(fmt.Sprintf("readme removed from repository: %s", repository.FullName))
+			return rf.store.Delete(ctx, repository.Id)
+		}
+
+		return nil
+	}
+
+	if errors.Is(err, ErrAccessBlocked) {
+		slog.Info(fmt.Sprintf("readme access blocked, repository: %s", repository.FullName))
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get readme of repository %s from GitHub: %v", repository.FullName, err)
+	}
+
+	if fetched && stored.Sha == readme.Sha {
+		rf.client.saveETag(ctx, etag)
+		return nil
+	}
+
+	text, err := readme.Text()
+
+	if err != nil {
+		return fmt.Errorf("failed to read readme of repository %s: %v", repository.FullName, err)
+	}
+
+	err = rf.store.Save(ctx, model.RepositoryReadme{
+		RepositoryId: repository.Id,
+		Sha:          readme.Sha,
+		Excerpt:      markdown.Excerpt(text),
+		ImageUrl:     dbutils.NewNullString(markdown.Image(text, readme.baseURL())),
+	})
+
+	if err != nil {
+		return err
+	}
+
+	rf.client.saveETag(ctx, etag)
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+func TestReadmeFetcherRefresh(t *testing.T) {
+	var sha atomic.Value
+	sha.Store("sha1")
+
+	var removed atomic.Bool
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/liweiyi88/onedump/readme" || removed.Load() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		content := "# onedump\n\n![logo](docs/logo.png)\n\nDatabase backup with **one** command."
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"path":         "README.md",
+			"sha":          sha.Load(),
+			"content":      base64.StdEncoding.EncodeToString([]byte(content)),
+			"encoding":     "base64",
+			"download_url": "https://raw.githubusercontent.com/liweiyi88/onedump/main/README.md",
+		})
+	})
+
+	store := &memReadmeStore{readmes: make(map[int]model.RepositoryReadme)}
+	fetcher := NewReadmeFetcher(client, store)
+	repository := model.GhRepository{Id: 1, FullName: "liweiyi88/onedump"}
+	ctx := context.Background()
+
+	firstErr := fetcher.Refresh(ctx, repository)
+	first := store.readmes[1]
+
+	// The README is not extracted again while its sha is unchanged.
+	unchangedErr := fetcher.Refresh(ctx, repository)
+	savedUnchanged := store.saved
+
+	sha.Store("sha2")
+	changedErr := fetcher.Refresh(ctx, repository)
+
+	removed.Store(true)
+	removedErr := fetcher.Refresh(ctx, repository)
+	_, exists := store.readmes[1]
+
+	missingErr := fetcher.Refresh(ctx, model.GhRepository{Id: 2, FullName: "liweiyi88/missing"})
+
+	expcts := []expectation{
+		{firstErr, nil},
+		{first.Sha, "sha1"},
+		{first.Excerpt, "Database backup with one command."},
+		{first.ImageUrl.String, "https://raw.githubusercontent.com/liweiyi88/onedump/main/docs/logo.png"},
+		{unchangedErr, nil},
+		{savedUnchanged, 1},
+		{changedErr, nil},
+		{store.saved, 2},
+		{removedErr, nil},
+		{exists, false},
+		{missingErr, nil},
+	}
+
+	assertExpects(t, expcts)
+}
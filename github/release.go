@@ -0,0 +1,179 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Only the latest releases and tags are fetched on every sync, older ones are saved by the previous syncs.
+const latestReleasesCount = 5
+
+// A release from the rest api, see https://docs.github.com/en/rest/releases/releases#list-releases
+type Release struct {
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// A git tag from the rest api, see https://docs.github.com/en/rest/repos/repos#list-repository-tags
+type RepositoryTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		Sha string `json:"sha"`
+	} `json:"commit"`
+}
+
+type commit struct {
+	Commit struct {
+		Committer struct {
+			Date time.Time `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+}
+
+// Get the latest releases, a conditional request returns ErrNotModified when they have not changed since they were fetched.
+func (ghClient *Client) GetReleases(ctx context.Context, fullName string, conditional bool) ([]Release, model.GithubEtag, error) {
+	var releases []Release
+
+	etag, err := ghClient.get(ctx, fmt.Sprintf("/repos/%s/releases?per_page=%d", fullName, latestReleasesCount), &releases, conditional)
+
+	return releases, etag, err
+}
+
+// Get the latest tags, a conditional request returns ErrNotModified when they have not changed since they were fetched.
+func (ghClient *Client) GetTags(ctx context.Context, fullName string, conditional bool) ([]RepositoryTag, model.GithubEtag, error) {
+	var tags []RepositoryTag
+
+	etag, err := ghClient.get(ctx, fmt.Sprintf("/repos/%s/tags?per_page=%d", fullName, latestReleasesCount), &tags, conditional)
+
+	return tags, etag, err
+}
+
+// Get the date of a commit, it is the publish date of a tag without a GitHub release.
+func (ghClient *Client) GetCommitDate(ctx context.Context, fullName string, sha string) (time.Time, error) {
+	var c commit
+
+	_, err := ghClient.get(ctx, fmt.Sprintf("/repos/%s/commits/%s", fullName, sha), &c, false)
+
+	return c.Commit.Committer.Date, err
+}
+
+// ReleaseStore keeps the releases of repositories, see model.ReleaseRepo.
+type ReleaseStore interface {
+	FindVersions(ctx context.Context, repositoryId int) (map[string]bool, error)
+	Save(ctx context.Context, releases []model.Release) error
+}
+
+// ReleaseFetcher tracks the latest releases and tags of repositories.
+type ReleaseFetcher struct {
+	client *Client
+	store  ReleaseStore
+}
+
+func NewReleaseFetcher(client *Client, store ReleaseStore) *ReleaseFetcher {
+	return &ReleaseFetcher{
+		client: client,
+		store:  store,
+	}
+}
+
+// Whether the error means the repository has nothing to fetch, e.g. it is missing or blocked on GitHub or the list has not changed.
+func isUnavailable(err error) bool {
+	return errors.Is(err, ErrNotModified) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrAccessBlocked)
+}
+
+// Save the latest releases of a repository along with its latest tags that have no GitHub release, the lists are requested conditionally.
+func (rf *ReleaseFetcher) Refresh(ctx context.Context, repository model.GhRepository) error {
+	versions, err := rf.store.FindVersions(ctx, repository.Id)
+
+	if err != nil {
+		return err
+	}
+
+	ghReleases, releasesETag, err := rf.client.GetReleases(ctx, repository.FullName, true)
+
+	if err != nil && !isUnavailable(err) {
+		return fmt.Errorf("failed to get releases of repository %s from GitHub: %v", repository.FullName, err)
+	}
+
+	tags, tagsETag, err := rf.client.GetTags(ctx, repository.FullName, true)
+
+	if err != nil && !isUnavailable(err) {
+		return fmt.Errorf("failed to get tags of repository %s from GitHub: %v", repository.FullName, err)
+	}
+
+	releases := make([]model.Release, 0, len(ghReleases)+len(tags))
+	released := make(map[string]bool)
+
+	for _, ghRelease := range ghReleases {
+		// Drafts are only visible with push access and have not been published.
+		if ghRelease.Draft || ghRelease.PublishedAt.IsZero() {
+			continue
+		}
+
+		released[ghRelease.TagName] = true
+
+		releases = append(releases, model.Release{
+			RepositoryId: repository.Id,
+			Version:      ghRelease.TagName,
+			Name:         dbutils.NewNullString(ghRelease.Name),
+			Prerelease:   ghRelease.Prerelease,
+			Source:       model.ReleaseSourceRelease,
+			PublishedAt:  ghRelease.PublishedAt,
+		})
+	}
+
+	pending := make([]RepositoryTag, 0, len(tags))
+
+	for _, tag := range tags {
+		if !released[tag.Name] && !versions[tag.Name] {
+			pending = append(pending, tag)
+		}
+	}
+
+	// The date of every new tag costs one more request, the tags are left to the next sync when the quota is not enough.
+	if !rf.client.HasQuota(len(pending)) {
+		slog.Warn(fmt.Sprintf("GitHub quota is not enough for the commits of %d tags, skip the tags of repository %s", len(pending), repository.FullName))
+		pending, tagsETag = nil, model.GithubEtag{}
+	}
+
+	for _, tag := range pending {
+		publishedAt, err := rf.client.GetCommitDate(ctx, repository.FullName, tag.Commit.Sha)
+
+		if isUnavailable(err) {
+			slog.Info(fmt.Sprintf("skip tag %s of repository %s, error: %v", tag.Name, repository.FullName, err))
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get the commit of tag %s of repository %s from GitHub: %v", tag.Name, repository.FullName, err)
+		}
+
+		releases = append(releases, model.Release{
+			RepositoryId: repository.Id,
+			Version:      tag.Name,
+			Source:       model.ReleaseSourceTag,
+			PublishedAt:  publishedAt,
+		})
+	}
+
+	if len(releases) > 0 {
+		if err := rf.store.Save(ctx, releases); err != nil {
+			return err
+		}
+	}
+
+	// The lists are only skipped by the next conditional request once every release and tag of them is saved.
+	rf.client.saveETag(ctx, releasesETag)
+	rf.client.saveETag(ctx, tagsETag)
+
+	return nil
+}
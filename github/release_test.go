@@ -0,0 +1,125 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+func TestReleaseFetcherRefresh(t *testing.T) {
+	var commits atomic.Int32
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/liweiyi88/onedump/releases":
+			w.Write([]byte(`[
+				{"tag_name": "v2.0.0", "name": "", "draft": true, "prerelease": false, "published_at": null},
+				{"tag_name": "v1.1.0-rc.1", "name": "Release candidate", "draft": false, "prerelease": true, "published_at": "2024-05-11T08:00:00Z"},
+				{"tag_name": "v1.0.0", "name": "First release", "draft": false, "prerelease": false, "published_at": "2024-05-10T18:00:00Z"}
+			]`))
+		case "/repos/liweiyi88/onedump/tags":
+			w.Write([]byte(`[
+				{"name": "v1.1.0-rc.1", "commit": {"sha": "c3"}},
+				{"name": "v1.0.0", "commit": {"sha": "c2"}},
+				{"name": "v0.9.0", "commit": {"sha": "c1"}}
+			]`))
+		case "/repos/liweiyi88/onedump/commits/c1":
+			commits.Add(1)
+			w.Write([]byte(`{"commit": {"committer": {"date": "2024-04-01T10:00:00Z"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	store := &memReleaseStore{releases: make(map[string]model.Release)}
+	fetcher := NewReleaseFetcher(client, store)
+	ctx := context.Background()
+
+	firstErr := fetcher.Refresh(ctx, model.GhRepository{Id: 1, FullName: "liweiyi88/onedump"})
+
+	// The commit of a tag that is already saved is not requested again.
+	secondErr := fetcher.Refresh(ctx, model.GhRepository{Id: 1, FullName: "liweiyi88/onedump"})
+
+	_, draft := store.releases["v2.0.0"]
+	saves := store.saves
+
+	missingErr := fetcher.Refresh(ctx, model.GhRepository{Id: 2, FullName: "liweiyi88/missing"})
+
+	expcts := []expectation{
+		{firstErr, nil},
+		{secondErr, nil},
+		{missingErr, nil},
+		{len(store.releases), 3},
+		{draft, false},
+		{store.releases["v1.1.0-rc.1"].Prerelease, true},
+		{store.releases["v1.1.0-rc.1"].Source, model.ReleaseSourceRelease},
+		{store.releases["v1.0.0"].Name.String, "First release"},
+		{store.releases["v0.9.0"].Source, model.ReleaseSourceTag},
+		{store.releases["v0.9.0"].Name.Valid, false},
+		{store.releases["v0.9.0"].PublishedAt.Format("2006-01-02"), "2024-04-01"},
+		{commits.Load(), int32(1)},
+		{saves, 2},
+		{store.saves, 2},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestReleaseFetcherSavesETagsAfterReleases(t *testing.T) {
+	var remaining atomic.Int32
+	remaining.Store(5000)
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(int(remaining.Load())))
+		w.Header().Set("X-Ratelimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		w.Header().Set("ETag", `"v1"`)
+
+		switch r.URL.Path {
+		case "/repos/liweiyi88/onedump/releases":
+			w.Write([]byte(`[{"tag_name": "v1.0.0", "name": "First release", "draft": false, "prerelease": false, "published_at": "2024-05-10T18:00:00Z"}]`))
+		case "/repos/liweiyi88/onedump/tags":
+			w.Write([]byte(`[{"name": "v1.0.0", "commit": {"sha": "c2"}}, {"name": "v0.9.0", "commit": {"sha": "c1"}}]`))
+		default:
+			w.Write([]byte(`{"commit": {"committer": {"date": "2024-04-01T10:00:00Z"}}}`))
+		}
+	})
+
+	etags := &memETagStore{etags: make(map[string]model.GithubEtag)}
+	client.SetETagStore(etags)
+
+	store := &memReleaseStore{releases: make(map[string]model.Release), err: errors.New("db down")}
+	fetcher := NewReleaseFetcher(client, store)
+	repository := model.GhRepository{Id: 1, FullName: "liweiyi88/onedump"}
+	ctx := context.Background()
+
+	failedErr := fetcher.Refresh(ctx, repository)
+	failedETags := len(etags.etags)
+
+	// The commit of the new tag is not requested without quota, so the tags are fetched again on the next sync.
+	store.err = nil
+	remaining.Store(0)
+	limitedErr := fetcher.Refresh(ctx, repository)
+
+	_, tagSaved := store.releases["v0.9.0"]
+	_, releasesETag := etags.etags["/repos/liweiyi88/onedump/releases?per_page=5"]
+	_, tagsETag := etags.etags["/repos/liweiyi88/onedump/tags?per_page=5"]
+
+	expcts := []expectation{
+		{failedErr != nil, true},
+		{failedETags, 0},
+		{limitedErr, nil},
+		{store.releases["v1.0.0"].Source, model.ReleaseSourceRelease},
+		{tagSaved, false},
+		{releasesETag, true},
+		{tagsETag, false},
+	}
+
+	assertExpects(t, expcts)
+}
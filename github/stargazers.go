@@ -0,0 +1,149 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+const (
+	stargazersPerPage = 100
+
+	// The first 10k stars are backfilled by default, GitHub does not serve stargazers beyond page 400.
+	// see https://docs.github.com/en/rest/activity/starring#list-stargazers
+	defaultMaxStargazerPages = 100
+	stargazerPagesLimit      = 400
+)
+
+type Stargazer struct {
+	StarredAt time.Time `json:"starred_at"`
+}
+
+// Get a page of the stargazers of a repository in the order they starred it, the page starts at 1.
+func (ghClient *Client) GetStargazers(ctx context.Context, fullName string, page int) ([]Stargazer, error) {
+	var stargazers []Stargazer
+
+	path := fmt.Sprintf("/repos/%s/stargazers?per_page=%d&page=%d", fullName, stargazersPerPage, page)
+	_, err := ghClient.getMediaType(ctx, path, "application/vnd.github.star+json", &stargazers, false)
+
+	return stargazers, err
+}
+
+// StarHistoryStore saves the backfilled star history into the metrics of repositories, see model.MetricRepo.
+type StarHistoryStore interface {
+	SaveStarHistory(ctx context.Context, repositoryId int, history []model.RepositoryMetric) error
+}
+
+// StarBackfiller reconstructs the daily star history of a repository from its stargazers,
+// so the metrics of a repository start from its first star rather than from the day it was first linked.
+type StarBackfiller struct {
+	client   *Client
+	store    StarHistoryStore
+	maxPages int
+}
+
+// Create the backfiller that fetches at most maxPages pages of 100 stargazers per repository, 0 uses the default of 100 pages.
+func NewStarBackfiller(client *Client, store StarHistoryStore, maxPages int) *StarBackfiller {
+	if maxPages <= 0 {
+		maxPages = defaultMaxStargazerPages
+	}
+
+	return &StarBackfiller{
+		client:   client,
+		store:    store,
+		maxPages: min(maxPages, stargazerPagesLimit),
+	}
+}
+
+// Backfill the star history of a newly linked repository, it is skipped when the quota is not enough for all of its pages.
+// Huge repositories only have the history of their first stars backfilled.
+func (b *StarBackfiller) Backfill(ctx context.Context, repository model.GhRepository) error {
+	pages := (repository.Stars + stargazersPerPage - 1) / stargazersPerPage
+	capped := pages > b.maxPages
+	pages = min(pages, b.maxPages)
+
+	if pages == 0 {
+		return nil
+	}
+
+	if !b.client.HasQuota(pages) {
+		quota := b.client.Quota()
+		slog.Warn("GitHub quota is not enough to backfill star history", slog.String("repository", repository.FullName), slog.Int("pages", pages), slog.Int("remaining", quota.Remaining))
+		return nil
+	}
+
+	stargazers := make([]Stargazer, 0, pages*stargazersPerPage)
+
+	for page := 1; page <= pages; page++ {
+		items, err := b.client.GetStargazers(ctx, repository.FullName, page)
+
+		if err != nil {
+			return fmt.Errorf("failed to get stargazers of repository %s: %w", repository.FullName, err)
+		}
+
+		stargazers = append(stargazers, items...)
+
+		if len(items) < stargazersPerPage {
+			capped = false
+			break
+		}
+	}
+
+	history := starHistory(stargazers, time.Now(), !capped)
+
+	if len(history) == 0 {
+		return nil
+	}
+
+	if err := b.store.SaveStarHistory(ctx, repository.Id, history); err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("backfilled %d days of star history, repository: %s", len(history), repository.FullName), slog.Bool("capped", capped))
+
+	return nil
+}
+
+// Build the daily star totals from the stargazers, each day is recorded at its end with the number of stars given until then.
+// Days from the day of until are left out as the snapshots of syncs cover them. When not all stargazers are fetched,
+// the last fetched day is left out as well because it may only have part of its stars.
+func starHistory(stargazers []Stargazer, until time.Time, complete bool) []model.RepositoryMetric {
+	slices.SortStableFunc(stargazers, func(a, b Stargazer) int {
+		return a.StarredAt.Compare(b.StarredAt)
+	})
+
+	today := until.UTC().Format(time.DateOnly)
+	history := make([]model.RepositoryMetric, 0)
+
+	for i, stargazer := range stargazers {
+		day := stargazer.StarredAt.UTC().Format(time.DateOnly)
+
+		if day >= today {
+			complete = true
+			break
+		}
+
+		if len(history) > 0 && history[len(history)-1].Date == day {
+			history[len(history)-1].Stars = i + 1
+			continue
+		}
+
+		start, _ := time.Parse(time.DateOnly, day)
+
+		history = append(history, model.RepositoryMetric{
+			Date:       day,
+			Stars:      i + 1,
+			RecordedAt: start.Add(24*time.Hour - time.Second),
+		})
+	}
+
+	if !complete && len(history) > 0 {
+		history = history[:len(history)-1]
+	}
+
+	return history
+}
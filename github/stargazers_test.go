@@ -0,0 +1,88 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+func TestStarHistory(t *testing.T) {
+	day := func(day, hour int) Stargazer {
+		return Stargazer{StarredAt: time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)}
+	}
+
+	until := time.Date(2024, 5, 20, 8, 0, 0, 0, time.UTC)
+	stargazers := []Stargazer{day(11, 9), day(10, 1), day(10, 5), day(13, 3), day(20, 1)}
+
+	complete := starHistory(stargazers, until, true)
+	partial := starHistory([]Stargazer{day(10, 1), day(11, 1), day(11, 2)}, until, false)
+
+	expcts := []expectation{
+		{len(complete), 3},
+		{complete[0].Date, "2024-05-10"},
+		{complete[0].Stars, 2},
+		{complete[0].RecordedAt, time.Date(2024, 5, 10, 23, 59, 59, 0, time.UTC)},
+		{complete[1].Stars, 3},
+		{complete[2].Date, "2024-05-13"},
+		{complete[2].Stars, 4},
+		{complete[2].Forks.Valid, false},
+		{len(partial), 1},
+		{partial[0].Date, "2024-05-10"},
+		{len(starHistory(nil, until, true)), 0},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestStarBackfillerBackfill(t *testing.T) {
+	pages := make([]int, 0)
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	client, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/stargazers" || r.Header.Get("Accept") != "application/vnd.github.star+json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pages = append(pages, page)
+
+		// 250 stars given 10 per day from 2024-01-01.
+		stargazers := make([]map[string]any, 0)
+
+		for i := (page - 1) * 100; i < min(page*100, 250); i++ {
+			stargazers = append(stargazers, map[string]any{"starred_at": first.Add(time.Duration(i/10) * 24 * time.Hour)})
+		}
+
+		json.NewEncoder(w).Encode(stargazers)
+	})
+
+	store := &memStarHistoryStore{history: make(map[int][]model.RepositoryMetric)}
+	ctx := context.Background()
+
+	err := NewStarBackfiller(client, store, 0).Backfill(ctx, model.GhRepository{Id: 1, FullName: "owner/repo", Stars: 250})
+	requestedPages := len(pages)
+
+	// Only the first two pages are fetched when the pages are capped, the last fetched day may be partial.
+	cappedErr := NewStarBackfiller(client, store, 2).Backfill(ctx, model.GhRepository{Id: 2, FullName: "owner/repo", Stars: 250})
+
+	expcts := []expectation{
+		{err, nil},
+		{requestedPages, 3},
+		{len(store.history[1]), 25},
+		{store.history[1][0].Date, "2024-01-01"},
+		{store.history[1][0].Stars, 10},
+		{store.history[1][24].Stars, 250},
+		{cappedErr, nil},
+		{len(pages), 5},
+		{len(store.history[2]), 19},
+		{store.history[2][18].Stars, 190},
+	}
+
+	assertExpects(t, expcts)
+}
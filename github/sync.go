@@ -5,10 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"sync/atomic"
 	"time"
 
 	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/tagging"
 	"github.com/liweiyi88/trendshift-backend/utils/sliceutils"
 	"golang.org/x/sync/errgroup"
 )
@@ -20,15 +23,136 @@ type SyncHandler struct {
 	repositoryRepo *model.GhRepositoryRepo
 	developerRepo  *model.DeveloperRepo
 	client         *Client
+	details        Details         // fetches the details in batches instead of the conditional rest requests when set.
+	tagger         *tagging.Tagger // tags the repositories from their topics when set.
+	readmes        *ReadmeFetcher  // refreshes the READMEs of the synced repositories when set.
+	releases       *ReleaseFetcher // tracks the releases and tags of the synced repositories when set.
 }
 
 func NewSyncHandler(db database.DB, repositoryRepo *model.GhRepositoryRepo, developerRepo *model.DeveloperRepo, client *Client) *SyncHandler {
 	return &SyncHandler{
-		db, repositoryRepo, developerRepo, client,
+		db:             db,
+		repositoryRepo: repositoryRepo,
+		developerRepo:  developerRepo,
+		client:         client,
 	}
 }
 
-func (s *SyncHandler) updateRepositories(ctx context.Context, repositories []model.GhRepository) error {
+// Sync with batches of details, e.g. from the GraphQLClient, rather than a conditional rest request per repository or developer.
+func (s *SyncHandler) WithDetails(details Details) *SyncHandler {
+	s.details = details
+	return s
+}
+
+// Tag the synced repositories from their GitHub topics.
+func (s *SyncHandler) WithTagger(tagger *tagging.Tagger) *SyncHandler {
+	s.tagger = tagger
+	return s
+}
+
+// Refresh the READMEs of the synced repositories, it sends one more request per repository.
+func (s *SyncHandler) WithReadmes(readmes *ReadmeFetcher) *SyncHandler {
+	s.readmes = readmes
+	return s
+}
+
+// Track the releases and tags of the synced repositories, it sends two more requests per repository and one per new tag.
+func (s *SyncHandler) WithReleases(releases *ReleaseFetcher) *SyncHandler {
+	s.releases = releases
+	return s
+}
+
+// Save the repository with its details, the tags from its topics, its README and its releases.
+func (s *SyncHandler) updateRepository(ctx context.Context, repository model.GhRepository) error {
+	if err := s.repositoryRepo.Update(ctx, repository); err != nil {
+		return err
+	}
+
+	if s.tagger != nil {
+		if err := s.tagger.Apply(ctx, repository); err != nil {
+			return err
+		}
+	}
+
+	if s.readmes != nil {
+		if err := s.readmes.Refresh(ctx, repository); err != nil {
+			return err
+		}
+	}
+
+	if s.releases != nil {
+		if err := s.releases.Refresh(ctx, repository); err != nil {
+			return err
+		}
+	}
+
+	jobrun.Count(ctx).Updated(1)
+	return nil
+}
+
+func applyRepositoryDetails(repository *model.GhRepository, ghRepository model.GhRepository) {
+	repository.Description = ghRepository.Description
+	repository.Forks = ghRepository.Forks
+	repository.Stars = ghRepository.Stars
+	repository.Owner = ghRepository.Owner
+	repository.Language = ghRepository.Language // Language can also be updated
+	repository.DefaultBranch = ghRepository.DefaultBranch
+	repository.Homepage = ghRepository.Homepage
+	repository.Topics = ghRepository.Topics
+	repository.License = ghRepository.License
+	repository.Archived = ghRepository.Archived
+	repository.Fork = ghRepository.Fork
+	repository.Parent = ghRepository.Parent
+	repository.OpenIssues = ghRepository.OpenIssues
+	repository.Subscribers = ghRepository.Subscribers
+	repository.Size = ghRepository.Size
+	repository.PushedAt = ghRepository.PushedAt
+	repository.GhCreatedAt = ghRepository.GhCreatedAt
+}
+
+func applyDeveloperDetails(developer *model.Developer, ghDeveloper model.Developer) {
+	developer.AvatarUrl = ghDeveloper.AvatarUrl
+	developer.Name = ghDeveloper.Name
+	developer.Company = ghDeveloper.Company
+	developer.Blog = ghDeveloper.Blog
+	developer.Location = ghDeveloper.Location
+	developer.Email = ghDeveloper.Email
+	developer.Bio = ghDeveloper.Bio
+	developer.TwitterUsername = ghDeveloper.TwitterUsername
+	developer.PublicRepos = ghDeveloper.PublicRepos
+	developer.PublicGists = ghDeveloper.PublicGists
+	developer.Followers = ghDeveloper.Followers
+	developer.Following = ghDeveloper.Following
+}
+
+// Count the conditional requests of a sync, unchanged resources are answered with 304 Not Modified.
+type syncStats struct {
+	requested   atomic.Int64
+	notModified atomic.Int64
+}
+
+// The share of requests answered with 304 Not Modified.
+func (stats *syncStats) hitRate() float64 {
+	requested := stats.requested.Load()
+
+	if requested == 0 {
+		return 0
+	}
+
+	return float64(stats.notModified.Load()) / float64(requested)
+}
+
+func (stats *syncStats) log(kind string) {
+	if stats.requested.Load() == 0 {
+		return
+	}
+
+	slog.Info(
+		fmt.Sprintf("%s sync summary: %d requested, %d not modified, hit rate %.1f%%", kind, stats.requested.Load(), stats.notModified.Load(), stats.hitRate()*100),
+	)
+}
+
+func (s *SyncHandler) updateRepositories(ctx context.Context, repositories []model.GhRepository, stats *syncStats) error {
 	group, ctx := errgroup.WithContext(ctx)
 
 	// Follow the github best practice to avoid reaching secondary rate limit
@@ -42,7 +166,30 @@ func (s *SyncHandler) updateRepositories(ctx context.Context, repositories []mod
 		repository := repository
 
 		group.Go(func() error {
-			ghRepository, err := s.client.GetRepository(ctx, repository.FullName)
+			ghRepository, etag, err := s.client.GetRepositoryIfChanged(ctx, repository.FullName)
+			stats.requested.Add(1)
+
+			if errors.Is(err, ErrNotModified) {
+				stats.notModified.Add(1)
+
+				if err := s.repositoryRepo.Touch(ctx, repository.Id); err != nil {
+					return err
+				}
+
+				// An unchanged repository can still miss its README or releases, e.g. when they were enabled after its last change,
+				// and a new release does not always change the repository.
+				if s.readmes != nil {
+					if err := s.readmes.Refresh(ctx, repository); err != nil {
+						return err
+					}
+				}
+
+				if s.releases != nil {
+					return s.releases.Refresh(ctx, repository)
+				}
+
+				return nil
+			}
 
 			if err != nil {
 				if errors.Is(err, ErrNotFound) {
@@ -52,24 +199,27 @@ func (s *SyncHandler) updateRepositories(ctx context.Context, repositories []mod
 				} else {
 					return fmt.Errorf("failed to get repository details from GitHub: %v", err)
 				}
+
+				// Keep the details of the repository rather than overwriting them with zero values, it is skipped until the next sync.
+				jobrun.Count(ctx).Failed(1)
+				return s.repositoryRepo.TouchMissing(ctx, repository.Id)
 			}
 
-			repository.Description = ghRepository.Description
-			repository.Forks = ghRepository.Forks
-			repository.Stars = ghRepository.Stars
-			repository.Owner = ghRepository.Owner
-			repository.Language = ghRepository.Language // Language can also be updated
-			repository.DefaultBranch = ghRepository.DefaultBranch
-			repository.Homepage = ghRepository.Homepage
+			applyRepositoryDetails(&repository, ghRepository)
 
-			return s.repositoryRepo.Update(ctx, repository)
+			if err := s.updateRepository(ctx, repository); err != nil {
+				return err
+			}
+
+			s.client.saveETag(ctx, etag)
+			return nil
 		})
 	}
 
 	return group.Wait()
 }
 
-func (s *SyncHandler) updateDevelopers(ctx context.Context, developers []model.Developer) error {
+func (s *SyncHandler) updateDevelopers(ctx context.Context, developers []model.Developer, stats *syncStats) error {
 	group, ctx := errgroup.WithContext(ctx)
 
 	// Follow the github best practice to avoid reaching secondary rate limit
@@ -83,7 +233,13 @@ func (s *SyncHandler) updateDevelopers(ctx context.Context, developers []model.D
 		developer := developer
 
 		group.Go(func() error {
-			ghDeveloper, err := s.client.GetDeveloper(ctx, developer.Username)
+			ghDeveloper, etag, err := s.client.GetDeveloperIfChanged(ctx, developer.Username)
+			stats.requested.Add(1)
+
+			if errors.Is(err, ErrNotModified) {
+				stats.notModified.Add(1)
+				return s.developerRepo.Touch(ctx, developer.Id)
+			}
 
 			if err != nil {
 				if errors.Is(err, ErrNotFound) {
@@ -93,28 +249,105 @@ func (s *SyncHandler) updateDevelopers(ctx context.Context, developers []model.D
 				} else {
 					return fmt.Errorf("failed to get developer details from GitHub: %v", err)
 				}
+
+				// Keep the details of the developer rather than overwriting them with zero values, it is skipped until the next sync.
+				jobrun.Count(ctx).Failed(1)
+				return s.developerRepo.TouchMissing(ctx, developer.Id)
+			}
+
+			applyDeveloperDetails(&developer, ghDeveloper)
+
+			if err := s.developerRepo.Update(ctx, developer); err != nil {
+				return err
 			}
 
-			developer.AvatarUrl = ghDeveloper.AvatarUrl
-			developer.Name = ghDeveloper.Name
-			developer.Company = ghDeveloper.Company
-			developer.Blog = ghDeveloper.Blog
-			developer.Location = ghDeveloper.Location
-			developer.Email = ghDeveloper.Email
-			developer.Bio = ghDeveloper.Bio
-			developer.TwitterUsername = ghDeveloper.TwitterUsername
-			developer.PublicRepos = ghDeveloper.PublicRepos
-			developer.PublicGists = ghDeveloper.PublicGists
-			developer.Followers = ghDeveloper.Followers
-			developer.Following = ghDeveloper.Following
-
-			return s.developerRepo.Update(ctx, developer)
+			s.client.saveETag(ctx, etag)
+			jobrun.Count(ctx).Updated(1)
+			return nil
 		})
 	}
 
 	return group.Wait()
 }
 
+// Update the repositories with a batch of details, repositories missing on GitHub are skipped until the next sync.
+func (s *SyncHandler) batchUpdateRepositories(ctx context.Context, repositories []model.GhRepository) error {
+	fullNames := make([]string, 0, len(repositories))
+
+	for _, repository := range repositories {
+		fullNames = append(fullNames, repository.FullName)
+	}
+
+	ghRepositories, err := s.details.GetRepositories(ctx, fullNames)
+
+	if err != nil {
+		return fmt.Errorf("failed to get repositories details from GitHub: %v", err)
+	}
+
+	for _, repository := range repositories {
+		ghRepository, ok := ghRepositories[repository.FullName]
+
+		if !ok {
+			jobrun.Count(ctx).Failed(1)
+			slog.Info(fmt.Sprintf("repository not found or blocked on GitHub, repository: %s", repository.FullName))
+
+			if err := s.repositoryRepo.TouchMissing(ctx, repository.Id); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		applyRepositoryDetails(&repository, ghRepository)
+
+		if err := s.updateRepository(ctx, repository); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Update the developers with a batch of details, developers missing on GitHub are skipped until the next sync.
+func (s *SyncHandler) batchUpdateDevelopers(ctx context.Context, developers []model.Developer) error {
+	usernames := make([]string, 0, len(developers))
+
+	for _, developer := range developers {
+		usernames = append(usernames, developer.Username)
+	}
+
+	ghDevelopers, err := s.details.GetDevelopers(ctx, usernames)
+
+	if err != nil {
+		return fmt.Errorf("failed to get developers details from GitHub: %v", err)
+	}
+
+	for _, developer := range developers {
+		ghDeveloper, ok := ghDevelopers[developer.Username]
+
+		if !ok {
+			jobrun.Count(ctx).Failed(1)
+			slog.Info(fmt.Sprintf("developer not found on GitHub, developer: %s", developer.Username))
+
+			if err := s.developerRepo.TouchMissing(ctx, developer.Id); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		applyDeveloperDetails(&developer, ghDeveloper)
+
+		if err := s.developerRepo.Update(ctx, developer); err != nil {
+			return err
+		}
+
+		jobrun.Count(ctx).Updated(1)
+	}
+
+	return nil
+}
+
 func (s *SyncHandler) syncRepositories(ctx context.Context, opts ...any) error {
 	repositories, err := s.repositoryRepo.FindAll(
 		ctx,
@@ -126,9 +359,37 @@ func (s *SyncHandler) syncRepositories(ctx context.Context, opts ...any) error {
 	}
 
 	chulks := sliceutils.Chunk[model.GhRepository](repositories, chulkSize)
+	stats := &syncStats{}
+	defer stats.log("repositories")
 
 	for _, chulk := range chulks {
-		err := s.updateRepositories(ctx, chulk)
+		if s.details != nil {
+			if err := s.batchUpdateRepositories(ctx, chulk); err != nil {
+				return fmt.Errorf("could not sync repositories: %v", err)
+			}
+
+			slog.Info(fmt.Sprintf("completed batch update for %d repositories", len(chulk)))
+			continue
+		}
+
+		// The READMEs and releases are refreshed for unchanged repositories too, so they are counted for every repository.
+		requests := len(chulk)
+
+		if s.readmes != nil {
+			requests += len(chulk)
+		}
+
+		if s.releases != nil {
+			requests += 2 * len(chulk)
+		}
+
+		if !s.client.HasQuota(requests) {
+			quota := s.client.Quota()
+			slog.Warn("GitHub quota is not enough for the next batch, stop syncing repositories", slog.Int("remaining", quota.Remaining), slog.Time("reset", quota.Reset))
+			return nil
+		}
+
+		err := s.updateRepositories(ctx, chulk, stats)
 
 		if err != nil {
 			return fmt.Errorf("could not sync repositories: %v", err)
@@ -149,9 +410,26 @@ func (s *SyncHandler) syncDevelopers(ctx context.Context, opts ...any) error {
 	}
 
 	chulks := sliceutils.Chunk[model.Developer](developers, chulkSize)
+	stats := &syncStats{}
+	defer stats.log("developers")
 
 	for _, chulk := range chulks {
-		err := s.updateDevelopers(ctx, chulk)
+		if s.details != nil {
+			if err := s.batchUpdateDevelopers(ctx, chulk); err != nil {
+				return fmt.Errorf("could not sync developers: %v", err)
+			}
+
+			slog.Info(fmt.Sprintf("completed batch update for %d developers", len(chulk)))
+			continue
+		}
+
+		if !s.client.HasQuota(len(chulk)) {
+			quota := s.client.Quota()
+			slog.Warn("GitHub quota is not enough for the next batch, stop syncing developers", slog.Int("remaining", quota.Remaining), slog.Time("reset", quota.Reset))
+			return nil
+		}
+
+		err := s.updateDevelopers(ctx, chulk, stats)
 
 		if err != nil {
 			return fmt.Errorf("could not sync developers: %v", err)
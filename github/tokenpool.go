@@ -0,0 +1,315 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrTokensRevoked = errors.New("all GitHub tokens are revoked")
+
+// A token of the pool with the rate limit reported by its latest response.
+type poolToken struct {
+	token    string
+	app      *AppAuth // set when the requests are sent with the installation tokens of a GitHub App.
+	label    string   // identifies the token in logs and job runs without revealing it.
+	quota    Quota
+	requests int
+	revoked  bool
+}
+
+// The requests sent with a token and its quota left, see TokenPool.Usage.
+type TokenUsage struct {
+	Token     string `json:"token"`
+	Requests  int    `json:"requests"`
+	Remaining int    `json:"remaining"`
+	Revoked   bool   `json:"revoked"`
+}
+
+// TokenPool rotates the requests over several tokens, every request is sent with the token that has the most quota left.
+// A token rejected with 401 Unauthorized is taken out of the rotation.
+type TokenPool struct {
+	mu     sync.Mutex
+	tokens []*poolToken
+}
+
+// Create a pool of the given tokens, empty and duplicated tokens are ignored.
+// A pool without tokens sends anonymous requests, their rate limit is 60 reqs/hour.
+func NewTokenPool(tokens ...string) *TokenPool {
+	pool := &TokenPool{}
+	seen := make(map[string]bool)
+
+	for _, token := range tokens {
+		token = strings.TrimSpace(token)
+
+		if token == "" || seen[token] {
+			continue
+		}
+
+		seen[token] = true
+		pool.tokens = append(pool.tokens, &poolToken{token: token, label: tokenLabel(len(pool.tokens), token)})
+	}
+
+	if len(pool.tokens) == 0 {
+		pool.tokens = append(pool.tokens, &poolToken{label: "anonymous"})
+	}
+
+	return pool
+}
+
+// Create a pool that sends the requests with the installation tokens of a GitHub App.
+// The installation has a rate limit of its own, so the refreshed tokens share the quota of a single pool token.
+func NewAppTokenPool(app *AppAuth) *TokenPool {
+	return &TokenPool{
+		tokens: []*poolToken{{app: app, label: fmt.Sprintf("app installation %d", app.InstallationId)}},
+	}
+}
+
+// Label a token by its position and last characters, e.g. "#2 (...a1b2)".
+func tokenLabel(index int, token string) string {
+	suffix := token
+
+	if len(token) > 4 {
+		suffix = token[len(token)-4:]
+	}
+
+	return fmt.Sprintf("#%d (...%s)", index+1, suffix)
+}
+
+// Get the token to send in the authorization header, it is empty for anonymous requests.
+func (t *poolToken) credential(ctx context.Context) (string, error) {
+	if t.app == nil {
+		return t.token, nil
+	}
+
+	token, err := t.app.Token(ctx)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to get GitHub App installation token: %v", err)
+	}
+
+	return token, nil
+}
+
+// The number of requests the token can send now, a token with an unknown or reset quota is treated as fully available.
+func (t *poolToken) available(now time.Time) int {
+	if !t.quota.Known || now.After(t.quota.Reset) {
+		return math.MaxInt
+	}
+
+	return t.quota.Remaining
+}
+
+// Pick the healthiest token, the one with the most quota left and then the fewest requests sent.
+// When the quota of every token is used up, it returns the token that resets first and how long to wait for it.
+func (pool *TokenPool) pick() (*poolToken, time.Duration, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+
+	var healthiest, earliestReset *poolToken
+
+	for _, token := range pool.tokens {
+		if token.revoked {
+			continue
+		}
+
+		if earliestReset == nil || token.quota.Reset.Before(earliestReset.quota.Reset) {
+			earliestReset = token
+		}
+
+		if healthiest == nil ||
+			token.available(now) > healthiest.available(now) ||
+			(token.available(now) == healthiest.available(now) && token.requests < healthiest.requests) {
+			healthiest = token
+		}
+	}
+
+	if healthiest == nil {
+		return nil, 0, ErrTokensRevoked
+	}
+
+	if healthiest.available(now) > 0 {
+		return healthiest, 0, nil
+	}
+
+	return earliestReset, earliestReset.quota.Reset.Sub(now), nil
+}
+
+// Count the request and keep the quota of the token from the response headers.
+func (pool *TokenPool) record(token *poolToken, header http.Header) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	token.requests++
+
+	limit, err := strconv.Atoi(header.Get("X-Ratelimit-Limit"))
+
+	if err != nil {
+		return
+	}
+
+	remaining, _ := strconv.Atoi(header.Get("X-Ratelimit-Remaining"))
+	reset, _ := strconv.ParseInt(header.Get("X-Ratelimit-Reset"), 10, 64)
+
+	// Responses of concurrent requests can arrive out of order, keep the lowest remaining quota of the same window.
+	resetAt := time.Unix(reset, 0)
+
+	if token.quota.Known && resetAt.Equal(token.quota.Reset) && remaining > token.quota.Remaining {
+		return
+	}
+
+	token.quota = Quota{Limit: limit, Remaining: remaining, Reset: resetAt, Known: true}
+}
+
+// Mark the quota of a rate limited token as used up for the given duration.
+func (pool *TokenPool) exhaust(token *poolToken, wait time.Duration) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	token.quota = Quota{Limit: token.quota.Limit, Remaining: 0, Reset: time.Now().Add(wait), Known: true}
+}
+
+// Forget the stale quota of a token after waiting for its rate limit, the next response tells the new quota.
+func (pool *TokenPool) forget(token *poolToken) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	token.quota = Quota{}
+}
+
+// Take a token rejected with 401 Unauthorized out of the rotation.
+func (pool *TokenPool) revoke(token *poolToken) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if !token.revoked {
+		token.revoked = true
+		slog.Warn("GitHub token is revoked, taking it out of the rotation", slog.String("token", token.label))
+	}
+}
+
+// Retry a request rejected with 401 Unauthorized with other credentials, it returns false when there are none.
+// A revoked personal access token is taken out of the rotation and does not count as a retry,
+// an installation token is refreshed as it can be revoked before it expires.
+func retryUnauthorized(pool *TokenPool, token *poolToken, attempt *int) bool {
+	switch {
+	case token.app != nil:
+		token.app.expire()
+		return *attempt < maxRetries
+	case token.token != "":
+		pool.revoke(token)
+		*attempt--
+		return true
+	default:
+		return false
+	}
+}
+
+// Check whether any token other than the given one can send a request now.
+func (pool *TokenPool) hasOther(token *poolToken) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+
+	for _, other := range pool.tokens {
+		if other != token && !other.revoked && other.available(now) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The combined quota of the tokens in rotation, it is unknown until every token has received a response.
+func (pool *TokenPool) Quota() Quota {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	quota := Quota{Known: true}
+	now := time.Now()
+
+	for _, token := range pool.tokens {
+		if token.revoked {
+			continue
+		}
+
+		if !token.quota.Known || now.After(token.quota.Reset) {
+			quota.Known = false
+			continue
+		}
+
+		quota.Limit += token.quota.Limit
+		quota.Remaining += token.quota.Remaining
+
+		if quota.Reset.IsZero() || token.quota.Reset.Before(quota.Reset) {
+			quota.Reset = token.quota.Reset
+		}
+	}
+
+	return quota
+}
+
+// Check whether the tokens in rotation can send the given number of requests before their rate limits reset.
+// It is true when the quota of any token is unknown or has been reset.
+func (pool *TokenPool) HasQuota(requests int) bool {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	now := time.Now()
+	remaining := 0
+
+	for _, token := range pool.tokens {
+		if token.revoked {
+			continue
+		}
+
+		available := token.available(now)
+
+		if available == math.MaxInt {
+			return true
+		}
+
+		remaining += available
+	}
+
+	return remaining >= requests
+}
+
+// The requests sent with every token since the pool was created.
+func (pool *TokenPool) Usage() []TokenUsage {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	usage := make([]TokenUsage, 0, len(pool.tokens))
+
+	for _, token := range pool.tokens {
+		usage = append(usage, TokenUsage{
+			Token:     token.label,
+			Requests:  token.requests,
+			Remaining: token.quota.Remaining,
+			Revoked:   token.revoked,
+		})
+	}
+
+	return usage
+}
+
+func (pool *TokenPool) LogUsage() {
+	for _, usage := range pool.Usage() {
+		slog.Info("GitHub token usage",
+			slog.String("token", usage.Token),
+			slog.Int("requests", usage.Requests),
+			slog.Int("remaining", usage.Remaining),
+			slog.Bool("revoked", usage.Revoked))
+	}
+}
@@ -0,0 +1,137 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/jobrun"
+)
+
+func TestNewTokenPool(t *testing.T) {
+	pool := NewTokenPool(" token-a ", "", "token-b", "token-a")
+	anonymous := NewTokenPool()
+
+	expcts := []expectation{
+		{len(pool.tokens), 2},
+		{pool.tokens[0].token, "token-a"},
+		{pool.tokens[0].label, "#1 (...en-a)"},
+		{pool.tokens[1].label, "#2 (...en-b)"},
+		{len(anonymous.tokens), 1},
+		{anonymous.tokens[0].token, ""},
+		{anonymous.tokens[0].label, "anonymous"},
+	}
+
+	assertExpects(t, expcts)
+}
+
+func TestTokenPoolPicksHealthiestToken(t *testing.T) {
+	pool := NewTokenPool("token-a", "token-b", "token-c")
+	reset := time.Now().Add(time.Hour)
+
+	pool.tokens[0].quota = Quota{Limit: 5000, Remaining: 100, Reset: reset, Known: true}
+	pool.tokens[1].quota = Quota{Limit: 5000, Remaining: 3000, Reset: reset, Known: true}
+	pool.tokens[2].quota = Quota{Limit: 5000, Remaining: 0, Reset: reset, Known: true}
+
+	healthiest, wait, err := pool.pick()
+
+	if err != nil || wait != 0 || healthiest.token != "token-b" {
+		t.Errorf("expected token-b without waiting but got: %v, wait: %v, error: %v", healthiest, wait, err)
+	}
+
+	if !pool.HasQuota(3100) || pool.HasQuota(3101) {
+		t.Error("expected the quota of all tokens to be combined")
+	}
+
+	pool.revoke(pool.tokens[1])
+
+	healthiest, _, _ = pool.pick()
+
+	if healthiest.token != "token-a" {
+		t.Errorf("expected a revoked token to be out of rotation but got: %s", healthiest.token)
+	}
+
+	pool.tokens[0].quota.Remaining = 0
+	pool.tokens[2].quota.Reset = time.Now().Add(time.Minute)
+
+	earliest, wait, _ := pool.pick()
+
+	if earliest.token != "token-c" || wait <= 0 || wait > time.Minute {
+		t.Errorf("expected to wait for token-c that resets first but got: %s, wait: %v", earliest.token, wait)
+	}
+
+	pool.revoke(pool.tokens[0])
+	pool.revoke(pool.tokens[2])
+
+	if _, _, err := pool.pick(); !errors.Is(err, ErrTokensRevoked) {
+		t.Errorf("expected all tokens to be revoked but got: %v", err)
+	}
+}
+
+func TestClientRotatesTokens(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+
+	client, waits := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		token := r.Header.Get("Authorization")
+		requests[token]++
+
+		switch token {
+		case "Bearer revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		case "Bearer limited":
+			w.Header().Set("X-Ratelimit-Limit", "5000")
+			w.Header().Set("X-Ratelimit-Remaining", "0")
+			w.Header().Set("X-Ratelimit-Reset", reset)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Header().Set("X-Ratelimit-Limit", "5000")
+		w.Header().Set("X-Ratelimit-Remaining", strconv.Itoa(4000-requests[token]))
+		w.Header().Set("X-Ratelimit-Reset", reset)
+		w.Write([]byte(`{"login": "liweiyi88"}`))
+	})
+
+	client.tokens = NewTokenPool("revoked", "limited", "healthy")
+
+	counters := &jobrun.Counters{}
+	ctx := jobrun.WithCounters(context.Background(), counters)
+
+	for range 3 {
+		if _, err := client.GetDeveloper(ctx, "liweiyi88"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage := client.Tokens().Usage()
+
+	expcts := []expectation{
+		{len(*waits), 0},
+		{requests["Bearer revoked"], 1},
+		{requests["Bearer limited"], 1},
+		{requests["Bearer healthy"], 3},
+		{usage[0].Revoked, true},
+		{usage[1].Remaining, 0},
+		{usage[2].Requests, 3},
+		{usage[2].Remaining, 3997},
+		{client.Quota().Remaining, 3997},
+	}
+
+	assertExpects(t, expcts)
+
+	client.tokens = NewTokenPool("revoked")
+
+	if _, err := client.GetDeveloper(ctx, "liweiyi88"); !errors.Is(err, ErrTokensRevoked) {
+		t.Errorf("expected all tokens to be revoked but got: %v", err)
+	}
+}
@@ -13,6 +13,15 @@ type Repositories struct {
 	TagRepo                *model.TagRepo
 	UserRepo               *model.UserRepo
 	StatsRepo              *model.StatsRepo
+	ScrapeRunRepo          *model.ScrapeRunRepo
+	LanguageRepo           *model.LanguageRepo
+	RankChangeRepo         *model.RankChangeRepo
+	JobLockRepo            *model.JobLockRepo
+	JobRunRepo             *model.JobRunRepo
+	GithubEtagRepo         *model.GithubEtagRepo
+	RepositoryReadmeRepo   *model.RepositoryReadmeRepo
+	MetricRepo             *model.MetricRepo
+	ReleaseRepo            *model.ReleaseRepo
 }
 
 func InitRepositories(db database.DB) *Repositories {
@@ -24,5 +33,14 @@ func InitRepositories(db database.DB) *Repositories {
 		TagRepo:                model.NewTagRepo(db),
 		UserRepo:               model.NewUserRepo(db),
 		StatsRepo:              model.NewStatsRepo(db),
+		ScrapeRunRepo:          model.NewScrapeRunRepo(db),
+		LanguageRepo:           model.NewLanguageRepo(db),
+		RankChangeRepo:         model.NewRankChangeRepo(db),
+		JobLockRepo:            model.NewJobLockRepo(db),
+		JobRunRepo:             model.NewJobRunRepo(db),
+		GithubEtagRepo:         model.NewGithubEtagRepo(db),
+		RepositoryReadmeRepo:   model.NewRepositoryReadmeRepo(db),
+		MetricRepo:             model.NewMetricRepo(db),
+		ReleaseRepo:            model.NewReleaseRepo(db),
 	}
 }
@@ -0,0 +1,177 @@
+// Package joblock makes sure a job, e.g. scraping or syncing repositories, only runs in one process at a time.
+package joblock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+)
+
+var ErrLocked = errors.New("job is locked by another process")
+
+const (
+	DefaultLease     = time.Minute
+	DefaultRetryWait = 5 * time.Second
+)
+
+// Store persists the locks, see model.JobLockRepo.
+type Store interface {
+	Acquire(ctx context.Context, name, owner string, lease time.Duration) (bool, error)
+	Renew(ctx context.Context, name, owner string, lease time.Duration) (bool, error)
+	Release(ctx context.Context, name, owner string) error
+}
+
+type Locker struct {
+	store     Store
+	lease     time.Duration
+	retryWait time.Duration
+}
+
+func NewLocker(store Store) *Locker {
+	return &Locker{
+		store:     store,
+		lease:     DefaultLease,
+		retryWait: DefaultRetryWait,
+	}
+}
+
+// A lock held by this process, the heartbeat keeps renewing the lease until it is released.
+type Lock struct {
+	name   string
+	owner  string
+	store  Store
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Owners are unique per lock, so the same job can not be locked twice in one process either.
+func newOwner() string {
+	hostname, _ := os.Hostname()
+
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	return fmt.Sprintf("%s:%d:%s", hostname, os.Getpid(), hex.EncodeToString(b))
+}
+
+// Acquire the lock of the job, when wait is false it returns ErrLocked right away if the lock is held by another process.
+// The returned context is cancelled when the lock is lost, e.g. the heartbeat failed and the lease expired.
+func (l *Locker) Acquire(ctx context.Context, name string, wait bool) (*Lock, context.Context, error) {
+	owner := newOwner()
+
+	for {
+		acquired, err := l.store.Acquire(ctx, name, owner, l.lease)
+
+		if err != nil {
+			return nil, ctx, err
+		}
+
+		if acquired {
+			break
+		}
+
+		if !wait {
+			return nil, ctx, fmt.Errorf("%w: %s", ErrLocked, name)
+		}
+
+		slog.Info("waiting for job lock...", slog.String("lock", name))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx, ctx.Err()
+		case <-time.After(l.retryWait):
+		}
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+
+	lock := &Lock{
+		name:   name,
+		owner:  owner,
+		store:  l.store,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go lock.heartbeat(lockCtx, l.lease)
+
+	return lock, lockCtx, nil
+}
+
+func (lock *Lock) heartbeat(ctx context.Context, lease time.Duration) {
+	defer close(lock.done)
+
+	ticker := time.NewTicker(lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := lock.store.Renew(ctx, lock.name, lock.owner, lease)
+
+			if err != nil {
+				// A failed heartbeat is retried on the next tick, the lease only expires after a few failures.
+				slog.Warn("failed to renew job lock", slog.String("lock", lock.name), slog.Any("error", err))
+				continue
+			}
+
+			if !renewed {
+				slog.Error("job lock lost, cancelling the job", slog.String("lock", lock.name))
+				lock.cancel()
+				return
+			}
+		}
+	}
+}
+
+func (lock *Lock) Name() string {
+	return lock.name
+}
+
+// Stop the heartbeat and release the lock, it is safe to call after the job context is done.
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.cancel()
+	<-lock.done
+
+	return lock.store.Release(context.WithoutCancel(ctx), lock.name, lock.owner)
+}
+
+// Run the job while holding the locks, the locks are acquired in order so two jobs sharing locks can not deadlock.
+func (l *Locker) Run(ctx context.Context, names []string, wait bool, job func(ctx context.Context) error) error {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	names = slices.Compact(names)
+
+	locks := make([]*Lock, 0, len(names))
+
+	defer func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			if err := locks[i].Release(ctx); err != nil {
+				slog.Error("failed to release job lock", slog.String("lock", locks[i].Name()), slog.Any("error", err))
+			}
+		}
+	}()
+
+	jobCtx := ctx
+
+	for _, name := range names {
+		lock, lockCtx, err := l.Acquire(jobCtx, name, wait)
+
+		if err != nil {
+			return err
+		}
+
+		locks = append(locks, lock)
+		jobCtx = lockCtx
+	}
+
+	return job(jobCtx)
+}
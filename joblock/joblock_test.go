@@ -0,0 +1,217 @@
+package joblock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memStore struct {
+	mu     sync.Mutex
+	owners map[string]string
+	renews int
+}
+
+func newMemStore() *memStore {
+	return &memStore{owners: make(map[string]string)}
+}
+
+func (s *memStore) Acquire(ctx context.Context, name, owner string, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.owners[name]; ok {
+		return current == owner, nil
+	}
+
+	s.owners[name] = owner
+	return true, nil
+}
+
+func (s *memStore) Renew(ctx context.Context, name, owner string, lease time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.renews++
+	return s.owners[name] == owner, nil
+}
+
+func (s *memStore) Release(ctx context.Context, name, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[name] == owner {
+		delete(s.owners, name)
+	}
+
+	return nil
+}
+
+func (s *memStore) steal(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.owners[name] = "someone else"
+}
+
+func (s *memStore) held(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.owners[name]
+	return ok
+}
+
+func newTestLocker(store Store) *Locker {
+	locker := NewLocker(store)
+	locker.lease = 30 * time.Millisecond
+	locker.retryWait = 5 * time.Millisecond
+
+	return locker
+}
+
+func TestAcquireNoWait(t *testing.T) {
+	store := newMemStore()
+	locker := newTestLocker(store)
+	ctx := context.Background()
+
+	lock, _, err := locker.Acquire(ctx, "scrape:repository", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = locker.Acquire(ctx, "scrape:repository", false)
+
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("expected ErrLocked but got: %v", err)
+	}
+
+	if _, _, err := locker.Acquire(ctx, "scrape:developer", false); err != nil {
+		t.Errorf("expected a different job to be locked but got: %v", err)
+	}
+
+	if err := lock.Release(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := locker.Acquire(ctx, "scrape:repository", false); err != nil {
+		t.Errorf("expected the released lock to be acquired but got: %v", err)
+	}
+}
+
+func TestAcquireWait(t *testing.T) {
+	store := newMemStore()
+	locker := newTestLocker(store)
+	ctx := context.Background()
+
+	lock, _, err := locker.Acquire(ctx, "sync:repository", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		lock.Release(ctx)
+	}()
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if _, _, err := locker.Acquire(waitCtx, "sync:repository", true); err != nil {
+		t.Errorf("expected the lock to be acquired after waiting but got: %v", err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := locker.Acquire(timeoutCtx, "sync:repository", true); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the wait to stop with the context but got: %v", err)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	store := newMemStore()
+	locker := newTestLocker(store)
+
+	lock, lockCtx, err := locker.Acquire(context.Background(), "link:repository", false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	store.mu.Lock()
+	renews := store.renews
+	store.mu.Unlock()
+
+	if renews == 0 {
+		t.Error("expected the lease to be renewed")
+	}
+
+	store.steal("link:repository")
+
+	select {
+	case <-lockCtx.Done():
+	case <-time.After(time.Second):
+		t.Error("expected the job context to be cancelled when the lock is lost")
+	}
+
+	lock.Release(context.Background())
+
+	if !store.held("link:repository") {
+		t.Error("expected the lock of another owner to be kept")
+	}
+}
+
+func TestRun(t *testing.T) {
+	store := newMemStore()
+	locker := newTestLocker(store)
+	ctx := context.Background()
+
+	var heldDuringJob bool
+
+	err := locker.Run(ctx, []string{"scrape:repository", "scrape:developer", "scrape:repository"}, false, func(ctx context.Context) error {
+		heldDuringJob = store.held("scrape:repository") && store.held("scrape:developer")
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{heldDuringJob, true},
+		{store.held("scrape:repository"), false},
+		{store.held("scrape:developer"), false},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+
+	store.steal("scrape:developer")
+
+	ran := false
+	err = locker.Run(ctx, []string{"scrape:repository", "scrape:developer"}, false, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+
+	if !errors.Is(err, ErrLocked) || ran {
+		t.Errorf("expected the job not to run when a lock is held, error: %v", err)
+	}
+
+	if store.held("scrape:repository") {
+		t.Error("expected the acquired locks to be released when another lock is held")
+	}
+}
@@ -0,0 +1,162 @@
+// Package jobrun records every execution of a job with its counts, the counters are carried in the context
+// so the scrape, link, sync and search handlers can count what they did without knowing about the job.
+package jobrun
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Wrap an error with ErrSkipped to record the run as skipped rather than failed.
+var ErrSkipped = errors.New("job skipped")
+
+// The error summary is truncated as the whole error can be found in the log and Sentry.
+const maxErrorLength = 1000
+
+type Counters struct {
+	scraped, inserted, updated, linked, failed atomic.Int64
+
+	mu       sync.Mutex
+	requests model.GithubRequests // GitHub requests per token label.
+}
+
+type countersKey struct{}
+
+func WithCounters(ctx context.Context, counters *Counters) context.Context {
+	return context.WithValue(ctx, countersKey{}, counters)
+}
+
+// Get the counters of the running job, the counters are nil and ignore the counts when the handler runs outside of a job.
+func Count(ctx context.Context) *Counters {
+	counters, _ := ctx.Value(countersKey{}).(*Counters)
+	return counters
+}
+
+func (c *Counters) Scraped(n int) {
+	if c != nil {
+		c.scraped.Add(int64(n))
+	}
+}
+
+func (c *Counters) Inserted(n int) {
+	if c != nil {
+		c.inserted.Add(int64(n))
+	}
+}
+
+func (c *Counters) Updated(n int) {
+	if c != nil {
+		c.updated.Add(int64(n))
+	}
+}
+
+func (c *Counters) Linked(n int) {
+	if c != nil {
+		c.linked.Add(int64(n))
+	}
+}
+
+func (c *Counters) Failed(n int) {
+	if c != nil {
+		c.failed.Add(int64(n))
+	}
+}
+
+func (c *Counters) Requested(token string) {
+	if c != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		if c.requests == nil {
+			c.requests = make(model.GithubRequests)
+		}
+
+		c.requests[token]++
+	}
+}
+
+// Store persists the runs, see model.JobRunRepo.
+type Store interface {
+	Save(ctx context.Context, run model.JobRun) (int64, error)
+	Finish(ctx context.Context, run model.JobRun) error
+}
+
+type Recorder struct {
+	store Store
+}
+
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store}
+}
+
+func summarize(err error) string {
+	summary := err.Error()
+
+	if len(summary) > maxErrorLength {
+		summary = summary[:maxErrorLength] + "..."
+	}
+
+	return summary
+}
+
+// Run the job and record its status and counts, a run that could not be recorded is logged but does not stop the job.
+func (r *Recorder) Record(ctx context.Context, jobType, arguments string, job func(ctx context.Context) error) error {
+	run := model.JobRun{
+		Type:      jobType,
+		Arguments: arguments,
+		Status:    model.JobRunRunning,
+		StartedAt: time.Now(),
+	}
+
+	id, err := r.store.Save(ctx, run)
+
+	if err != nil {
+		slog.Error("failed to save job run", slog.String("type", jobType), slog.Any("error", err))
+	}
+
+	counters := &Counters{}
+	err = job(WithCounters(ctx, counters))
+
+	finishedAt := time.Now()
+
+	run.Id = int(id)
+	run.FinishedAt = &finishedAt
+	run.Scraped = int(counters.scraped.Load())
+	run.Inserted = int(counters.inserted.Load())
+	run.Updated = int(counters.updated.Load())
+	run.Linked = int(counters.linked.Load())
+	run.Failed = int(counters.failed.Load())
+	run.GithubRequests = counters.requests
+
+	for token, requests := range run.GithubRequests {
+		slog.Info("GitHub requests of the job", slog.String("type", jobType), slog.String("token", token), slog.Int("requests", requests))
+	}
+
+	switch {
+	case err == nil:
+		run.Status = model.JobRunSucceeded
+	case errors.Is(err, ErrSkipped):
+		run.Status = model.JobRunSkipped
+		run.Error = dbutils.NewNullString(summarize(err))
+		err = nil
+	default:
+		run.Status = model.JobRunFailed
+		run.Error = dbutils.NewNullString(summarize(err))
+	}
+
+	if id > 0 {
+		// The run is recorded even if the job was cancelled.
+		if finishErr := r.store.Finish(context.WithoutCancel(ctx), run); finishErr != nil {
+			slog.Error("failed to finish job run", slog.String("type", jobType), slog.Any("error", finishErr))
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,132 @@
+package jobrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+type memStore struct {
+	saved    []model.JobRun
+	finished []model.JobRun
+}
+
+func (s *memStore) Save(ctx context.Context, run model.JobRun) (int64, error) {
+	s.saved = append(s.saved, run)
+	return int64(len(s.saved)), nil
+}
+
+func (s *memStore) Finish(ctx context.Context, run model.JobRun) error {
+	s.finished = append(s.finished, run)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	store := &memStore{}
+	recorder := NewRecorder(store)
+
+	err := recorder.Record(context.Background(), "scrape", "repository --since=daily", func(ctx context.Context) error {
+		Count(ctx).Scraped(25)
+		Count(ctx).Inserted(25)
+		Count(ctx).Linked(3)
+		Count(ctx).Failed(1)
+		Count(ctx).Requested("#1 (...abcd)")
+		Count(ctx).Requested("#1 (...abcd)")
+		Count(ctx).Requested("#2 (...efgh)")
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := store.finished[0]
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{store.saved[0].Status, model.JobRunRunning},
+		{run.Id, 1},
+		{run.Type, "scrape"},
+		{run.Arguments, "repository --since=daily"},
+		{run.Status, model.JobRunSucceeded},
+		{run.Scraped, 25},
+		{run.Inserted, 25},
+		{run.Updated, 0},
+		{run.Linked, 3},
+		{run.Failed, 1},
+		{run.GithubRequests["#1 (...abcd)"], 2},
+		{run.GithubRequests["#2 (...efgh)"], 1},
+		{run.Error.Valid, false},
+		{run.FinishedAt != nil, true},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestRecordErrors(t *testing.T) {
+	store := &memStore{}
+	recorder := NewRecorder(store)
+	ctx := context.Background()
+
+	failure := errors.New(strings.Repeat("x", 2000))
+
+	err := recorder.Record(ctx, "sync", "repository", func(ctx context.Context) error {
+		Count(ctx).Updated(10)
+		return failure
+	})
+
+	if !errors.Is(err, failure) {
+		t.Errorf("expected the job error to be returned but got: %v", err)
+	}
+
+	err = recorder.Record(ctx, "link", "developer", func(ctx context.Context) error {
+		return fmt.Errorf("%w: locked", ErrSkipped)
+	})
+
+	if err != nil {
+		t.Errorf("expected a skipped job not to return an error but got: %v", err)
+	}
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{store.finished[0].Status, model.JobRunFailed},
+		{store.finished[0].Updated, 10},
+		{len(store.finished[0].Error.String), maxErrorLength + 3},
+		{store.finished[1].Status, model.JobRunSkipped},
+		{store.finished[1].Error.String, "job skipped: locked"},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestCountOutsideOfJob(t *testing.T) {
+	counters := Count(context.Background())
+
+	if counters != nil {
+		t.Fatal("expected no counters outside of a job")
+	}
+
+	// Counting outside of a job is a no-op.
+	counters.Scraped(1)
+	counters.Inserted(1)
+	counters.Updated(1)
+	counters.Linked(1)
+	counters.Failed(1)
+	counters.Requested("anonymous")
+}
@@ -2,6 +2,7 @@ package jwttoken
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -15,6 +16,17 @@ type AppClaim struct {
 	jwt.RegisteredClaims
 }
 
+// Check whether the claim has the given role, multiple roles are separated by commas, e.g. "user,admin".
+func (claim *AppClaim) HasRole(role string) bool {
+	for _, r := range strings.Split(claim.Role, ",") {
+		if strings.TrimSpace(r) == role {
+			return true
+		}
+	}
+
+	return false
+}
+
 type TokenService struct {
 	signingKey []byte
 }
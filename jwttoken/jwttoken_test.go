@@ -66,6 +66,18 @@ func TestVerify(t *testing.T) {
 				actual: claims.Subject,
 				want:   user.Username,
 			},
+			{
+				actual: claims.HasRole("admin"),
+				want:   true,
+			},
+			{
+				actual: claims.HasRole("user"),
+				want:   true,
+			},
+			{
+				actual: claims.HasRole("editor"),
+				want:   false,
+			},
 		}
 
 		for _, test := range expcts {
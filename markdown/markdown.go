@@ -0,0 +1,210 @@
+// Package markdown extracts a plain text excerpt and the first image from a README, so trending cards of repositories
+// with an empty or one-line description still have something to show.
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxExcerptLength = 1000
+
+var (
+	htmlComment    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	codeBlock      = regexp.MustCompile("(?ms)^\\s*(```|~~~).*?^\\s*(```|~~~)[ \\t]*$")
+	markdownImage  = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	htmlImage      = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+	inlineLink     = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	referenceLink  = regexp.MustCompile(`\[([^\]]*)\]\[[^\]]*\]`)
+	linkDefinition = regexp.MustCompile(`^\s*\[[^\]]+\]:\s*\S+`)
+	htmlTag        = regexp.MustCompile(`<[^>]*>`)
+	listMarker     = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+	emphasis       = regexp.MustCompile("\\*\\*|__|~~|`")
+	blankLines     = regexp.MustCompile(`\n\s*\n`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// Images that are status badges rather than a logo or a screenshot of the project.
+var badgeHosts = []string{
+	"shields.io",
+	"badgen.net",
+	"badge.fury.io",
+	"travis-ci.",
+	"circleci.com",
+	"codecov.io",
+	"coveralls.io",
+	"goreportcard.com",
+	"pkg.go.dev/badge",
+	"godoc.org",
+	"/badge",
+	"badge.svg",
+	"/workflows/",
+}
+
+func isBadge(src string) bool {
+	src = strings.ToLower(src)
+
+	for _, host := range badgeHosts {
+		if strings.Contains(src, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Remove the parts of the README that are never shown in an excerpt, e.g. comments and code blocks.
+func strip(readme string) string {
+	readme = strings.ReplaceAll(readme, "\r\n", "\n")
+	readme = htmlComment.ReplaceAllString(readme, "")
+
+	return codeBlock.ReplaceAllString(readme, "")
+}
+
+// A heading, a table or a horizontal rule is not a paragraph of the excerpt.
+func isParagraph(block string) bool {
+	lines := strings.Split(strings.TrimSpace(block), "\n")
+	first, last := strings.TrimSpace(lines[0]), strings.TrimSpace(lines[len(lines)-1])
+
+	if strings.HasPrefix(first, "#") || strings.HasPrefix(first, "|") {
+		return false
+	}
+
+	// A setext heading is underlined with = or -, a horizontal rule only has the rule.
+	return strings.Trim(last, "=-*_ ") != ""
+}
+
+// Turn a markdown paragraph into a single line of plain text.
+func plain(block string) string {
+	lines := make([]string, 0)
+
+	for _, line := range strings.Split(block, "\n") {
+		if linkDefinition.MatchString(line) {
+			continue
+		}
+
+		line = strings.TrimLeft(strings.TrimSpace(line), "> ")
+		lines = append(lines, listMarker.ReplaceAllString(line, ""))
+	}
+
+	text := strings.Join(lines, " ")
+	text = markdownImage.ReplaceAllString(text, "")
+	text = inlineLink.ReplaceAllString(text, "$1")
+	text = referenceLink.ReplaceAllString(text, "$1")
+	text = htmlTag.ReplaceAllString(text, " ")
+	text = emphasis.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	return strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+}
+
+func hasLetter(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
+
+// Cut the text at a word boundary so it is no longer than maxLength runes including the ellipsis.
+func truncate(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	runes := []rune(text)[:maxLength-3]
+
+	if i := strings.LastIndexFunc(string(runes), unicode.IsSpace); i > 0 {
+		return strings.TrimSpace(string(runes)[:i]) + "..."
+	}
+
+	return string(runes) + "..."
+}
+
+// Get the plain text of the first paragraphs of a README, headings, badges, images and code blocks are left out.
+func Excerpt(readme string) string {
+	paragraphs := make([]string, 0)
+	length := 0
+
+	for _, block := range blankLines.Split(strip(readme), -1) {
+		if strings.TrimSpace(block) == "" || !isParagraph(block) {
+			continue
+		}
+
+		text := plain(block)
+
+		if !hasLetter(text) {
+			continue
+		}
+
+		paragraphs = append(paragraphs, text)
+		length += utf8.RuneCountInString(text)
+
+		if length >= maxExcerptLength {
+			break
+		}
+	}
+
+	return truncate(strings.Join(paragraphs, "\n\n"), maxExcerptLength)
+}
+
+// Get the first image of a README that is not a badge, it is usually the logo or a screenshot of the project.
+// Relative images are resolved against the base url, e.g. the raw url of the README.
+func Image(readme string, base string) string {
+	readme = strip(readme)
+
+	type match struct {
+		start int
+		src   string
+	}
+
+	matches := make([]match, 0)
+
+	for _, pattern := range []*regexp.Regexp{markdownImage, htmlImage} {
+		for _, indexes := range pattern.FindAllStringSubmatchIndex(readme, -1) {
+			matches = append(matches, match{indexes[0], readme[indexes[2]:indexes[3]]})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		return a.start - b.start
+	})
+
+	for _, m := range matches {
+		if isBadge(m.src) {
+			continue
+		}
+
+		if src := resolve(base, html.UnescapeString(m.src)); src != "" {
+			return src
+		}
+	}
+
+	return ""
+}
+
+// Resolve the image against the base url, only http and https images are returned.
+func resolve(base string, src string) string {
+	ref, err := url.Parse(strings.TrimSpace(src))
+
+	if err != nil {
+		return ""
+	}
+
+	if !ref.IsAbs() {
+		baseURL, err := url.Parse(base)
+
+		if err != nil || !baseURL.IsAbs() {
+			return ""
+		}
+
+		ref = baseURL.ResolveReference(ref)
+	}
+
+	if ref.Scheme != "http" && ref.Scheme != "https" {
+		return ""
+	}
+
+	return ref.String()
+}
@@ -0,0 +1,82 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const readme = `<!-- generated, do not edit -->
+<p align="center">
+  <img src="https://img.shields.io/badge/go-1.22-blue" alt="go">
+  <img src="./assets/logo.svg" width="200" alt="logo">
+</p>
+
+# Trendshift
+
+[![Build](https://github.com/liweiyi88/trendshift-backend/actions/workflows/ci.yml/badge.svg)](https://github.com/liweiyi88/trendshift-backend/actions)
+
+Trendshift tracks the **trending** repositories and developers on [GitHub](https://github.com) &amp; keeps their history.
+
+` + "```bash\ngo run main.go serve\n```" + `
+
+Features
+========
+
+- Daily, weekly and monthly ` + "`trending`" + ` pages
+- Rank changes
+
+| Command | Description |
+| ------- | ----------- |
+| serve   | run the api |
+
+![screenshot](https://trendshift.io/screenshot.png)
+`
+
+func TestExcerpt(t *testing.T) {
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{Excerpt(readme), "Trendshift tracks the trending repositories and developers on GitHub & keeps their history.\n\nDaily, weekly and monthly trending pages Rank changes"},
+		{Excerpt("# Only a title"), ""},
+		{Excerpt(""), ""},
+		{Excerpt("A tool.\r\n\r\n---\r\n\r\nMore."), "A tool.\n\nMore."},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %q but got %q", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestExcerptTruncated(t *testing.T) {
+	excerpt := Excerpt(strings.Repeat("word ", 500))
+
+	if utf8.RuneCountInString(excerpt) > maxExcerptLength || !strings.HasSuffix(excerpt, "word...") {
+		t.Errorf("expect a truncated excerpt but got %d runes: %s", utf8.RuneCountInString(excerpt), excerpt)
+	}
+}
+
+func TestImage(t *testing.T) {
+	base := "https://raw.githubusercontent.com/liweiyi88/trendshift-backend/main/"
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{Image(readme, base), "https://raw.githubusercontent.com/liweiyi88/trendshift-backend/main/assets/logo.svg"},
+		{Image("![screenshot](https://trendshift.io/screenshot.png)", ""), "https://trendshift.io/screenshot.png"},
+		{Image("![logo](logo.png)", ""), ""},
+		{Image("![badge](https://img.shields.io/badge/a-b-c)", base), ""},
+		{Image("![inline](data:image/png;base64,AAAA)", base), ""},
+		{Image("no images", base), ""},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
@@ -6,23 +6,45 @@ import (
 	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
+// The popular repository highlighted next to a developer on the trending page.
+type PopularRepository struct {
+	RepositoryId dbutils.NullInt64 `json:"repository_id"`
+	FullName     string            `json:"full_name"`
+}
+
+type DeveloperTrending struct {
+	Trending
+	PopularRepository *PopularRepository `json:"popular_repository"`
+}
+
+func NewPopularRepository(fullName dbutils.NullString, repositoryId dbutils.NullInt64) *PopularRepository {
+	if !fullName.Valid {
+		return nil
+	}
+
+	return &PopularRepository{
+		RepositoryId: repositoryId,
+		FullName:     fullName.String,
+	}
+}
+
 type Developer struct {
-	Id              int                `json:"developer_id"` // primary key saved in DB.
-	GhId            int                `json:"id"`           // id from github repository api response.
-	Username        string             `json:"login"`        // github api use login as username.
-	AvatarUrl       string             `json:"avatar_url"`
-	Name            dbutils.NullString `json:"name"`
-	Company         dbutils.NullString `json:"company"`
-	Blog            dbutils.NullString `json:"blog"`
-	Location        dbutils.NullString `json:"location"`
-	Email           dbutils.NullString `json:"email"`
-	Bio             dbutils.NullString `json:"bio"`
-	TwitterUsername dbutils.NullString `json:"twitter_username"`
-	PublicRepos     int                `json:"public_repos"`
-	PublicGists     int                `json:"public_gists"`
-	Followers       int                `json:"followers"`
-	Following       int                `json:"following"`
-	Trendings       []Trending         `json:"trendings"`
-	CreatedAt       time.Time          `json:"created_at"`
-	UpdatedAt       time.Time          `json:"updated_at"`
+	Id              int                 `json:"developer_id"` // primary key saved in DB.
+	GhId            int                 `json:"id"`           // id from github repository api response.
+	Username        string              `json:"login"`        // github api use login as username.
+	AvatarUrl       string              `json:"avatar_url"`
+	Name            dbutils.NullString  `json:"name"`
+	Company         dbutils.NullString  `json:"company"`
+	Blog            dbutils.NullString  `json:"blog"`
+	Location        dbutils.NullString  `json:"location"`
+	Email           dbutils.NullString  `json:"email"`
+	Bio             dbutils.NullString  `json:"bio"`
+	TwitterUsername dbutils.NullString  `json:"twitter_username"`
+	PublicRepos     int                 `json:"public_repos"`
+	PublicGists     int                 `json:"public_gists"`
+	Followers       int                 `json:"followers"`
+	Following       int                 `json:"following"`
+	Trendings       []DeveloperTrending `json:"trendings"`
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
 }
@@ -13,8 +13,9 @@ import (
 
 type TrendingDeveloperResponse struct {
 	Developer
-	BestRanking   int `json:"best_ranking"`   // non db column field
-	FeaturedCount int `json:"featured_count"` // non db column field
+	BestRanking       int                `json:"best_ranking"`       // non db column field
+	FeaturedCount     int                `json:"featured_count"`     // non db column field
+	PopularRepository *PopularRepository `json:"popular_repository"` // non db column field, the popular repository of the latest trending.
 }
 
 type DeveloperRepo struct {
@@ -92,7 +93,7 @@ func (dr *DeveloperRepo) FindAll(ctx context.Context, opts ...any) ([]Developer,
 
 func (dr *DeveloperRepo) FindById(ctx context.Context, id int) (Developer, error) {
 	qb := dbutils.NewQueryBuilder()
-	qb.Query("select developers.*, trending_developers.`trend_date`, trending_developers.`rank`, trending_developers.`language` as `trending_language` from developers join trending_developers on developers.id = trending_developers.developer_id")
+	qb.Query("select developers.*, trending_developers.`trend_date`, trending_developers.`rank`, trending_developers.`language` as `trending_language`, trending_developers.`period`, trending_developers.`repo_full_name`, trending_developers.`repository_id` from developers join trending_developers on developers.id = trending_developers.developer_id")
 	qb.Where("developers.id = ?", id)
 	query, args := qb.GetQuery()
 
@@ -109,7 +110,9 @@ func (dr *DeveloperRepo) FindById(ctx context.Context, id int) (Developer, error
 	collectionMap := dbutils.NewCollectionMap[int, *Developer]()
 
 	for rows.Next() {
-		var trending Trending
+		var trending DeveloperTrending
+		var popularRepoFullName dbutils.NullString
+		var popularRepositoryId dbutils.NullInt64
 
 		if err := rows.Scan(
 			&developer.Id,
@@ -132,10 +135,15 @@ func (dr *DeveloperRepo) FindById(ctx context.Context, id int) (Developer, error
 			&trending.TrendDate,
 			&trending.Rank,
 			&trending.TrendingLanguage,
+			&trending.Period,
+			&popularRepoFullName,
+			&popularRepositoryId,
 		); err != nil {
 			return developer, err
 		}
 
+		trending.PopularRepository = NewPopularRepository(popularRepoFullName, popularRepositoryId)
+
 		if !collectionMap.Has(developer.Id) {
 			developer.Trendings = append(developer.Trendings, trending)
 			collectionMap.Set(developer.Id, &developer)
@@ -152,8 +160,39 @@ func (dr *DeveloperRepo) FindById(ctx context.Context, id int) (Developer, error
 	return developer, nil
 }
 
+// The criteria of the subquery that picks the popular repository from the latest trending of the same pages and date range,
+// the trending developers of the subquery are aliased as td.
+func popularRepositoryCriteria(options opt.Options) (string, []any) {
+	criteria := []string{"td.developer_id = developers.id", "td.repo_full_name is not null"}
+	args := make([]any, 0, 3)
+
+	if options.Language != "" {
+		criteria = append(criteria, "td.`language` = ?")
+		args = append(args, options.Language)
+	} else {
+		criteria = append(criteria, "td.`language` is null")
+	}
+
+	criteria = append(criteria, "td.`period` = ?")
+	args = append(args, PeriodOrDefault(options.Period))
+
+	if options.DateRange > 0 {
+		since := time.Now().AddDate(0, 0, -options.DateRange)
+		criteria = append(criteria, "td.`trend_date` > ?")
+		args = append(args, since.Format("2006-01-02"))
+	}
+
+	return strings.Join(criteria, " and "), args
+}
+
 func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any) ([]TrendingDeveloperResponse, error) {
-	query := "select developers.*, count(*) as count, min(trending_developers.`rank`) as best_ranking from developers join trending_developers on developers.id = trending_developers.developer_id"
+	options := opt.ExtractOptions(opts...)
+	popularWhere, popularArgs := popularRepositoryCriteria(options)
+
+	query := "select developers.*, count(*) as count, min(trending_developers.`rank`) as best_ranking, " +
+		"(select td.`repo_full_name` from trending_developers td where " + popularWhere + " order by td.trend_date desc, td.id desc limit 1) as popular_repo_full_name, " +
+		"(select td.`repository_id` from trending_developers td where " + popularWhere + " order by td.trend_date desc, td.id desc limit 1) as popular_repository_id " +
+		"from developers join trending_developers on developers.id = trending_developers.developer_id"
 
 	qb := dbutils.NewQueryBuilder()
 	qb.Query(query)
@@ -162,7 +201,6 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 	qb.OrderBy("best_ranking", "ASC")
 	qb.OrderBy("developers.id", "ASC")
 
-	options := opt.ExtractOptions(opts...)
 	lang, dateRange, limit := options.Language, options.DateRange, options.Limit
 
 	if lang != "" {
@@ -171,6 +209,8 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 		qb.Where("`trending_developers`.`language` is null", nil)
 	}
 
+	qb.Where("`trending_developers`.`period` = ?", PeriodOrDefault(options.Period))
+
 	if dateRange > 0 {
 		since := time.Now().AddDate(0, 0, -dateRange)
 		qb.Where("`trending_developers`.`trend_date` > ?", since.Format("2006-01-02"))
@@ -184,6 +224,9 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 
 	q, args := qb.GetQuery()
 
+	// The placeholders of the subqueries come before the ones of the where clause.
+	args = append(append(append([]any{}, popularArgs...), popularArgs...), args...)
+
 	rows, err := dr.db.QueryContext(ctx, q, args...)
 
 	if err != nil {
@@ -196,6 +239,8 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 
 	for rows.Next() {
 		var dev TrendingDeveloperResponse
+		var popularRepoFullName dbutils.NullString
+		var popularRepositoryId dbutils.NullInt64
 
 		if err := rows.Scan(
 			&dev.Id,
@@ -217,11 +262,15 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 			&dev.UpdatedAt,
 			&dev.FeaturedCount,
 			&dev.BestRanking,
+			&popularRepoFullName,
+			&popularRepositoryId,
 		); err != nil {
 			return nil, err
 
 		}
 
+		dev.PopularRepository = NewPopularRepository(popularRepoFullName, popularRepositoryId)
+
 		developers = append(developers, dev)
 	}
 
@@ -232,12 +281,21 @@ func (dr *DeveloperRepo) FindTrendingDevelopers(ctx context.Context, opts ...any
 	return developers, nil
 }
 
+// Update the developer, the followers are also recorded as a snapshot so their history is kept.
 func (dr *DeveloperRepo) Update(ctx context.Context, developer Developer) error {
 	query := "UPDATE `developers` SET avatar_url = ?, name = ?, company = ?, blog = ?, location = ?, email = ?, bio = ?, twitter_username = ?, public_repos = ?, public_gists = ?, followers = ?, following = ?, updated_at = ? WHERE id = ?"
 
+	tx, err := dr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin update developer transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
 	updatedAt := time.Now()
 
-	result, err := dr.db.ExecContext(
+	result, err := tx.ExecContext(
 		ctx, query,
 		developer.AvatarUrl,
 		developer.Name,
@@ -268,17 +326,76 @@ func (dr *DeveloperRepo) Update(ctx context.Context, developer Developer) error
 		return fmt.Errorf("unexpected number of rows affected after update: %d", n)
 	}
 
+	if err := insertDeveloperMetric(ctx, tx, developer.Id, developer.Followers); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update developer transaction: %v", err)
+	}
+
 	return nil
 }
 
+// Mark the developer as synced when it has not changed on GitHub, the unchanged followers are recorded as a snapshot.
+func (dr *DeveloperRepo) Touch(ctx context.Context, id int) error {
+	now := time.Now().Format(time.DateTime)
+
+	tx, err := dr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin touch developer transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE `developers` SET updated_at = ? WHERE id = ?"
+
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to run developers touch query, developer id: %d, error: %v", id, err)
+	}
+
+	query = "INSERT INTO `developer_metrics` (`developer_id`, `followers`, `recorded_at`) SELECT `id`, `followers`, ? FROM `developers` WHERE `id` = ?"
+
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to insert developer metric, developer id: %d, error: %v", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit touch developer transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Mark the developer as synced when it is missing or blocked on GitHub, no snapshot is recorded as its followers are unknown.
+func (dr *DeveloperRepo) TouchMissing(ctx context.Context, id int) error {
+	query := "UPDATE `developers` SET updated_at = ? WHERE id = ?"
+
+	if _, err := dr.db.ExecContext(ctx, query, time.Now().Format(time.DateTime), id); err != nil {
+		return fmt.Errorf("failed to run developers touch query, developer id: %d, error: %v", id, err)
+	}
+
+	return nil
+}
+
+// Save the developer along with the first snapshot of its followers.
 func (dr *DeveloperRepo) Save(ctx context.Context, developer Developer) (int64, error) {
 	query := "INSERT INTO `developers` (`gh_id`, `username`, `avatar_url`, `name`, `company`, `blog`, `location`, `email`, `bio`, `twitter_username`, `public_repos`, `public_gists`, `followers`, `following`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	var lastInsertId int64
 
+	tx, err := dr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return lastInsertId, fmt.Errorf("failed to begin save developer transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
 	createdAt, updatedAt := time.Now(), time.Now()
 
-	result, err := dr.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		developer.GhId,
 		developer.Username,
 		developer.AvatarUrl,
@@ -312,6 +429,14 @@ func (dr *DeveloperRepo) Save(ctx context.Context, developer Developer) (int64,
 		return lastInsertId, fmt.Errorf("developers insert rows affected returns error: %v", err)
 	}
 
+	if err := insertDeveloperMetric(ctx, tx, int(lastInsertId), developer.Followers); err != nil {
+		return lastInsertId, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return lastInsertId, fmt.Errorf("failed to commit save developer transaction: %v", err)
+	}
+
 	return lastInsertId, nil
 }
 
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// The validators of a GitHub api resource, sent with the next request so GitHub can answer 304 Not Modified.
+type GithubEtag struct {
+	Resource     string // the api path, e.g. /repos/liweiyi88/onedump.
+	ETag         string
+	LastModified string
+	UpdatedAt    time.Time
+}
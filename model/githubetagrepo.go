@@ -0,0 +1,43 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+type GithubEtagRepo struct {
+	db database.DB
+}
+
+func NewGithubEtagRepo(db database.DB) *GithubEtagRepo {
+	return &GithubEtagRepo{
+		db: db,
+	}
+}
+
+// Find the validators of a resource, it returns sql.ErrNoRows when the resource has not been fetched before.
+func (er *GithubEtagRepo) Find(ctx context.Context, resource string) (GithubEtag, error) {
+	query := "SELECT `resource`, `etag`, `last_modified`, `updated_at` FROM `github_etags` WHERE `resource` = ?"
+
+	var etag GithubEtag
+
+	err := er.db.QueryRowContext(ctx, query, resource).Scan(&etag.Resource, &etag.ETag, &etag.LastModified, &etag.UpdatedAt)
+
+	return etag, err
+}
+
+func (er *GithubEtagRepo) Save(ctx context.Context, etag GithubEtag) error {
+	query := "INSERT INTO `github_etags` (`resource`, `etag`, `last_modified`, `updated_at`) VALUES (?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE `etag` = VALUES(`etag`), `last_modified` = VALUES(`last_modified`), `updated_at` = VALUES(`updated_at`)"
+
+	_, err := er.db.ExecContext(ctx, query, etag.Resource, etag.ETag, etag.LastModified, time.Now().Format(time.DateTime))
+
+	if err != nil {
+		return fmt.Errorf("failed to save github etag, resource: %s, error: %v", etag.Resource, err)
+	}
+
+	return nil
+}
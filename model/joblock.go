@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// JobLock is a lease on a job held by one process, it expires unless the owner keeps renewing it.
+type JobLock struct {
+	Name        string    `json:"name"`
+	Owner       string    `json:"owner"`
+	AcquiredAt  time.Time `json:"acquired_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Expired     bool      `json:"expired"`
+}
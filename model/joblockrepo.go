@@ -0,0 +1,127 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+type JobLockRepo struct {
+	db database.DB
+}
+
+func NewJobLockRepo(db database.DB) *JobLockRepo {
+	return &JobLockRepo{
+		db: db,
+	}
+}
+
+// The database clock is used for the lease, so processes on different hosts agree on when a lock expires.
+func leaseSeconds(lease time.Duration) int {
+	seconds := int(lease.Seconds())
+
+	if seconds < 1 {
+		return 1
+	}
+
+	return seconds
+}
+
+// Acquire the lock when it is free or expired, it returns false when the lock is held by another owner.
+func (jr *JobLockRepo) Acquire(ctx context.Context, name, owner string, lease time.Duration) (bool, error) {
+	// expires_at is assigned last as MySQL evaluates the assignments from left to right.
+	query := "INSERT INTO `job_locks` (`name`, `owner`, `acquired_at`, `heartbeat_at`, `expires_at`) VALUES (?, ?, NOW(), NOW(), NOW() + INTERVAL ? SECOND) " +
+		"ON DUPLICATE KEY UPDATE " +
+		"`owner` = IF(`expires_at` < NOW(), VALUES(`owner`), `owner`), " +
+		"`acquired_at` = IF(`expires_at` < NOW(), VALUES(`acquired_at`), `acquired_at`), " +
+		"`heartbeat_at` = IF(`expires_at` < NOW(), VALUES(`heartbeat_at`), `heartbeat_at`), " +
+		"`expires_at` = IF(`expires_at` < NOW(), VALUES(`expires_at`), `expires_at`)"
+
+	if _, err := jr.db.ExecContext(ctx, query, name, owner, leaseSeconds(lease)); err != nil {
+		return false, fmt.Errorf("failed to acquire job lock, name: %s, error: %v", name, err)
+	}
+
+	var currentOwner string
+
+	if err := jr.db.QueryRowContext(ctx, "SELECT `owner` FROM `job_locks` WHERE `name` = ?", name).Scan(&currentOwner); err != nil {
+		return false, fmt.Errorf("failed to query job lock owner, name: %s, error: %v", name, err)
+	}
+
+	return currentOwner == owner, nil
+}
+
+// Extend the lease of a lock, it returns false when the lock is no longer held by the owner.
+func (jr *JobLockRepo) Renew(ctx context.Context, name, owner string, lease time.Duration) (bool, error) {
+	query := "UPDATE `job_locks` SET `heartbeat_at` = NOW(), `expires_at` = NOW() + INTERVAL ? SECOND WHERE `name` = ? AND `owner` = ?"
+
+	result, err := jr.db.ExecContext(ctx, query, leaseSeconds(lease), name, owner)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to renew job lock, name: %s, error: %v", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("renew job lock rows affected returns error: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (jr *JobLockRepo) Release(ctx context.Context, name, owner string) error {
+	if _, err := jr.db.ExecContext(ctx, "DELETE FROM `job_locks` WHERE `name` = ? AND `owner` = ?", name, owner); err != nil {
+		return fmt.Errorf("failed to release job lock, name: %s, error: %v", name, err)
+	}
+
+	return nil
+}
+
+// Release a lock regardless of its owner, e.g. the lock of a crashed process that should not wait for the lease to expire.
+func (jr *JobLockRepo) ForceRelease(ctx context.Context, name string) (bool, error) {
+	result, err := jr.db.ExecContext(ctx, "DELETE FROM `job_locks` WHERE `name` = ?", name)
+
+	if err != nil {
+		return false, fmt.Errorf("failed to force release job lock, name: %s, error: %v", name, err)
+	}
+
+	affected, err := result.RowsAffected()
+
+	if err != nil {
+		return false, fmt.Errorf("force release job lock rows affected returns error: %v", err)
+	}
+
+	return affected > 0, nil
+}
+
+func (jr *JobLockRepo) FindAll(ctx context.Context) ([]JobLock, error) {
+	query := "SELECT `name`, `owner`, `acquired_at`, `heartbeat_at`, `expires_at`, `expires_at` < NOW() FROM `job_locks` ORDER BY `name` ASC"
+
+	rows, err := jr.db.QueryContext(ctx, query)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job locks: %v", err)
+	}
+
+	defer rows.Close()
+
+	locks := make([]JobLock, 0)
+
+	for rows.Next() {
+		var lock JobLock
+
+		if err := rows.Scan(&lock.Name, &lock.Owner, &lock.AcquiredAt, &lock.HeartbeatAt, &lock.ExpiresAt, &lock.Expired); err != nil {
+			return locks, fmt.Errorf("failed to scan job lock: %v", err)
+		}
+
+		locks = append(locks, lock)
+	}
+
+	if err = rows.Err(); err != nil {
+		return locks, err
+	}
+
+	return locks, nil
+}
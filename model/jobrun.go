@@ -0,0 +1,79 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Status of a command execution, a run is left running if the process crashed.
+const (
+	JobRunRunning   = "running"
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+	JobRunSkipped   = "skipped" // the job was running in another process.
+)
+
+// The GitHub requests of a run per token, keyed by the token label. It is saved as a json column.
+type GithubRequests map[string]int
+
+func (r GithubRequests) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+
+	value, err := json.Marshal(r)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode github requests: %v", err)
+	}
+
+	return string(value), nil
+}
+
+func (r *GithubRequests) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*r = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported github requests type: %T", src)
+	}
+
+	return json.Unmarshal(data, r)
+}
+
+type JobRun struct {
+	Id         int                `json:"id"`
+	Type       string             `json:"type"`
+	Arguments  string             `json:"arguments"`
+	Status     string             `json:"status"`
+	Scraped    int                `json:"scraped"`  // rows parsed from trending pages.
+	Inserted   int                `json:"inserted"` // trending rows, repositories and developers inserted.
+	Updated    int                `json:"updated"`  // repositories and developers updated from GitHub or in full text search.
+	Linked     int                `json:"linked"`   // trending rows linked with repositories or developers.
+	Failed     int                `json:"failed"`   // pages or records that failed or were skipped.
+	Error      dbutils.NullString `json:"error"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at"`
+
+	GithubRequests GithubRequests `json:"github_requests"`
+}
+
+func IsValidJobRunStatus(status string) bool {
+	switch status {
+	case JobRunRunning, JobRunSucceeded, JobRunFailed, JobRunSkipped:
+		return true
+	default:
+		return false
+	}
+}
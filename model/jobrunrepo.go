@@ -0,0 +1,149 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+const defaultJobRunsLimit = 50
+
+type JobRunRepo struct {
+	db database.DB
+}
+
+func NewJobRunRepo(db database.DB) *JobRunRepo {
+	return &JobRunRepo{
+		db: db,
+	}
+}
+
+// Save a started run and return its id.
+func (jr *JobRunRepo) Save(ctx context.Context, run JobRun) (int64, error) {
+	query := "INSERT INTO `job_runs` (`type`, `arguments`, `status`, `started_at`) VALUES (?, ?, ?, ?)"
+
+	startedAt := time.Now()
+
+	if !run.StartedAt.IsZero() {
+		startedAt = run.StartedAt
+	}
+
+	result, err := jr.db.ExecContext(ctx, query, run.Type, run.Arguments, run.Status, startedAt.Format(time.DateTime))
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to exec insert job_runs query to db, type: %s, error: %v", run.Type, err)
+	}
+
+	lastInsertId, err := result.LastInsertId()
+
+	if err != nil {
+		return 0, fmt.Errorf("job_runs insert last insert id returns error: %v", err)
+	}
+
+	return lastInsertId, nil
+}
+
+// Save the status, counts and error of a finished run.
+func (jr *JobRunRepo) Finish(ctx context.Context, run JobRun) error {
+	query := "UPDATE `job_runs` SET `status` = ?, `scraped` = ?, `inserted` = ?, `updated` = ?, `linked` = ?, `failed` = ?, `error` = ?, `finished_at` = ?, `github_requests` = ? WHERE `id` = ?"
+
+	finishedAt := time.Now()
+
+	if run.FinishedAt != nil {
+		finishedAt = *run.FinishedAt
+	}
+
+	_, err := jr.db.ExecContext(
+		ctx,
+		query,
+		run.Status,
+		run.Scraped,
+		run.Inserted,
+		run.Updated,
+		run.Linked,
+		run.Failed,
+		run.Error,
+		finishedAt.Format(time.DateTime),
+		run.GithubRequests,
+		run.Id,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to exec update job_runs query to db, id: %d, error: %v", run.Id, err)
+	}
+
+	return nil
+}
+
+func scanJobRun(scan func(dest ...any) error) (JobRun, error) {
+	var run JobRun
+	var finishedAt sql.NullTime
+
+	err := scan(&run.Id, &run.Type, &run.Arguments, &run.Status, &run.Scraped, &run.Inserted, &run.Updated, &run.Linked, &run.Failed, &run.Error, &run.StartedAt, &finishedAt, &run.GithubRequests)
+
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+
+	return run, err
+}
+
+// Find the latest runs, filtered by the type and the status when they are given.
+func (jr *JobRunRepo) FindAll(ctx context.Context, jobType, status string, opts ...any) ([]JobRun, error) {
+	limit := opt.ExtractOptions(opts...).Limit
+
+	if limit <= 0 {
+		limit = defaultJobRunsLimit
+	}
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT * FROM job_runs")
+
+	if jobType != "" {
+		qb.Where("type = ?", jobType)
+	}
+
+	if status != "" {
+		qb.Where("status = ?", status)
+	}
+
+	qb.OrderBy("id", "DESC")
+	qb.Limit(limit)
+
+	query, args := qb.GetQuery()
+
+	rows, err := jr.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %v", err)
+	}
+
+	defer rows.Close()
+
+	runs := make([]JobRun, 0)
+
+	for rows.Next() {
+		run, err := scanJobRun(rows.Scan)
+
+		if err != nil {
+			return runs, fmt.Errorf("failed to scan job run: %v", err)
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return runs, err
+	}
+
+	return runs, nil
+}
+
+func (jr *JobRunRepo) FindById(ctx context.Context, id int) (JobRun, error) {
+	return scanJobRun(jr.db.QueryRowContext(ctx, "SELECT * FROM `job_runs` WHERE `id` = ?", id).Scan)
+}
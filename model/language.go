@@ -0,0 +1,22 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// A programming language tracked on the GitHub trending page.
+type Language struct {
+	Id           int       `json:"id"`
+	Name         string    `json:"name"`
+	Slug         string    `json:"slug"` // the language used in the trending page url, e.g. "c++" for github.com/trending/c++.
+	Enabled      bool      `json:"enabled"`
+	ExpectedRows int       `json:"expected_rows"` // the minimum number of rows on its trending page, a scrape with fewer rows is reported as unhealthy.
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Normalise a language slug the same way as the language column of the trending tables.
+func LanguageSlug(slug string) string {
+	return strings.ToLower(strings.TrimSpace(slug))
+}
@@ -0,0 +1,29 @@
+package model
+
+import "testing"
+
+func TestLanguageSlug(t *testing.T) {
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{
+			actual: LanguageSlug("Kotlin"),
+			want:   "kotlin",
+		},
+		{
+			actual: LanguageSlug(" C++ "),
+			want:   "c++",
+		},
+		{
+			actual: LanguageSlug("c#"),
+			want:   "c#",
+		},
+	}
+
+	for _, test := range expcts {
+		if test.actual != test.want {
+			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
+		}
+	}
+}
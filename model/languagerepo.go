@@ -0,0 +1,145 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+const languageColumns = "`id`, `name`, `slug`, `enabled`, `expected_rows`, `created_at`, `updated_at`"
+
+type LanguageRepo struct {
+	db database.DB
+}
+
+func NewLanguageRepo(db database.DB) *LanguageRepo {
+	return &LanguageRepo{
+		db: db,
+	}
+}
+
+func (lr *LanguageRepo) find(ctx context.Context, query string, args ...any) ([]Language, error) {
+	rows, err := lr.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	languages := make([]Language, 0)
+
+	for rows.Next() {
+		var language Language
+
+		if err := rows.Scan(&language.Id, &language.Name, &language.Slug, &language.Enabled, &language.ExpectedRows, &language.CreatedAt, &language.UpdatedAt); err != nil {
+			return languages, err
+		}
+
+		languages = append(languages, language)
+	}
+
+	if err = rows.Err(); err != nil {
+		return languages, err
+	}
+
+	return languages, nil
+}
+
+// Find all languages including the disabled ones.
+func (lr *LanguageRepo) FindAll(ctx context.Context) ([]Language, error) {
+	return lr.find(ctx, "SELECT "+languageColumns+" FROM `languages` ORDER BY `name` ASC")
+}
+
+// Find the languages to scrape and to show on the website.
+func (lr *LanguageRepo) FindEnabled(ctx context.Context) ([]Language, error) {
+	return lr.find(ctx, "SELECT "+languageColumns+" FROM `languages` WHERE `enabled` = 1 ORDER BY `name` ASC")
+}
+
+func (lr *LanguageRepo) FindById(ctx context.Context, id int) (Language, error) {
+	query := "SELECT " + languageColumns + " FROM `languages` WHERE `id` = ?"
+
+	var language Language
+
+	row := lr.db.QueryRowContext(ctx, query, id)
+
+	if err := row.Scan(&language.Id, &language.Name, &language.Slug, &language.Enabled, &language.ExpectedRows, &language.CreatedAt, &language.UpdatedAt); err != nil {
+		return language, err
+	}
+
+	return language, nil
+}
+
+func (lr *LanguageRepo) FindBySlug(ctx context.Context, slug string) (Language, error) {
+	query := "SELECT " + languageColumns + " FROM `languages` WHERE `slug` = ?"
+
+	var language Language
+
+	row := lr.db.QueryRowContext(ctx, query, LanguageSlug(slug))
+
+	if err := row.Scan(&language.Id, &language.Name, &language.Slug, &language.Enabled, &language.ExpectedRows, &language.CreatedAt, &language.UpdatedAt); err != nil {
+		return language, err
+	}
+
+	return language, nil
+}
+
+func (lr *LanguageRepo) Save(ctx context.Context, language Language) (int, error) {
+	query := "INSERT INTO `languages` (`name`, `slug`, `enabled`, `expected_rows`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?)"
+
+	var lastInsertId int64
+
+	now := time.Now()
+
+	result, err := lr.db.ExecContext(ctx, query,
+		language.Name,
+		LanguageSlug(language.Slug),
+		language.Enabled,
+		language.ExpectedRows,
+		now.Format(time.DateTime),
+		now.Format(time.DateTime))
+
+	if err != nil {
+		return int(lastInsertId), fmt.Errorf("failed to exec insert languages query to db, error: %v", err)
+	}
+
+	lastInsertId, err = result.LastInsertId()
+
+	if err != nil {
+		return int(lastInsertId), fmt.Errorf("failed to get languages last insert id after insert, error: %v", err)
+	}
+
+	_, err = result.RowsAffected()
+
+	if err != nil {
+		return int(lastInsertId), fmt.Errorf("language insert rows affected returns error: %v", err)
+	}
+
+	return int(lastInsertId), nil
+}
+
+func (lr *LanguageRepo) Update(ctx context.Context, language Language) error {
+	query := "UPDATE `languages` SET `name` = ?, `slug` = ?, `enabled` = ?, `expected_rows` = ?, `updated_at` = ? WHERE `id` = ?"
+
+	result, err := lr.db.ExecContext(ctx, query,
+		language.Name,
+		LanguageSlug(language.Slug),
+		language.Enabled,
+		language.ExpectedRows,
+		time.Now().Format(time.DateTime),
+		language.Id)
+
+	if err != nil {
+		return fmt.Errorf("failed to exec update languages query to db, language: %s, error: %v", language.Slug, err)
+	}
+
+	_, err = result.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("language update rows affected returns error: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Intervals of the metrics over time, a point is the latest snapshot within the interval.
+const (
+	DayInterval  = "day"
+	WeekInterval = "week"
+)
+
+func IsValidInterval(interval string) bool {
+	switch interval {
+	case DayInterval, WeekInterval:
+		return true
+	default:
+		return false
+	}
+}
+
+// The first day of the interval that the time falls in, weeks start on Monday.
+func IntervalDate(t time.Time, interval string) string {
+	if interval == WeekInterval {
+		t = t.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+// Stars and forks of a repository at the end of an interval.
+// The forks are null for the star history backfilled from the stargazers.
+type RepositoryMetric struct {
+	Date        string            `json:"date"` // the first day of the interval.
+	Stars       int               `json:"stars"`
+	Forks       dbutils.NullInt64 `json:"forks"`
+	StarsGained int               `json:"stars_gained"` // compared with the previous interval.
+	ForksGained dbutils.NullInt64 `json:"forks_gained"`
+	RecordedAt  time.Time         `json:"recorded_at"`
+}
+
+// Followers of a developer at the end of an interval.
+type DeveloperMetric struct {
+	Date            string    `json:"date"` // the first day of the interval.
+	Followers       int       `json:"followers"`
+	FollowersGained int       `json:"followers_gained"` // compared with the previous interval.
+	RecordedAt      time.Time `json:"recorded_at"`
+}
+
+// Keep the latest of the snapshots sorted by time in each interval and calculate the growth,
+// the growth of the first interval is compared with the snapshot before the range when there is one.
+func RepositoryMetrics(snapshots []RepositoryMetric, previous *RepositoryMetric, interval string) []RepositoryMetric {
+	metrics := make([]RepositoryMetric, 0)
+
+	for _, snapshot := range snapshots {
+		snapshot.Date = IntervalDate(snapshot.RecordedAt, interval)
+
+		if len(metrics) > 0 && metrics[len(metrics)-1].Date == snapshot.Date {
+			metrics[len(metrics)-1] = snapshot
+			continue
+		}
+
+		metrics = append(metrics, snapshot)
+	}
+
+	for i := range metrics {
+		if i > 0 {
+			previous = &metrics[i-1]
+		}
+
+		if previous == nil {
+			continue
+		}
+
+		metrics[i].StarsGained = metrics[i].Stars - previous.Stars
+
+		if metrics[i].Forks.Valid && previous.Forks.Valid {
+			metrics[i].ForksGained.Int64, metrics[i].ForksGained.Valid = metrics[i].Forks.Int64-previous.Forks.Int64, true
+		}
+	}
+
+	return metrics
+}
+
+// Same as RepositoryMetrics for the followers of a developer.
+func DeveloperMetrics(snapshots []DeveloperMetric, previous *DeveloperMetric, interval string) []DeveloperMetric {
+	metrics := make([]DeveloperMetric, 0)
+
+	for _, snapshot := range snapshots {
+		snapshot.Date = IntervalDate(snapshot.RecordedAt, interval)
+
+		if len(metrics) > 0 && metrics[len(metrics)-1].Date == snapshot.Date {
+			metrics[len(metrics)-1] = snapshot
+			continue
+		}
+
+		metrics = append(metrics, snapshot)
+	}
+
+	for i := range metrics {
+		if i > 0 {
+			previous = &metrics[i-1]
+		}
+
+		if previous != nil {
+			metrics[i].FollowersGained = metrics[i].Followers - previous.Followers
+		}
+	}
+
+	return metrics
+}
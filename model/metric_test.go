@@ -0,0 +1,117 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+func TestIntervalDate(t *testing.T) {
+	// 2024-05-15 is a Wednesday.
+	wednesday := time.Date(2024, 5, 15, 13, 30, 0, 0, time.UTC)
+	sunday := time.Date(2024, 5, 19, 23, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 5, 20, 0, 0, 0, 0, time.UTC)
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{IntervalDate(wednesday, DayInterval), "2024-05-15"},
+		{IntervalDate(wednesday, WeekInterval), "2024-05-13"},
+		{IntervalDate(sunday, WeekInterval), "2024-05-13"},
+		{IntervalDate(monday, WeekInterval), "2024-05-20"},
+		{IsValidInterval(DayInterval), true},
+		{IsValidInterval(WeekInterval), true},
+		{IsValidInterval("month"), false},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestRepositoryMetrics(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	forks := func(forks int64) dbutils.NullInt64 {
+		return dbutils.NullInt64{NullInt64: sql.NullInt64{Int64: forks, Valid: true}}
+	}
+
+	snapshots := []RepositoryMetric{
+		{Stars: 80, RecordedAt: at(12, 23)}, // backfilled from the stargazers without forks.
+		{Stars: 100, Forks: forks(10), RecordedAt: at(13, 1)},
+		{Stars: 120, Forks: forks(11), RecordedAt: at(13, 9)},
+		{Stars: 150, Forks: forks(12), RecordedAt: at(15, 9)},
+		{Stars: 200, Forks: forks(15), RecordedAt: at(20, 9)},
+	}
+
+	daily := RepositoryMetrics(snapshots, &RepositoryMetric{Stars: 70}, DayInterval)
+	weekly := RepositoryMetrics(snapshots, nil, WeekInterval)
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{len(daily), 4},
+		{daily[0].Date, "2024-05-12"},
+		{daily[0].StarsGained, 10},
+		{daily[0].ForksGained.Valid, false},
+		{daily[1].Date, "2024-05-13"},
+		{daily[1].Stars, 120},
+		{daily[1].StarsGained, 40},
+		{daily[1].ForksGained.Valid, false},
+		{daily[2].Date, "2024-05-15"},
+		{daily[2].StarsGained, 30},
+		{daily[2].ForksGained.Int64, int64(1)},
+		{daily[3].StarsGained, 50},
+		{daily[3].ForksGained.Int64, int64(3)},
+		{len(weekly), 3},
+		{weekly[0].Date, "2024-05-06"},
+		{weekly[0].StarsGained, 0},
+		{weekly[1].Date, "2024-05-13"},
+		{weekly[1].Stars, 150},
+		{weekly[1].StarsGained, 70},
+		{weekly[2].Date, "2024-05-20"},
+		{weekly[2].StarsGained, 50},
+		{len(RepositoryMetrics(nil, nil, DayInterval)), 0},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestDeveloperMetrics(t *testing.T) {
+	snapshots := []DeveloperMetric{
+		{Followers: 10, RecordedAt: time.Date(2024, 5, 13, 1, 0, 0, 0, time.UTC)},
+		{Followers: 12, RecordedAt: time.Date(2024, 5, 14, 1, 0, 0, 0, time.UTC)},
+		{Followers: 11, RecordedAt: time.Date(2024, 5, 14, 9, 0, 0, 0, time.UTC)},
+	}
+
+	metrics := DeveloperMetrics(snapshots, &DeveloperMetric{Followers: 8}, DayInterval)
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{len(metrics), 2},
+		{metrics[0].FollowersGained, 2},
+		{metrics[1].Date, "2024-05-14"},
+		{metrics[1].Followers, 11},
+		{metrics[1].FollowersGained, 1},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
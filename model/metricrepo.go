@@ -0,0 +1,156 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+type MetricRepo struct {
+	db database.DB
+}
+
+func NewMetricRepo(db database.DB) *MetricRepo {
+	return &MetricRepo{
+		db: db,
+	}
+}
+
+// Find the stars and forks of a repository per interval from the snapshots recorded within [from, to).
+func (mr *MetricRepo) FindRepositoryMetrics(ctx context.Context, repositoryId int, from, to time.Time, interval string) ([]RepositoryMetric, error) {
+	query := "SELECT `stars`, `forks`, `recorded_at` FROM `repository_metrics` WHERE `repository_id` = ? AND `recorded_at` >= ? AND `recorded_at` < ? ORDER BY `recorded_at` ASC, `id` ASC"
+
+	rows, err := mr.db.QueryContext(ctx, query, repositoryId, from.Format(time.DateTime), to.Format(time.DateTime))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query repository metrics, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	defer rows.Close()
+
+	snapshots := make([]RepositoryMetric, 0)
+
+	for rows.Next() {
+		var snapshot RepositoryMetric
+
+		if err := rows.Scan(&snapshot.Stars, &snapshot.Forks, &snapshot.RecordedAt); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var previous RepositoryMetric
+
+	query = "SELECT `stars`, `forks`, `recorded_at` FROM `repository_metrics` WHERE `repository_id` = ? AND `recorded_at` < ? ORDER BY `recorded_at` DESC, `id` DESC LIMIT 1"
+	err = mr.db.QueryRowContext(ctx, query, repositoryId, from.Format(time.DateTime)).Scan(&previous.Stars, &previous.Forks, &previous.RecordedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return RepositoryMetrics(snapshots, nil, interval), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous repository metric, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	return RepositoryMetrics(snapshots, &previous, interval), nil
+}
+
+// Find the followers of a developer per interval from the snapshots recorded within [from, to).
+func (mr *MetricRepo) FindDeveloperMetrics(ctx context.Context, developerId int, from, to time.Time, interval string) ([]DeveloperMetric, error) {
+	query := "SELECT `followers`, `recorded_at` FROM `developer_metrics` WHERE `developer_id` = ? AND `recorded_at` >= ? AND `recorded_at` < ? ORDER BY `recorded_at` ASC, `id` ASC"
+
+	rows, err := mr.db.QueryContext(ctx, query, developerId, from.Format(time.DateTime), to.Format(time.DateTime))
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query developer metrics, developer id: %d, error: %v", developerId, err)
+	}
+
+	defer rows.Close()
+
+	snapshots := make([]DeveloperMetric, 0)
+
+	for rows.Next() {
+		var snapshot DeveloperMetric
+
+		if err := rows.Scan(&snapshot.Followers, &snapshot.RecordedAt); err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var previous DeveloperMetric
+
+	query = "SELECT `followers`, `recorded_at` FROM `developer_metrics` WHERE `developer_id` = ? AND `recorded_at` < ? ORDER BY `recorded_at` DESC, `id` DESC LIMIT 1"
+	err = mr.db.QueryRowContext(ctx, query, developerId, from.Format(time.DateTime)).Scan(&previous.Followers, &previous.RecordedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return DeveloperMetrics(snapshots, nil, interval), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous developer metric, developer id: %d, error: %v", developerId, err)
+	}
+
+	return DeveloperMetrics(snapshots, &previous, interval), nil
+}
+
+// Save the daily star history of a repository backfilled from its stargazers, the forks of the history are unknown.
+func (mr *MetricRepo) SaveStarHistory(ctx context.Context, repositoryId int, history []RepositoryMetric) error {
+	tx, err := mr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin save star history transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT INTO `repository_metrics` (`repository_id`, `stars`, `forks`, `recorded_at`) VALUES (?, ?, NULL, ?)"
+
+	for _, metric := range history {
+		if _, err := tx.ExecContext(ctx, query, repositoryId, metric.Stars, metric.RecordedAt.Format(time.DateTime)); err != nil {
+			return fmt.Errorf("failed to insert star history, repository id: %d, error: %v", repositoryId, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit save star history transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Record a snapshot of the stars and forks of a repository, it is written whenever a sync or link saves the repository.
+func insertRepositoryMetric(ctx context.Context, db database.Execer, repositoryId int, stars int, forks int) error {
+	query := "INSERT INTO `repository_metrics` (`repository_id`, `stars`, `forks`, `recorded_at`) VALUES (?, ?, ?, ?)"
+
+	if _, err := db.ExecContext(ctx, query, repositoryId, stars, forks, time.Now().Format(time.DateTime)); err != nil {
+		return fmt.Errorf("failed to insert repository metric, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	return nil
+}
+
+// Record a snapshot of the followers of a developer, it is written whenever a sync or link saves the developer.
+func insertDeveloperMetric(ctx context.Context, db database.Execer, developerId int, followers int) error {
+	query := "INSERT INTO `developer_metrics` (`developer_id`, `followers`, `recorded_at`) VALUES (?, ?, ?)"
+
+	if _, err := db.ExecContext(ctx, query, developerId, followers, time.Now().Format(time.DateTime)); err != nil {
+		return fmt.Errorf("failed to insert developer metric, developer id: %d, error: %v", developerId, err)
+	}
+
+	return nil
+}
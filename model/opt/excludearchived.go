@@ -0,0 +1,17 @@
+package opt
+
+type ExcludeArchivedOption struct {
+	value bool
+}
+
+func ExcludeArchived(value bool) *ExcludeArchivedOption {
+	return &ExcludeArchivedOption{value}
+}
+
+func (e *ExcludeArchivedOption) Get() bool {
+	if e == nil {
+		return false
+	}
+
+	return e.value
+}
@@ -0,0 +1,17 @@
+package opt
+
+type ExcludeForksOption struct {
+	value bool
+}
+
+func ExcludeForks(value bool) *ExcludeForksOption {
+	return &ExcludeForksOption{value}
+}
+
+func (e *ExcludeForksOption) Get() bool {
+	if e == nil {
+		return false
+	}
+
+	return e.value
+}
@@ -0,0 +1,28 @@
+package opt
+
+import "strings"
+
+// The SPDX ids of the licenses to filter by, e.g. MIT and Apache-2.0.
+type LicensesOption struct {
+	values []string
+}
+
+func Licenses(values ...string) *LicensesOption {
+	return &LicensesOption{values}
+}
+
+func (l *LicensesOption) Get() []string {
+	if l == nil {
+		return nil
+	}
+
+	licenses := make([]string, 0, len(l.values))
+
+	for _, value := range l.values {
+		if value = strings.TrimSpace(value); value != "" {
+			licenses = append(licenses, value)
+		}
+	}
+
+	return licenses
+}
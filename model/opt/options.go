@@ -1,11 +1,16 @@
 package opt
 
 type Options struct {
-	Language  string
-	DateRange int
-	Limit     int
-	Start     string
-	End       string
+	Language        string
+	DateRange       int
+	Limit           int
+	Start           string
+	End             string
+	Period          string
+	SpokenLanguage  string
+	ExcludeForks    bool
+	ExcludeArchived bool
+	Licenses        []string
 }
 
 func ExtractOptions(opts ...any) Options {
@@ -31,6 +36,26 @@ func ExtractOptions(opts ...any) Options {
 		if v, ok := option.(*EndOption); ok {
 			options.End = v.Get()
 		}
+
+		if v, ok := option.(*PeriodOption); ok {
+			options.Period = v.Get()
+		}
+
+		if v, ok := option.(*SpokenLanguageOption); ok {
+			options.SpokenLanguage = v.Get()
+		}
+
+		if v, ok := option.(*ExcludeForksOption); ok {
+			options.ExcludeForks = v.Get()
+		}
+
+		if v, ok := option.(*ExcludeArchivedOption); ok {
+			options.ExcludeArchived = v.Get()
+		}
+
+		if v, ok := option.(*LicensesOption); ok {
+			options.Licenses = v.Get()
+		}
 	}
 
 	return options
@@ -31,6 +31,14 @@ func TestExtractOptionsWithEmptyArgs(t *testing.T) {
 			actual: options.Limit,
 			want:   0,
 		},
+		{
+			actual: options.Period,
+			want:   "",
+		},
+		{
+			actual: options.SpokenLanguage,
+			want:   "",
+		},
 	}
 
 	for _, test := range expcts {
@@ -47,6 +55,11 @@ func TestExtractOptionsWithArgs(t *testing.T) {
 		Start("2023-10-04 00:00:00"),
 		End("2023-10-04 23:59:59"),
 		Limit(24),
+		Period(" Weekly "),
+		SpokenLanguage("ZH"),
+		ExcludeForks(true),
+		ExcludeArchived(true),
+		Licenses("MIT", " ", " Apache-2.0 "),
 	)
 
 	expcts := []struct {
@@ -73,6 +86,30 @@ func TestExtractOptionsWithArgs(t *testing.T) {
 			actual: options.Limit,
 			want:   24,
 		},
+		{
+			actual: options.Period,
+			want:   "weekly",
+		},
+		{
+			actual: options.SpokenLanguage,
+			want:   "zh",
+		},
+		{
+			actual: options.ExcludeForks,
+			want:   true,
+		},
+		{
+			actual: options.ExcludeArchived,
+			want:   true,
+		},
+		{
+			actual: len(options.Licenses),
+			want:   2,
+		},
+		{
+			actual: options.Licenses[1],
+			want:   "Apache-2.0",
+		},
 	}
 
 	for _, test := range expcts {
@@ -0,0 +1,19 @@
+package opt
+
+import "strings"
+
+type PeriodOption struct {
+	value string
+}
+
+func Period(value string) *PeriodOption {
+	return &PeriodOption{value}
+}
+
+func (p *PeriodOption) Get() string {
+	if p == nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(p.value))
+}
@@ -0,0 +1,19 @@
+package opt
+
+import "strings"
+
+type SpokenLanguageOption struct {
+	value string
+}
+
+func SpokenLanguage(value string) *SpokenLanguageOption {
+	return &SpokenLanguageOption{value}
+}
+
+func (s *SpokenLanguageOption) Get() string {
+	if s == nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(s.value))
+}
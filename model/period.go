@@ -0,0 +1,26 @@
+package model
+
+// Periods supported by the GitHub trending page, see the `since` query parameter.
+const (
+	DailyPeriod   = "daily"
+	WeeklyPeriod  = "weekly"
+	MonthlyPeriod = "monthly"
+)
+
+func IsValidPeriod(period string) bool {
+	switch period {
+	case DailyPeriod, WeeklyPeriod, MonthlyPeriod:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns the period to query, trending snapshots default to the daily period.
+func PeriodOrDefault(period string) string {
+	if period == "" {
+		return DailyPeriod
+	}
+
+	return period
+}
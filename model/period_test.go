@@ -0,0 +1,45 @@
+package model
+
+import "testing"
+
+func TestIsValidPeriod(t *testing.T) {
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{
+			actual: IsValidPeriod("daily"),
+			want:   true,
+		},
+		{
+			actual: IsValidPeriod("weekly"),
+			want:   true,
+		},
+		{
+			actual: IsValidPeriod("monthly"),
+			want:   true,
+		},
+		{
+			actual: IsValidPeriod("yearly"),
+			want:   false,
+		},
+		{
+			actual: IsValidPeriod(""),
+			want:   false,
+		},
+		{
+			actual: PeriodOrDefault(""),
+			want:   "daily",
+		},
+		{
+			actual: PeriodOrDefault("monthly"),
+			want:   "monthly",
+		},
+	}
+
+	for _, test := range expcts {
+		if test.actual != test.want {
+			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
+		}
+	}
+}
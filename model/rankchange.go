@@ -0,0 +1,97 @@
+package model
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// How a repository or developer changed between two snapshots of the same trending page.
+const (
+	RankEntered = "entered"
+	RankLeft    = "left"
+	RankMoved   = "moved"
+)
+
+// The trending page a rank change belongs to.
+const (
+	RankChangeRepository = "repository"
+	RankChangeDeveloper  = "developer"
+)
+
+type RankChange struct {
+	Id             int                `json:"id"`
+	Type           string             `json:"type"`
+	Name           string             `json:"name"` // full name of a repository or username of a developer.
+	Language       dbutils.NullString `json:"language"`
+	SpokenLanguage dbutils.NullString `json:"spoken_language"`
+	Period         string             `json:"period"`
+	Change         string             `json:"change"`
+	PreviousRank   dbutils.NullInt64  `json:"previous_rank"`
+	Rank           dbutils.NullInt64  `json:"rank"`
+	TrendDate      time.Time          `json:"trend_date"`
+	CreatedAt      time.Time          `json:"created_at"`
+}
+
+func nullRank(rank int, ok bool) dbutils.NullInt64 {
+	return dbutils.NullInt64{
+		NullInt64: sql.NullInt64{Int64: int64(rank), Valid: ok},
+	}
+}
+
+// Compare the ranks keyed by name of the previous and the current snapshot.
+// Entered and moved changes are ordered by the current rank, followed by left changes ordered by the previous rank.
+func DiffRanks(previous, current map[string]int) []RankChange {
+	changes := make([]RankChange, 0)
+
+	for name, rank := range current {
+		previousRank, ok := previous[name]
+
+		switch {
+		case !ok:
+			changes = append(changes, RankChange{Name: name, Change: RankEntered, Rank: nullRank(rank, true)})
+		case previousRank != rank:
+			changes = append(changes, RankChange{Name: name, Change: RankMoved, PreviousRank: nullRank(previousRank, true), Rank: nullRank(rank, true)})
+		}
+	}
+
+	for name, previousRank := range previous {
+		if _, ok := current[name]; !ok {
+			changes = append(changes, RankChange{Name: name, Change: RankLeft, PreviousRank: nullRank(previousRank, true)})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+
+		if a.Rank.Valid != b.Rank.Valid {
+			return a.Rank.Valid
+		}
+
+		if a.Rank.Valid {
+			return a.Rank.Int64 < b.Rank.Int64
+		}
+
+		return a.PreviousRank.Int64 < b.PreviousRank.Int64
+	})
+
+	return changes
+}
+
+// Count the entered, left and moved changes for logging.
+func CountRankChanges(changes []RankChange) (entered, left, moved int) {
+	for _, change := range changes {
+		switch change.Change {
+		case RankEntered:
+			entered++
+		case RankLeft:
+			left++
+		case RankMoved:
+			moved++
+		}
+	}
+
+	return entered, left, moved
+}
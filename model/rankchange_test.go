@@ -0,0 +1,69 @@
+package model
+
+import "testing"
+
+func TestDiffRanks(t *testing.T) {
+	previous := map[string]int{
+		"ollama/ollama":     1,
+		"liweiyi88/onedump": 2,
+		"golang/go":         3,
+		"rust-lang/rust":    4,
+	}
+
+	current := map[string]int{
+		"liweiyi88/onedump":  1,
+		"ollama/ollama":      2,
+		"golang/go":          3,
+		"zed-industries/zed": 4,
+	}
+
+	changes := DiffRanks(previous, current)
+
+	expcts := []struct {
+		name, change       string
+		previousRank, rank int64
+	}{
+		{name: "liweiyi88/onedump", change: RankMoved, previousRank: 2, rank: 1},
+		{name: "ollama/ollama", change: RankMoved, previousRank: 1, rank: 2},
+		{name: "zed-industries/zed", change: RankEntered, rank: 4},
+		{name: "rust-lang/rust", change: RankLeft, previousRank: 4},
+	}
+
+	if len(changes) != len(expcts) {
+		t.Fatalf("expect %d rank changes, actual got: %d", len(expcts), len(changes))
+	}
+
+	for i, want := range expcts {
+		change := changes[i]
+
+		if change.Name != want.name || change.Change != want.change {
+			t.Errorf("expect %s to be %s, actual got: %s %s", want.name, want.change, change.Name, change.Change)
+		}
+
+		if change.PreviousRank.Int64 != want.previousRank || change.PreviousRank.Valid != (want.previousRank > 0) {
+			t.Errorf("expect previous rank of %s to be %d, actual got: %v", want.name, want.previousRank, change.PreviousRank)
+		}
+
+		if change.Rank.Int64 != want.rank || change.Rank.Valid != (want.rank > 0) {
+			t.Errorf("expect rank of %s to be %d, actual got: %v", want.name, want.rank, change.Rank)
+		}
+	}
+
+	entered, left, moved := CountRankChanges(changes)
+
+	if entered != 1 || left != 1 || moved != 2 {
+		t.Errorf("expect 1 entered, 1 left and 2 moved, actual got: %d, %d and %d", entered, left, moved)
+	}
+}
+
+func TestDiffRanksWithoutPrevious(t *testing.T) {
+	changes := DiffRanks(map[string]int{}, map[string]int{"b": 2, "a": 1})
+
+	if len(changes) != 2 || changes[0].Name != "a" || changes[1].Name != "b" || changes[0].Change != RankEntered {
+		t.Errorf("expect every row to enter in rank order, actual got: %+v", changes)
+	}
+
+	if changes := DiffRanks(map[string]int{"a": 1}, map[string]int{"a": 1}); len(changes) != 0 {
+		t.Errorf("expect no changes for the same snapshot, actual got: %+v", changes)
+	}
+}
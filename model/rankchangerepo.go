@@ -0,0 +1,132 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+type RankChangeRepo struct {
+	db database.DB
+}
+
+func NewRankChangeRepo(db database.DB) *RankChangeRepo {
+	return &RankChangeRepo{
+		db: db,
+	}
+}
+
+// Save rank changes within the transaction that replaces the trending snapshot.
+func saveRankChanges(ctx context.Context, tx *sql.Tx, changes []RankChange) error {
+	query := "INSERT INTO `rank_changes` (`type`, `name`, `language`, `spoken_language`, `period`, `change`, `previous_rank`, `rank`, `trend_date`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	createdAt := time.Now()
+
+	for _, change := range changes {
+		result, err := tx.ExecContext(
+			ctx,
+			query,
+			change.Type,
+			change.Name,
+			change.Language,
+			change.SpokenLanguage,
+			PeriodOrDefault(change.Period),
+			change.Change,
+			change.PreviousRank,
+			change.Rank,
+			change.TrendDate.Format("2006-01-02"),
+			createdAt.Format(time.DateTime),
+		)
+
+		if err != nil {
+			return fmt.Errorf("failed to exec insert rank_changes query to db, name: %s, error: %v", change.Name, err)
+		}
+
+		_, err = result.RowsAffected()
+
+		if err != nil {
+			return fmt.Errorf("rank_changes insert rows affected returns error: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Find the rank changes of a repository or developer page, the latest changes come first.
+// An empty name returns the changes of all repositories or developers.
+func (rr *RankChangeRepo) Find(ctx context.Context, changeType, name string, opts ...any) ([]RankChange, error) {
+	options := opt.ExtractOptions(opts...)
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT * FROM `rank_changes`")
+	qb.Where("`type` = ?", changeType)
+
+	if name != "" {
+		qb.Where("`name` = ?", name)
+	}
+
+	if options.Language != "" {
+		qb.Where("`language` = ?", options.Language)
+	}
+
+	if options.Period != "" {
+		qb.Where("`period` = ?", options.Period)
+	}
+
+	if options.DateRange > 0 {
+		since := time.Now().AddDate(0, 0, -options.DateRange)
+		qb.Where("`trend_date` > ?", since.Format("2006-01-02"))
+	}
+
+	qb.OrderBy("`trend_date`", "DESC")
+	qb.OrderBy("`id`", "DESC")
+
+	if options.Limit > 0 {
+		qb.Limit(options.Limit)
+	}
+
+	query, args := qb.GetQuery()
+
+	rows, err := rr.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rank changes: %v", err)
+	}
+
+	defer rows.Close()
+
+	changes := make([]RankChange, 0)
+
+	for rows.Next() {
+		var change RankChange
+
+		if err := rows.Scan(
+			&change.Id,
+			&change.Type,
+			&change.Name,
+			&change.Language,
+			&change.SpokenLanguage,
+			&change.Period,
+			&change.Change,
+			&change.PreviousRank,
+			&change.Rank,
+			&change.TrendDate,
+			&change.CreatedAt,
+		); err != nil {
+			return changes, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	if err = rows.Err(); err != nil {
+		return changes, err
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Sources of a release, a tag without a GitHub release is tracked as a release published at its commit date.
+const (
+	ReleaseSourceRelease = "release"
+	ReleaseSourceTag     = "tag"
+)
+
+type Release struct {
+	Id           int                `json:"id"`
+	RepositoryId int                `json:"repository_id"`
+	Version      string             `json:"version"` // the tag name, e.g. v1.2.0.
+	Name         dbutils.NullString `json:"name"`
+	Prerelease   bool               `json:"prerelease"`
+	Source       string             `json:"source"`
+	PublishedAt  time.Time          `json:"published_at"`
+	CreatedAt    time.Time          `json:"created_at"`
+}
+
+// The trend date is scanned as a RFC3339 time when the dsn has parseTime, only its date is compared.
+func parseTrendDate(value string) (time.Time, error) {
+	if len(value) > len(time.DateOnly) {
+		value = value[:len(time.DateOnly)]
+	}
+
+	return time.Parse(time.DateOnly, value)
+}
+
+// Mark the trending days that fall within the given days after a release, so a trending caused by a release can be told apart.
+func MarkReleases(trendings []RepositoryTrending, releases []Release, days int) {
+	for i := range trendings {
+		trendDate, err := parseTrendDate(trendings[i].TrendDate)
+
+		if err != nil {
+			continue
+		}
+
+		var latest *Release
+
+		for j, release := range releases {
+			published, _ := time.Parse(time.DateOnly, release.PublishedAt.Format(time.DateOnly))
+
+			if published.After(trendDate) || published.AddDate(0, 0, days).Before(trendDate) {
+				continue
+			}
+
+			if latest == nil || release.PublishedAt.After(latest.PublishedAt) {
+				latest = &releases[j]
+			}
+		}
+
+		if latest != nil {
+			trendings[i].NearRelease = true
+			trendings[i].ReleaseVersion = latest.Version
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarkReleases(t *testing.T) {
+	at := func(day, hour int) time.Time {
+		return time.Date(2024, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	trendings := []RepositoryTrending{
+		{Trending: Trending{TrendDate: "2024-05-09"}},
+		{Trending: Trending{TrendDate: "2024-05-10T00:00:00Z"}}, // scanned with parseTime.
+		{Trending: Trending{TrendDate: "2024-05-13"}},
+		{Trending: Trending{TrendDate: "2024-05-14"}},
+		{Trending: Trending{TrendDate: "2024-05-20"}},
+	}
+
+	releases := []Release{
+		{Version: "v1.1.0", PublishedAt: at(12, 23)},
+		{Version: "v1.0.0", PublishedAt: at(10, 18)},
+		{Version: "v1.1.0-rc.1", PublishedAt: at(11, 8)},
+	}
+
+	MarkReleases(trendings, releases, 3)
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{trendings[0].NearRelease, false},
+		{trendings[0].ReleaseVersion, ""},
+		{trendings[1].NearRelease, true},
+		{trendings[1].ReleaseVersion, "v1.0.0"},
+		{trendings[2].NearRelease, true},
+		{trendings[2].ReleaseVersion, "v1.1.0"},
+		{trendings[3].ReleaseVersion, "v1.1.0"},
+		{trendings[4].NearRelease, false},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
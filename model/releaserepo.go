@@ -0,0 +1,129 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+type ReleaseRepo struct {
+	db database.DB
+}
+
+func NewReleaseRepo(db database.DB) *ReleaseRepo {
+	return &ReleaseRepo{
+		db: db,
+	}
+}
+
+// Find the releases of a repository, the latest release comes first.
+func (rr *ReleaseRepo) FindByRepositoryId(ctx context.Context, repositoryId int, opts ...any) ([]Release, error) {
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT `id`, `repository_id`, `version`, `name`, `prerelease`, `source`, `published_at`, `created_at` FROM `releases`")
+	qb.Where("`repository_id` = ?", repositoryId)
+	qb.OrderBy("`published_at`", "DESC")
+
+	if limit := opt.ExtractOptions(opts...).Limit; limit > 0 {
+		qb.Limit(limit)
+	}
+
+	query, args := qb.GetQuery()
+
+	rows, err := rr.db.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query releases, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	defer rows.Close()
+
+	releases := make([]Release, 0)
+
+	for rows.Next() {
+		var release Release
+
+		if err := rows.Scan(
+			&release.Id,
+			&release.RepositoryId,
+			&release.Version,
+			&release.Name,
+			&release.Prerelease,
+			&release.Source,
+			&release.PublishedAt,
+			&release.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		releases = append(releases, release)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// Find the versions of the saved releases of a repository.
+func (rr *ReleaseRepo) FindVersions(ctx context.Context, repositoryId int) (map[string]bool, error) {
+	query := "SELECT `version` FROM `releases` WHERE `repository_id` = ?"
+
+	rows, err := rr.db.QueryContext(ctx, query, repositoryId)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release versions, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	defer rows.Close()
+
+	versions := make(map[string]bool)
+
+	for rows.Next() {
+		var version string
+
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+
+		versions[version] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// Save the releases of a repository, a saved version is updated, e.g. when a GitHub release is published for a tracked tag.
+func (rr *ReleaseRepo) Save(ctx context.Context, releases []Release) error {
+	tx, err := rr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin save releases transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "INSERT INTO `releases` (`repository_id`, `version`, `name`, `prerelease`, `source`, `published_at`, `created_at`) VALUES (?, ?, ?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `prerelease` = VALUES(`prerelease`), `source` = VALUES(`source`), `published_at` = VALUES(`published_at`)"
+
+	createdAt := time.Now().Format(time.DateTime)
+
+	for _, release := range releases {
+		if _, err := tx.ExecContext(ctx, query, release.RepositoryId, release.Version, release.Name, release.Prerelease, release.Source, release.PublishedAt.Format(time.DateTime), createdAt); err != nil {
+			return fmt.Errorf("failed to save release, repository id: %d, version: %s, error: %v", release.RepositoryId, release.Version, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit save releases transaction: %v", err)
+	}
+
+	return nil
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 	"unicode/utf8"
 
@@ -14,27 +17,116 @@ type Owner struct {
 	AvatarUrl string `json:"avatar_url"`
 }
 
+// The license of a repository, GitHub sends NOASSERTION as the spdx id of a license it does not recognise.
+type License struct {
+	SpdxId dbutils.NullString `json:"spdx_id"`
+}
+
+// The repository that a fork is forked from.
+type Parent struct {
+	FullName dbutils.NullString `json:"full_name"`
+}
+
 type Trending struct {
 	TrendingLanguage dbutils.NullString `json:"trending_language"`
 	TrendDate        string             `json:"trend_date"`
 	Rank             int                `json:"rank"`
+	Period           string             `json:"period"`
+	SpokenLanguage   dbutils.NullString `json:"trending_spoken_language"`
+}
+
+// Trending of a repository along with the metrics shown on the trending page.
+type RepositoryTrending struct {
+	Trending
+	StarsToday         int                `json:"stars_today"`
+	Stars              int                `json:"stars"`
+	Forks              int                `json:"forks"`
+	RepositoryLanguage dbutils.NullString `json:"repository_language"` // primary language shown on the trending row.
+	BuiltBy            Contributors       `json:"built_by"`
+	NearRelease        bool               `json:"near_release"`              // non db column field, see MarkReleases.
+	ReleaseVersion     string             `json:"release_version,omitempty"` // non db column field, the latest release near the trending day.
+}
+
+// GitHub topics of a repository, they are saved as a json column.
+type Topics []string
+
+func (t Topics) Value() (driver.Value, error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	value, err := json.Marshal(t)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode topics: %v", err)
+	}
+
+	return string(value), nil
+}
+
+func (t *Topics) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported topics type: %T", src)
+	}
+
+	return json.Unmarshal(data, t)
 }
 
 type GhRepository struct {
-	Id            int                `json:"repository_id"` // primary key saved in DB.
-	GhrId         int                `json:"id"`            // id from github repository api response.
-	FullName      string             `json:"full_name"`
-	Owner         Owner              `json:"owner"`
-	Forks         int                `json:"forks"`
-	Stars         int                `json:"watchers"`
-	Language      string             `json:"language"`
-	Description   dbutils.NullString `json:"description"`
-	DefaultBranch dbutils.NullString `json:"default_branch"`
-	Homepage      dbutils.NullString `json:"homepage"`
-	Tags          []Tag              `json:"tags"`
-	Trendings     []Trending         `json:"trendings"`
-	CreatedAt     time.Time          `json:"created_at"`
-	UpdatedAt     time.Time          `json:"updated_at"`
+	Id            int                  `json:"repository_id"` // primary key saved in DB.
+	GhrId         int                  `json:"id"`            // id from github repository api response.
+	FullName      string               `json:"full_name"`
+	Owner         Owner                `json:"owner"`
+	Forks         int                  `json:"forks"`
+	Stars         int                  `json:"watchers"`
+	Language      string               `json:"language"`
+	Description   dbutils.NullString   `json:"description"`
+	DefaultBranch dbutils.NullString   `json:"default_branch"`
+	Homepage      dbutils.NullString   `json:"homepage"`
+	Topics        Topics               `json:"topics"`
+	License       License              `json:"license"`
+	Archived      bool                 `json:"archived"`
+	Fork          bool                 `json:"fork"`
+	Parent        Parent               `json:"parent"`
+	OpenIssues    int                  `json:"open_issues_count"` // open issues and pull requests.
+	Subscribers   int                  `json:"subscribers_count"` // the watchers on GitHub, the rest api calls the stars watchers.
+	Size          int                  `json:"size"`              // in KB.
+	PushedAt      dbutils.NullTime     `json:"pushed_at"`
+	GhCreatedAt   dbutils.NullTime     `json:"github_created_at"` // when the repository was created on GitHub, see UnmarshalJSON.
+	Readme        *RepositoryReadme    `json:"readme,omitempty"`  // only loaded for a single repository.
+	Tags          []Tag                `json:"tags"`
+	Trendings     []RepositoryTrending `json:"trendings"`
+	CreatedAt     time.Time            `json:"created_at"`
+	UpdatedAt     time.Time            `json:"updated_at"`
+}
+
+// Decode a repository from the GitHub rest api, its created_at is when the repository was created on GitHub
+// rather than when it was saved, so it is decoded into GhCreatedAt.
+func (gr *GhRepository) UnmarshalJSON(data []byte) error {
+	type ghRepository GhRepository
+
+	repository := struct {
+		*ghRepository
+		GhCreatedAt dbutils.NullTime `json:"created_at"`
+	}{ghRepository: (*ghRepository)(gr)}
+
+	if err := json.Unmarshal(data, &repository); err != nil {
+		return err
+	}
+
+	gr.GhCreatedAt = repository.GhCreatedAt
+
+	return nil
 }
 
 func (gr GhRepository) GetDescription() string {
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// The plain text excerpt and the first image of a repository README, they are extracted again when the blob sha of the README changes.
+type RepositoryReadme struct {
+	RepositoryId int                `json:"-"`
+	Sha          string             `json:"sha"`
+	Excerpt      string             `json:"excerpt"`
+	ImageUrl     dbutils.NullString `json:"image_url"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+type RepositoryReadmeRepo struct {
+	db database.DB
+}
+
+func NewRepositoryReadmeRepo(db database.DB) *RepositoryReadmeRepo {
+	return &RepositoryReadmeRepo{
+		db: db,
+	}
+}
+
+// Find the README of a repository, it returns sql.ErrNoRows when the README has not been fetched or the repository has none.
+func (rr *RepositoryReadmeRepo) Find(ctx context.Context, repositoryId int) (RepositoryReadme, error) {
+	query := "SELECT `repository_id`, `sha`, `excerpt`, `image_url`, `updated_at` FROM `repository_readmes` WHERE `repository_id` = ?"
+
+	var readme RepositoryReadme
+
+	err := rr.db.QueryRowContext(ctx, query, repositoryId).Scan(&readme.RepositoryId, &readme.Sha, &readme.Excerpt, &readme.ImageUrl, &readme.UpdatedAt)
+
+	return readme, err
+}
+
+func (rr *RepositoryReadmeRepo) Save(ctx context.Context, readme RepositoryReadme) error {
+	query := "INSERT INTO `repository_readmes` (`repository_id`, `sha`, `excerpt`, `image_url`, `updated_at`) VALUES (?, ?, ?, ?, ?) " +
+		"ON DUPLICATE KEY UPDATE `sha` = VALUES(`sha`), `excerpt` = VALUES(`excerpt`), `image_url` = VALUES(`image_url`), `updated_at` = VALUES(`updated_at`)"
+
+	_, err := rr.db.ExecContext(ctx, query, readme.RepositoryId, readme.Sha, readme.Excerpt, readme.ImageUrl, time.Now().Format(time.DateTime))
+
+	if err != nil {
+		return fmt.Errorf("failed to save repository readme, repository id: %d, error: %v", readme.RepositoryId, err)
+	}
+
+	return nil
+}
+
+// Delete the README of a repository, e.g. after it was removed from the repository.
+func (rr *RepositoryReadmeRepo) Delete(ctx context.Context, repositoryId int) error {
+	query := "DELETE FROM `repository_readmes` WHERE `repository_id` = ?"
+
+	if _, err := rr.db.ExecContext(ctx, query, repositoryId); err != nil {
+		return fmt.Errorf("failed to delete repository readme, repository id: %d, error: %v", repositoryId, err)
+	}
+
+	return nil
+}
@@ -15,6 +15,7 @@ type TrendingRepositoryResponse struct {
 	GhRepository
 	BestRanking   int `json:"best_ranking"`   // non db column field
 	FeaturedCount int `json:"featured_count"` // non db column field
+	StarsToday    int `json:"stars_today"`    // non db column field, the most stars gained in a single trending period within the date range.
 }
 
 type GhRepositoryRepo struct {
@@ -31,7 +32,7 @@ func (gr *GhRepositoryRepo) FindById(ctx context.Context, id int) (GhRepository,
 
 	qb := dbutils.NewQueryBuilder()
 
-	qb.Query("select repositories.*, trending_repositories.`trend_date`, trending_repositories.`rank`, trending_repositories.`language` as `trending_language` from repositories join trending_repositories on repositories.id = trending_repositories.repository_id")
+	qb.Query("select repositories.*, trending_repositories.`trend_date`, trending_repositories.`rank`, trending_repositories.`language` as `trending_language`, trending_repositories.`period`, trending_repositories.`spoken_language` as `trending_spoken_language`, trending_repositories.`stars_today`, trending_repositories.`stars` as `trending_stars`, trending_repositories.`forks` as `trending_forks`, trending_repositories.`repository_language`, trending_repositories.`built_by` from repositories join trending_repositories on repositories.id = trending_repositories.repository_id")
 	qb.Where("repositories.id = ?", id)
 	query, args := qb.GetQuery()
 
@@ -48,7 +49,7 @@ func (gr *GhRepositoryRepo) FindById(ctx context.Context, id int) (GhRepository,
 	collectionMap := dbutils.NewCollectionMap[int, *GhRepository]()
 
 	for rows.Next() {
-		var trending Trending
+		var trending RepositoryTrending
 
 		if err := rows.Scan(
 			&ghr.Id,
@@ -64,9 +65,26 @@ func (gr *GhRepositoryRepo) FindById(ctx context.Context, id int) (GhRepository,
 			&ghr.Description,
 			&ghr.DefaultBranch,
 			&ghr.Homepage,
+			&ghr.Topics,
+			&ghr.License.SpdxId,
+			&ghr.Archived,
+			&ghr.Fork,
+			&ghr.Parent.FullName,
+			&ghr.OpenIssues,
+			&ghr.Subscribers,
+			&ghr.Size,
+			&ghr.PushedAt,
+			&ghr.GhCreatedAt,
 			&trending.TrendDate,
 			&trending.Rank,
 			&trending.TrendingLanguage,
+			&trending.Period,
+			&trending.SpokenLanguage,
+			&trending.StarsToday,
+			&trending.Stars,
+			&trending.Forks,
+			&trending.RepositoryLanguage,
+			&trending.BuiltBy,
 		); err != nil {
 			return ghr, err
 		}
@@ -108,6 +126,16 @@ func (gr *GhRepositoryRepo) FindByName(ctx context.Context, name string) (GhRepo
 		&ghr.Description,
 		&ghr.DefaultBranch,
 		&ghr.Homepage,
+		&ghr.Topics,
+		&ghr.License.SpdxId,
+		&ghr.Archived,
+		&ghr.Fork,
+		&ghr.Parent.FullName,
+		&ghr.OpenIssues,
+		&ghr.Subscribers,
+		&ghr.Size,
+		&ghr.PushedAt,
+		&ghr.GhCreatedAt,
 	); err != nil {
 		return ghr, err
 	}
@@ -164,6 +192,16 @@ func (gr *GhRepositoryRepo) FindAll(ctx context.Context, opts ...any) ([]GhRepos
 			&ghr.Description,
 			&ghr.DefaultBranch,
 			&ghr.Homepage,
+			&ghr.Topics,
+			&ghr.License.SpdxId,
+			&ghr.Archived,
+			&ghr.Fork,
+			&ghr.Parent.FullName,
+			&ghr.OpenIssues,
+			&ghr.Subscribers,
+			&ghr.Size,
+			&ghr.PushedAt,
+			&ghr.GhCreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -178,17 +216,40 @@ func (gr *GhRepositoryRepo) FindAll(ctx context.Context, opts ...any) ([]GhRepos
 	return repositories, nil
 }
 
-func (gr *GhRepositoryRepo) FindAllWithTags(ctx context.Context, filter string) ([]*GhRepository, error) {
-	var query string
-	var args []any
+// Filter the repositories by their GitHub metadata, e.g. leave out forks and archived repositories or only keep some licenses.
+func filterRepositories(qb *dbutils.QueryBuilder, options opt.Options) {
+	if options.ExcludeForks {
+		qb.Where("`repositories`.`fork` = ?", false)
+	}
+
+	if options.ExcludeArchived {
+		qb.Where("`repositories`.`archived` = ?", false)
+	}
+
+	licenses := make([]any, 0, len(options.Licenses))
+
+	for _, license := range options.Licenses {
+		licenses = append(licenses, license)
+	}
+
+	qb.WhereIn("`repositories`.`license`", licenses...)
+}
+
+func (gr *GhRepositoryRepo) FindAllWithTags(ctx context.Context, filter string, opts ...any) ([]*GhRepository, error) {
+	qb := dbutils.NewQueryBuilder()
 
 	if filter == "today" {
-		query = "select repositories.*, tags.id as tag_id, tags.`name` as tag_name  from repositories left join repositories_tags ON repositories.id = repositories_tags.repository_id left join tags on repositories_tags.tag_id = tags.id join trending_repositories on repositories.id = trending_repositories.repository_id where trend_date = ? group by repositories.full_name, tags.id"
-		args = append(args, time.Now().Format("2006-01-02"))
+		qb.Query("select repositories.*, tags.id as tag_id, tags.`name` as tag_name  from repositories left join repositories_tags ON repositories.id = repositories_tags.repository_id left join tags on repositories_tags.tag_id = tags.id join trending_repositories on repositories.id = trending_repositories.repository_id")
+		qb.Where("trend_date = ?", time.Now().Format("2006-01-02"))
+		qb.GroupBy("repositories.full_name, tags.id")
 	} else {
-		query = "select repositories.*, tags.id as tag_id, tags.`name` as tag_name from repositories left join repositories_tags ON repositories.id = repositories_tags.repository_id left join tags on repositories_tags.tag_id = tags.id"
+		qb.Query("select repositories.*, tags.id as tag_id, tags.`name` as tag_name from repositories left join repositories_tags ON repositories.id = repositories_tags.repository_id left join tags on repositories_tags.tag_id = tags.id")
 	}
 
+	filterRepositories(qb, opt.ExtractOptions(opts...))
+
+	query, args := qb.GetQuery()
+
 	rows, err := gr.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
@@ -217,6 +278,16 @@ func (gr *GhRepositoryRepo) FindAllWithTags(ctx context.Context, filter string)
 			&ghr.Description,
 			&ghr.DefaultBranch,
 			&ghr.Homepage,
+			&ghr.Topics,
+			&ghr.License.SpdxId,
+			&ghr.Archived,
+			&ghr.Fork,
+			&ghr.Parent.FullName,
+			&ghr.OpenIssues,
+			&ghr.Subscribers,
+			&ghr.Size,
+			&ghr.PushedAt,
+			&ghr.GhCreatedAt,
 			&tagId,
 			&tagName,
 		); err != nil {
@@ -244,7 +315,7 @@ func (gr *GhRepositoryRepo) FindAllWithTags(ctx context.Context, filter string)
 }
 
 func (gr *GhRepositoryRepo) FindTrendingRepositories(ctx context.Context, opts ...any) ([]TrendingRepositoryResponse, error) {
-	query := "select repositories.*, count(*) as count, min(trending_repositories.`rank`) as best_ranking from repositories join trending_repositories on repositories.id = trending_repositories.repository_id"
+	query := "select repositories.*, count(*) as count, min(trending_repositories.`rank`) as best_ranking, max(trending_repositories.`stars_today`) as stars_today from repositories join trending_repositories on repositories.id = trending_repositories.repository_id"
 
 	qb := dbutils.NewQueryBuilder()
 	qb.Query(query)
@@ -254,21 +325,12 @@ func (gr *GhRepositoryRepo) FindTrendingRepositories(ctx context.Context, opts .
 	qb.OrderBy("repositories.id", "ASC")
 
 	options := opt.ExtractOptions(opts...)
-	lang, dateRange, limit := options.Language, options.DateRange, options.Limit
-
-	if lang != "" {
-		qb.Where("`trending_repositories`.`language` = ?", lang)
-	} else {
-		qb.Where("`trending_repositories`.`language` is null", nil)
-	}
 
-	if dateRange > 0 {
-		since := time.Now().AddDate(0, 0, -dateRange)
-		qb.Where("`trending_repositories`.`trend_date` > ?", since.Format("2006-01-02"))
-	}
+	filterTrendings(qb, options)
+	filterRepositories(qb, options)
 
-	if limit > 0 {
-		qb.Limit(limit)
+	if options.Limit > 0 {
+		qb.Limit(options.Limit)
 	}
 
 	qb.GroupBy("repositories.id")
@@ -302,8 +364,19 @@ func (gr *GhRepositoryRepo) FindTrendingRepositories(ctx context.Context, opts .
 			&trr.Description,
 			&trr.DefaultBranch,
 			&trr.Homepage,
+			&trr.Topics,
+			&trr.License.SpdxId,
+			&trr.Archived,
+			&trr.Fork,
+			&trr.Parent.FullName,
+			&trr.OpenIssues,
+			&trr.Subscribers,
+			&trr.Size,
+			&trr.PushedAt,
+			&trr.GhCreatedAt,
 			&trr.FeaturedCount,
 			&trr.BestRanking,
+			&trr.StarsToday,
 		); err != nil {
 			return nil, err
 
@@ -315,9 +388,94 @@ func (gr *GhRepositoryRepo) FindTrendingRepositories(ctx context.Context, opts .
 		return nil, err
 	}
 
+	if err := gr.attachTrendings(ctx, repositories, options); err != nil {
+		return nil, err
+	}
+
 	return repositories, nil
 }
 
+// Filter the trending rows by the trending page they were scraped from and the date range.
+func filterTrendings(qb *dbutils.QueryBuilder, options opt.Options) {
+	if options.Language != "" {
+		qb.Where("`trending_repositories`.`language` = ?", options.Language)
+	} else {
+		qb.Where("`trending_repositories`.`language` is null", nil)
+	}
+
+	qb.Where("`trending_repositories`.`period` = ?", PeriodOrDefault(options.Period))
+
+	if options.SpokenLanguage != "" {
+		qb.Where("`trending_repositories`.`spoken_language` = ?", options.SpokenLanguage)
+	} else {
+		qb.Where("`trending_repositories`.`spoken_language` is null", nil)
+	}
+
+	if options.DateRange > 0 {
+		since := time.Now().AddDate(0, 0, -options.DateRange)
+		qb.Where("`trending_repositories`.`trend_date` > ?", since.Format("2006-01-02"))
+	}
+}
+
+// Attach the trending rows that the trending repositories are ranked by, the latest row comes first,
+// so the response can explain a ranking with the metrics of each row, e.g. the stars gained on the day.
+func (gr *GhRepositoryRepo) attachTrendings(ctx context.Context, repositories []TrendingRepositoryResponse, options opt.Options) error {
+	if len(repositories) == 0 {
+		return nil
+	}
+
+	ids := make([]any, 0, len(repositories))
+	indexes := make(map[int]int, len(repositories))
+
+	for i, repository := range repositories {
+		ids = append(ids, repository.Id)
+		indexes[repository.Id] = i
+		repositories[i].Trendings = make([]RepositoryTrending, 0)
+	}
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("select trending_repositories.`repository_id`, trending_repositories.`trend_date`, trending_repositories.`rank`, trending_repositories.`language`, trending_repositories.`period`, trending_repositories.`spoken_language`, trending_repositories.`stars_today`, trending_repositories.`stars`, trending_repositories.`forks`, trending_repositories.`repository_language`, trending_repositories.`built_by` from trending_repositories")
+	qb.WhereIn("`trending_repositories`.`repository_id`", ids...)
+	filterTrendings(qb, options)
+	qb.OrderBy("trending_repositories.`trend_date`", "DESC")
+
+	q, args := qb.GetQuery()
+
+	rows, err := gr.db.QueryContext(ctx, q, args...)
+
+	if err != nil {
+		return fmt.Errorf("failed to query trendings of trending repositories: %v", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var repositoryId int
+		var trending RepositoryTrending
+
+		if err := rows.Scan(
+			&repositoryId,
+			&trending.TrendDate,
+			&trending.Rank,
+			&trending.TrendingLanguage,
+			&trending.Period,
+			&trending.SpokenLanguage,
+			&trending.StarsToday,
+			&trending.Stars,
+			&trending.Forks,
+			&trending.RepositoryLanguage,
+			&trending.BuiltBy,
+		); err != nil {
+			return err
+		}
+
+		i := indexes[repositoryId]
+		repositories[i].Trendings = append(repositories[i].Trendings, trending)
+	}
+
+	return rows.Err()
+}
+
 func (gr *GhRepositoryRepo) FindRepositoriesByNames(ctx context.Context, names []string) ([]GhRepository, error) {
 	ghRepos := make([]GhRepository, 0)
 
@@ -352,6 +510,16 @@ func (gr *GhRepositoryRepo) FindRepositoriesByNames(ctx context.Context, names [
 			&ghr.Description,
 			&ghr.DefaultBranch,
 			&ghr.Homepage,
+			&ghr.Topics,
+			&ghr.License.SpdxId,
+			&ghr.Archived,
+			&ghr.Fork,
+			&ghr.Parent.FullName,
+			&ghr.OpenIssues,
+			&ghr.Subscribers,
+			&ghr.Size,
+			&ghr.PushedAt,
+			&ghr.GhCreatedAt,
 		); err != nil {
 			return ghRepos, err
 		}
@@ -366,14 +534,23 @@ func (gr *GhRepositoryRepo) FindRepositoriesByNames(ctx context.Context, names [
 	return ghRepos, nil
 }
 
+// Save the repository along with the first snapshot of its stars and forks.
 func (gr *GhRepositoryRepo) Save(ctx context.Context, ghRepo GhRepository) (int64, error) {
-	query := "INSERT INTO `repositories` (`full_name`, `ghr_id`, stars, forks, `language`, `owner`, `owner_avatar_url`, `description`, `default_branch`, `homepage`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	query := "INSERT INTO `repositories` (`full_name`, `ghr_id`, stars, forks, `language`, `owner`, `owner_avatar_url`, `description`, `default_branch`, `homepage`, `topics`, `license`, `archived`, `fork`, `parent`, `open_issues`, `subscribers`, `size`, `pushed_at`, `github_created_at`, `created_at`, `updated_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
 	var lastInsertId int64
 
+	tx, err := gr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return lastInsertId, fmt.Errorf("failed to begin save repository transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
 	createdAt, updatedAt := time.Now(), time.Now()
 
-	result, err := gr.db.ExecContext(ctx, query,
+	result, err := tx.ExecContext(ctx, query,
 		ghRepo.FullName,
 		ghRepo.GhrId,
 		ghRepo.Stars,
@@ -384,6 +561,16 @@ func (gr *GhRepositoryRepo) Save(ctx context.Context, ghRepo GhRepository) (int6
 		ghRepo.GetDescription(),
 		ghRepo.DefaultBranch,
 		ghRepo.Homepage,
+		ghRepo.Topics,
+		ghRepo.License.SpdxId,
+		ghRepo.Archived,
+		ghRepo.Fork,
+		ghRepo.Parent.FullName,
+		ghRepo.OpenIssues,
+		ghRepo.Subscribers,
+		ghRepo.Size,
+		ghRepo.PushedAt,
+		ghRepo.GhCreatedAt,
 		createdAt.Format(time.DateTime),
 		updatedAt.Format(time.DateTime),
 	)
@@ -403,15 +590,34 @@ func (gr *GhRepositoryRepo) Save(ctx context.Context, ghRepo GhRepository) (int6
 		return lastInsertId, fmt.Errorf("repositories insert rows affected returns error: %v", err)
 	}
 
+	if err := insertRepositoryMetric(ctx, tx, int(lastInsertId), ghRepo.Stars, ghRepo.Forks); err != nil {
+		return lastInsertId, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return lastInsertId, fmt.Errorf("failed to commit save repository transaction: %v", err)
+	}
+
 	return lastInsertId, nil
 }
 
+// Update the repository, the stars and forks are also recorded as a snapshot so their history is kept.
 func (gr *GhRepositoryRepo) Update(ctx context.Context, ghRepo GhRepository) error {
-	query := "UPDATE `repositories` SET full_name = ?, ghr_id = ?, stars = ?, forks = ?, language = ?, owner = ?, owner_avatar_url = ?, description = ?, default_branch = ?, homepage = ?, updated_at = ? WHERE id = ?"
+	query := "UPDATE `repositories` SET full_name = ?, ghr_id = ?, stars = ?, forks = ?, language = ?, owner = ?, owner_avatar_url = ?, description = ?, default_branch = ?, homepage = ?, topics = ?, " +
+		"license = ?, archived = ?, fork = ?, parent = ?, open_issues = ?, subscribers = ?, size = ?, pushed_at = ?, github_created_at = ?, updated_at = ? WHERE id = ?"
+
+	tx, err := gr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin update repository transaction: %v", err)
+	}
+
+	defer tx.Rollback()
 
 	updatedAt := time.Now()
 
-	result, err := gr.db.ExecContext(ctx, query, ghRepo.FullName, ghRepo.GhrId, ghRepo.Stars, ghRepo.Forks, ghRepo.Language, ghRepo.Owner.Name, ghRepo.Owner.AvatarUrl, ghRepo.GetDescription(), ghRepo.DefaultBranch, ghRepo.Homepage, updatedAt.Format(time.DateTime), ghRepo.Id)
+	result, err := tx.ExecContext(ctx, query, ghRepo.FullName, ghRepo.GhrId, ghRepo.Stars, ghRepo.Forks, ghRepo.Language, ghRepo.Owner.Name, ghRepo.Owner.AvatarUrl, ghRepo.GetDescription(), ghRepo.DefaultBranch, ghRepo.Homepage, ghRepo.Topics,
+		ghRepo.License.SpdxId, ghRepo.Archived, ghRepo.Fork, ghRepo.Parent.FullName, ghRepo.OpenIssues, ghRepo.Subscribers, ghRepo.Size, ghRepo.PushedAt, ghRepo.GhCreatedAt, updatedAt.Format(time.DateTime), ghRepo.Id)
 
 	if err != nil {
 		return fmt.Errorf("failed to run repositories update query, gh repo id: %d, error: %v", ghRepo.Id, err)
@@ -427,10 +633,70 @@ func (gr *GhRepositoryRepo) Update(ctx context.Context, ghRepo GhRepository) err
 		return fmt.Errorf("unexpected number of rows affected after update: %d", n)
 	}
 
+	if err := insertRepositoryMetric(ctx, tx, ghRepo.Id, ghRepo.Stars, ghRepo.Forks); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit update repository transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Mark the repository as synced when it has not changed on GitHub, the unchanged stars and forks are recorded as a snapshot.
+func (gr *GhRepositoryRepo) Touch(ctx context.Context, id int) error {
+	now := time.Now().Format(time.DateTime)
+
+	tx, err := gr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to begin touch repository transaction: %v", err)
+	}
+
+	defer tx.Rollback()
+
+	query := "UPDATE `repositories` SET updated_at = ? WHERE id = ?"
+
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to run repositories touch query, repository id: %d, error: %v", id, err)
+	}
+
+	query = "INSERT INTO `repository_metrics` (`repository_id`, `stars`, `forks`, `recorded_at`) SELECT `id`, `stars`, `forks`, ? FROM `repositories` WHERE `id` = ?"
+
+	if _, err := tx.ExecContext(ctx, query, now, id); err != nil {
+		return fmt.Errorf("failed to insert repository metric, repository id: %d, error: %v", id, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit touch repository transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Mark the repository as synced when it is missing or blocked on GitHub, no snapshot is recorded as its stars and forks are unknown.
+func (gr *GhRepositoryRepo) TouchMissing(ctx context.Context, id int) error {
+	query := "UPDATE `repositories` SET updated_at = ? WHERE id = ?"
+
+	if _, err := gr.db.ExecContext(ctx, query, time.Now().Format(time.DateTime), id); err != nil {
+		return fmt.Errorf("failed to run repositories touch query, repository id: %d, error: %v", id, err)
+	}
+
 	return nil
 }
 
+// Replace the tags assigned by editors, the tags from GitHub topics are kept.
 func (gr *GhRepositoryRepo) SaveTags(ctx context.Context, ghRepo GhRepository, tags []Tag) error {
+	return gr.saveTags(ctx, ghRepo, tags, TagSourceManual)
+}
+
+// Replace the tags mapped from the GitHub topics of the repository, the tags assigned by editors are kept.
+func (gr *GhRepositoryRepo) SaveTopicTags(ctx context.Context, ghRepo GhRepository, tags []Tag) error {
+	return gr.saveTags(ctx, ghRepo, tags, TagSourceTopic)
+}
+
+func (gr *GhRepositoryRepo) saveTags(ctx context.Context, ghRepo GhRepository, tags []Tag, source string) error {
 	tx, err := gr.db.BeginTx(ctx, nil)
 
 	if err != nil {
@@ -438,18 +704,23 @@ func (gr *GhRepositoryRepo) SaveTags(ctx context.Context, ghRepo GhRepository, t
 	}
 
 	defer tx.Rollback()
-	query := "DELETE FROM `repositories_tags` WHERE repository_id = ?"
+	query := "DELETE FROM `repositories_tags` WHERE repository_id = ? AND source = ?"
 
-	_, err = tx.ExecContext(ctx, query, ghRepo.Id)
+	_, err = tx.ExecContext(ctx, query, ghRepo.Id, source)
 
 	if err != nil {
 		return fmt.Errorf("failed to run delete repositories_tags query, repository id: %d, error: %v", ghRepo.Id, err)
 	}
 
-	for _, tag := range tags {
-		query := "INSERT INTO `repositories_tags` (`repository_id`, `tag_id`) VALUES (?, ?)"
+	// A tag assigned by an editor takes over the same tag from topics, a topic tag never takes over a manual one.
+	insert := "INSERT INTO `repositories_tags` (`repository_id`, `tag_id`, `source`) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE source = VALUES(source)"
 
-		result, err := tx.ExecContext(ctx, query, ghRepo.Id, tag.Id)
+	if source == TagSourceTopic {
+		insert = "INSERT IGNORE INTO `repositories_tags` (`repository_id`, `tag_id`, `source`) VALUES (?, ?, ?)"
+	}
+
+	for _, tag := range tags {
+		result, err := tx.ExecContext(ctx, insert, ghRepo.Id, tag.Id, source)
 
 		if err != nil {
 			return fmt.Errorf("failed to run insert repositories_tags query, repository id: %d, tag id: %d error: %v", ghRepo.Id, tag.Id, err)
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// A run is abnormal when it has less rows than this ratio of the recent average.
+const abnormalRowsRatio = 0.5
+
+// Minimum number of rows expected on the trending page of all languages, the other pages expect the rows of their language.
+const allLanguagesExpectedRows = 20
+
+type ScrapeHealth struct {
+	Type           string    `json:"type"`
+	Language       string    `json:"language"`
+	SpokenLanguage string    `json:"spoken_language"`
+	Period         string    `json:"period"`
+	Status         string    `json:"status"`
+	Rows           int       `json:"rows"`
+	ExpectedRows   int       `json:"expected_rows"`
+	AverageRows    float64   `json:"average_rows"`
+	ScrapedAt      time.Time `json:"scraped_at"`
+	Healthy        bool      `json:"healthy"`
+	Issues         []string  `json:"issues"`
+}
+
+type scrapeRunKey struct {
+	scrapeType, language, spokenLanguage, period string
+}
+
+// Check the latest scrape run of every type, language, spoken language and period against the expected rows
+// and the runs before it. Expected rows come from the languages and only apply to pages not filtered by spoken language.
+func CheckScrapeHealth(runs []ScrapeRun, languages []Language) []ScrapeHealth {
+	expectedRows := map[string]int{"": allLanguagesExpectedRows}
+
+	for _, language := range languages {
+		expectedRows[LanguageSlug(language.Slug)] = language.ExpectedRows
+	}
+
+	grouped := make(map[scrapeRunKey][]ScrapeRun)
+
+	for _, run := range runs {
+		key := scrapeRunKey{run.Type, run.Language, run.SpokenLanguage, PeriodOrDefault(run.Period)}
+		grouped[key] = append(grouped[key], run)
+	}
+
+	healths := make([]ScrapeHealth, 0, len(grouped))
+
+	for key, runs := range grouped {
+		sort.SliceStable(runs, func(i, j int) bool {
+			return runs[i].ScrapedAt.Before(runs[j].ScrapedAt)
+		})
+
+		latest, history := runs[len(runs)-1], runs[:len(runs)-1]
+
+		health := ScrapeHealth{
+			Type:           key.scrapeType,
+			Language:       key.language,
+			SpokenLanguage: key.spokenLanguage,
+			Period:         key.period,
+			Status:         latest.Status,
+			Rows:           latest.Parsed,
+			ScrapedAt:      latest.ScrapedAt,
+			Issues:         make([]string, 0),
+		}
+
+		if key.spokenLanguage == "" {
+			health.ExpectedRows = expectedRows[key.language]
+		}
+
+		var total, count int
+
+		for _, run := range history {
+			if run.Status == ScrapeRunOk || run.Status == ScrapeRunEmpty {
+				total += run.Parsed
+				count++
+			}
+		}
+
+		if count > 0 {
+			health.AverageRows = float64(total) / float64(count)
+		}
+
+		switch latest.Status {
+		case ScrapeRunFailed:
+			health.Issues = append(health.Issues, fmt.Sprintf("failed to scrape the trending page: %s", latest.Error.String))
+		case ScrapeRunLayoutChanged:
+			health.Issues = append(health.Issues, fmt.Sprintf("the page layout has changed, %d rows matched but %d parsed", latest.Rows, latest.Parsed))
+		}
+
+		if latest.Status == ScrapeRunOk || latest.Status == ScrapeRunEmpty {
+			if latest.Parsed == 0 && (health.ExpectedRows > 0 || health.AverageRows > 0) {
+				health.Issues = append(health.Issues, "no rows scraped")
+			} else if latest.Parsed < health.ExpectedRows {
+				health.Issues = append(health.Issues, fmt.Sprintf("only %d rows scraped, expected at least %d", latest.Parsed, health.ExpectedRows))
+			} else if latest.Parsed > 0 && float64(latest.Parsed) < health.AverageRows*abnormalRowsRatio {
+				health.Issues = append(health.Issues, fmt.Sprintf("only %d rows scraped, recent average is %.1f", latest.Parsed, health.AverageRows))
+			}
+		}
+
+		health.Healthy = len(health.Issues) == 0
+		healths = append(healths, health)
+	}
+
+	sort.Slice(healths, func(i, j int) bool {
+		a, b := healths[i], healths[j]
+
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+
+		if a.Period != b.Period {
+			return a.Period < b.Period
+		}
+
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+
+		return a.SpokenLanguage < b.SpokenLanguage
+	})
+
+	return healths
+}
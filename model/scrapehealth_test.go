@@ -0,0 +1,107 @@
+package model
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+func TestCheckScrapeHealth(t *testing.T) {
+	now := time.Now()
+	yesterday, twoDaysAgo := now.AddDate(0, 0, -1), now.AddDate(0, 0, -2)
+
+	run := func(language, spokenLanguage string, parsed int, status string, scrapedAt time.Time) ScrapeRun {
+		return ScrapeRun{
+			Type:           "repository",
+			Language:       language,
+			SpokenLanguage: spokenLanguage,
+			Period:         DailyPeriod,
+			Rows:           parsed,
+			Parsed:         parsed,
+			Status:         status,
+			ScrapedAt:      scrapedAt,
+		}
+	}
+
+	failed := run("c", "", 0, ScrapeRunFailed, now)
+	failed.Error = dbutils.NullString{NullString: sql.NullString{String: "timeout", Valid: true}}
+
+	changed := run("c++", "", 0, ScrapeRunLayoutChanged, now)
+	changed.Rows = 25
+
+	runs := []ScrapeRun{
+		run("", "", 25, ScrapeRunOk, now),
+		run("", "", 25, ScrapeRunOk, yesterday),
+		run("go", "", 25, ScrapeRunOk, twoDaysAgo),
+		run("go", "", 24, ScrapeRunOk, yesterday),
+		run("go", "", 0, ScrapeRunEmpty, now),
+		run("rust", "", 25, ScrapeRunOk, yesterday),
+		run("rust", "", 13, ScrapeRunOk, now),
+		run("dart", "", 2, ScrapeRunOk, now),
+		run("swift", "", 3, ScrapeRunOk, yesterday),
+		run("swift", "", 1, ScrapeRunOk, now),
+		run("zig", "ja", 0, ScrapeRunEmpty, now),
+		failed,
+		changed,
+	}
+
+	languages := []Language{{Slug: "go", ExpectedRows: 10}, {Slug: "rust", ExpectedRows: 10}, {Slug: "dart", ExpectedRows: 1}, {Slug: "swift", ExpectedRows: 3}}
+
+	healths := CheckScrapeHealth(runs, languages)
+
+	expcts := []struct {
+		language, spokenLanguage string
+		healthy                  bool
+		issues                   int
+	}{
+		{language: "", healthy: true},
+		{language: "c", issues: 1},
+		{language: "c++", issues: 1},
+		{language: "dart", healthy: true},
+		{language: "go", issues: 1},
+		{language: "rust", healthy: true},
+		{language: "swift", issues: 1},
+		{language: "zig", spokenLanguage: "ja", healthy: true},
+	}
+
+	if len(healths) != len(expcts) {
+		t.Fatalf("expect %d health results, actual got: %d", len(expcts), len(healths))
+	}
+
+	for i, want := range expcts {
+		health := healths[i]
+
+		if health.Language != want.language || health.SpokenLanguage != want.spokenLanguage {
+			t.Errorf("expect health %d for language %q and spoken language %q, actual got: %q and %q", i, want.language, want.spokenLanguage, health.Language, health.SpokenLanguage)
+		}
+
+		if health.Healthy != want.healthy || len(health.Issues) != want.issues {
+			t.Errorf("expect language %q healthy: %v with %d issues, actual got: %v with %v", want.language, want.healthy, want.issues, health.Healthy, health.Issues)
+		}
+	}
+
+	if healths[4].AverageRows != 24.5 {
+		t.Errorf("expect go average rows to be 24.5, actual got: %v", healths[4].AverageRows)
+	}
+}
+
+func TestCheckScrapeHealthAbnormalDrop(t *testing.T) {
+	now := time.Now()
+
+	runs := []ScrapeRun{
+		{Type: "developer", Language: "php", Period: WeeklyPeriod, Parsed: 25, Status: ScrapeRunOk, ScrapedAt: now.AddDate(0, 0, -1)},
+		{Type: "developer", Language: "php", Period: WeeklyPeriod, Parsed: 5, Status: ScrapeRunOk, ScrapedAt: now},
+	}
+
+	healths := CheckScrapeHealth(runs, nil)
+
+	if len(healths) != 1 {
+		t.Fatalf("expect 1 health result, actual got: %d", len(healths))
+	}
+
+	if healths[0].Healthy || healths[0].ExpectedRows != 0 || healths[0].Rows != 5 {
+		t.Errorf("expect an unhealthy result with 5 rows and no expected rows, actual got: %+v", healths[0])
+	}
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// Status of a single scrape of a GitHub trending page.
+const (
+	ScrapeRunOk            = "ok"             // rows were found and parsed.
+	ScrapeRunEmpty         = "empty"          // GitHub shows its "no trending data" notice for the page.
+	ScrapeRunLayoutChanged = "layout_changed" // the selectors do not match the page anymore.
+	ScrapeRunFailed        = "failed"         // the page could not be fetched.
+)
+
+type ScrapeRun struct {
+	Id             int
+	Type           string
+	Language       string
+	SpokenLanguage string
+	Period         string
+	Rows           int // rows matched by the row selector.
+	Parsed         int // rows that could be parsed into a repository or developer.
+	Status         string
+	Error          dbutils.NullString
+	ScrapedAt      time.Time
+}
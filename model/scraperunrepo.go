@@ -0,0 +1,97 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/database"
+)
+
+type ScrapeRunRepo struct {
+	db database.DB
+}
+
+func NewScrapeRunRepo(db database.DB) *ScrapeRunRepo {
+	return &ScrapeRunRepo{
+		db: db,
+	}
+}
+
+func (sr *ScrapeRunRepo) Save(ctx context.Context, run ScrapeRun) error {
+	query := "INSERT INTO `scrape_runs` (`type`, `language`, `spoken_language`, `period`, `rows`, `parsed`, `status`, `error`, `scraped_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	scrapedAt := time.Now()
+
+	if !run.ScrapedAt.IsZero() {
+		scrapedAt = run.ScrapedAt
+	}
+
+	result, err := sr.db.ExecContext(
+		ctx,
+		query,
+		run.Type,
+		run.Language,
+		run.SpokenLanguage,
+		PeriodOrDefault(run.Period),
+		run.Rows,
+		run.Parsed,
+		run.Status,
+		run.Error,
+		scrapedAt.Format(time.DateTime),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to exec insert scrape_runs query to db, type: %s, language: %s, error: %v", run.Type, run.Language, err)
+	}
+
+	_, err = result.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("scrape_runs insert rows affected returns error: %v", err)
+	}
+
+	return nil
+}
+
+// Find all scrape runs since the given time, the oldest run comes first.
+func (sr *ScrapeRunRepo) FindSince(ctx context.Context, since time.Time) ([]ScrapeRun, error) {
+	query := "SELECT * FROM `scrape_runs` WHERE `scraped_at` >= ? ORDER BY `scraped_at` ASC, `id` ASC"
+
+	rows, err := sr.db.QueryContext(ctx, query, since.Format(time.DateTime))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	runs := make([]ScrapeRun, 0)
+
+	for rows.Next() {
+		var run ScrapeRun
+
+		if err := rows.Scan(
+			&run.Id,
+			&run.Type,
+			&run.Language,
+			&run.SpokenLanguage,
+			&run.Period,
+			&run.Rows,
+			&run.Parsed,
+			&run.Status,
+			&run.Error,
+			&run.ScrapedAt,
+		); err != nil {
+			return runs, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return runs, err
+	}
+
+	return runs, nil
+}
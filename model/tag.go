@@ -1,5 +1,11 @@
 package model
 
+// How a tag is assigned to a repository.
+const (
+	TagSourceManual = "manual" // assigned by an editor.
+	TagSourceTopic  = "topic"  // mapped from a GitHub topic of the repository.
+)
+
 type Tag struct {
 	Id   int    `json:"id"`
 	Name string `json:"name"`
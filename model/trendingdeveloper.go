@@ -7,11 +7,16 @@ import (
 )
 
 type TrendingDeveloper struct {
-	Id          int
-	Username    string
-	Language    dbutils.NullString
-	Rank        int
-	ScrapedAt   time.Time
-	TrendDate   time.Time
-	DeveloperId dbutils.NullInt64
+	Id                  int
+	Username            string
+	Language            dbutils.NullString
+	Rank                int
+	ScrapedAt           time.Time
+	TrendDate           time.Time
+	DeveloperId         dbutils.NullInt64
+	Period              string
+	PopularRepoFullName dbutils.NullString // the popular repository highlighted next to the developer on the trending page.
+	PopularRepositoryId dbutils.NullInt64
+	Unavailable         bool // the developer was not found on GitHub when it was linked.
+	RepoUnavailable     bool // the popular repository was not found or blocked on GitHub when it was linked.
 }
@@ -2,15 +2,15 @@ package model
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
-type RankedTrendingDevelopers = map[int]TrendingDeveloper
-
 type TrendingDeveloperRepo struct {
 	db database.DB
 }
@@ -39,8 +39,9 @@ func (tdr *TrendingDeveloperRepo) LinkDeveloper(ctx context.Context, developer D
 	return nil
 }
 
+// Get the usernames of the trending developers that are not linked yet, developers that were not found on GitHub are left out.
 func (tdr *TrendingDeveloperRepo) FindUnlinkedDevelopers(ctx context.Context) ([]string, error) {
-	query := "select `username` from `trending_developers` where `developer_id` is null group by `username`"
+	query := "select `username` from `trending_developers` where `developer_id` is null group by `username` having max(`unavailable`) = 0"
 
 	rows, err := tdr.db.QueryContext(ctx, query)
 
@@ -72,99 +73,291 @@ func (tdr *TrendingDeveloperRepo) FindUnlinkedDevelopers(ctx context.Context) ([
 
 }
 
-func (tdr *TrendingDeveloperRepo) FindRankedTrendingDevelopersByDate(ctx context.Context, date time.Time, language string) (RankedTrendingDevelopers, error) {
-	lang := strings.TrimSpace(language)
+// Get all popular repositories' full name when there is no repository_id set in the table,
+// repositories that were not found or blocked on GitHub are left out.
+func (tdr *TrendingDeveloperRepo) FindUnlinkedPopularRepositories(ctx context.Context) ([]string, error) {
+	query := "select `repo_full_name` from `trending_developers` where `repo_full_name` is not null and `repository_id` is null group by `repo_full_name` having max(`repo_unavailable`) = 0"
 
-	query := "SELECT * FROM trending_developers WHERE trend_date = ? AND language is null"
-	args := []any{date.Format("2006-01-02")}
+	rows, err := tdr.db.QueryContext(ctx, query)
 
-	if lang != "" {
-		query = "SELECT * FROM trending_developers WHERE trend_date = ? AND language = ?"
-		args = append(args, lang)
+	if err != nil {
+		return nil, err
 	}
 
-	rows, err := tdr.db.QueryContext(ctx, query, args...)
+	defer rows.Close()
+
+	unlinkedRepos := make([]string, 0)
+
+	for rows.Next() {
+		var fullName string
+
+		if err := rows.Scan(&fullName); err != nil {
+			return unlinkedRepos, err
+		}
+
+		unlinkedRepos = append(unlinkedRepos, fullName)
+	}
+
+	if err = rows.Err(); err != nil {
+		return unlinkedRepos, err
+	}
+
+	return unlinkedRepos, nil
+}
+
+// Mark the developer as not found on GitHub, so it is not requested again by every link.
+func (tdr *TrendingDeveloperRepo) MarkDeveloperUnavailable(ctx context.Context, username string) error {
+	query := "UPDATE `trending_developers` SET unavailable = 1 WHERE username = ? AND developer_id IS NULL"
+
+	if _, err := tdr.db.ExecContext(ctx, query, username); err != nil {
+		return fmt.Errorf("failed to mark developer unavailable, developer: %s, error: %v", username, err)
+	}
+
+	return nil
+}
+
+// Save the relation between trending developers and their popular repositories.
+func (tdr *TrendingDeveloperRepo) LinkPopularRepository(ctx context.Context, repository GhRepository) error {
+	query := "UPDATE `trending_developers` SET repository_id = ? WHERE repo_full_name = ?"
+
+	result, err := tdr.db.ExecContext(ctx, query, repository.Id, repository.FullName)
 
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to run link popular repository update query, repository: %s, error: %v", repository.FullName, err)
+	}
+
+	_, err = result.RowsAffected()
+
+	if err != nil {
+		return fmt.Errorf("link popular repository rows affected returns error: %v", err)
+	}
+
+	return nil
+}
+
+// Mark the popular repository as not found or blocked on GitHub, so it is not requested again by every link.
+func (tdr *TrendingDeveloperRepo) MarkPopularRepositoryUnavailable(ctx context.Context, fullName string) error {
+	query := "UPDATE `trending_developers` SET repo_unavailable = 1 WHERE repo_full_name = ? AND repository_id IS NULL"
+
+	if _, err := tdr.db.ExecContext(ctx, query, fullName); err != nil {
+		return fmt.Errorf("failed to mark popular repository unavailable, repository: %s, error: %v", fullName, err)
+	}
+
+	return nil
+}
+
+// Add the criteria of a trending page to the query.
+func whereTrendingDeveloperPage(qb *dbutils.QueryBuilder, language, period string) {
+	qb.Where("period = ?", PeriodOrDefault(period))
+
+	if language != "" {
+		qb.Where("language = ?", language)
+	} else {
+		qb.Where("language is null", nil)
+	}
+}
+
+// Find the ranks keyed by username of the latest snapshot on or before the given date.
+func (tdr *TrendingDeveloperRepo) findPreviousRanks(ctx context.Context, tx *sql.Tx, date time.Time, language, period string) (map[string]int, error) {
+	ranks := make(map[string]int)
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT max(trend_date) FROM trending_developers")
+	qb.Where("trend_date <= ?", date.Format("2006-01-02"))
+	whereTrendingDeveloperPage(qb, language, period)
+	query, args := qb.GetQuery()
+
+	var previousDate sql.NullTime
+
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&previousDate); err != nil {
+		return nil, fmt.Errorf("failed to query previous trending developers date: %v", err)
+	}
+
+	if !previousDate.Valid {
+		return ranks, nil
+	}
+
+	qb.Query("SELECT username, `rank` FROM trending_developers")
+	qb.Where("trend_date = ?", previousDate.Time.Format("2006-01-02"))
+	whereTrendingDeveloperPage(qb, language, period)
+	query, args = qb.GetQuery()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous trending developers: %v", err)
 	}
 
 	defer rows.Close()
 
-	rankedTrendingDevelopers := make(map[int]TrendingDeveloper, 0)
+	for rows.Next() {
+		var username string
+		var rank int
+
+		if err := rows.Scan(&username, &rank); err != nil {
+			return ranks, err
+		}
+
+		ranks[username] = rank
+	}
+
+	if err = rows.Err(); err != nil {
+		return ranks, err
+	}
+
+	return ranks, nil
+}
+
+// Find the names in the column marked unavailable by the flag column on the trending page of the given date,
+// e.g. the usernames by unavailable or the popular repositories by repo_unavailable, the marks are kept when the page is replaced.
+func (tdr *TrendingDeveloperRepo) findUnavailable(ctx context.Context, tx *sql.Tx, column, flag string, date time.Time, language, period string) (map[string]bool, error) {
+	qb := dbutils.NewQueryBuilder()
+	qb.Query(fmt.Sprintf("SELECT DISTINCT %s FROM trending_developers", column))
+	qb.Where("trend_date = ?", date.Format("2006-01-02"))
+	qb.Where(flag+" = ?", true)
+	whereTrendingDeveloperPage(qb, language, period)
+	query, args := qb.GetQuery()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unavailable %s of trending developers: %v", column, err)
+	}
+
+	defer rows.Close()
+
+	unavailable := make(map[string]bool)
 
 	for rows.Next() {
-		var td TrendingDeveloper
+		var name string
 
-		if err := rows.Scan(&td.Id, &td.Username, &td.Language, &td.Rank, &td.ScrapedAt, &td.TrendDate, &td.DeveloperId); err != nil {
-			return rankedTrendingDevelopers, err
+		if err := rows.Scan(&name); err != nil {
+			return unavailable, err
 		}
 
-		rankedTrendingDevelopers[td.Rank] = td
+		unavailable[name] = true
 	}
 
 	if err = rows.Err(); err != nil {
-		return rankedTrendingDevelopers, err
+		return unavailable, err
 	}
 
-	return rankedTrendingDevelopers, nil
+	return unavailable, nil
 }
 
-func (tdr *TrendingDeveloperRepo) Save(ctx context.Context, trendingDeveloper TrendingDeveloper) error {
-	query := "INSERT INTO `trending_developers` (`username`, `language`, `rank`, `scraped_at`, `trend_date`) VALUES (?, ?, ?, ?, ?)"
+// Replace the snapshot of a trending developer page for the given date in a single transaction,
+// it returns what entered, left or moved compared with the previous snapshot and saves them as rank changes.
+func (tdr *TrendingDeveloperRepo) ReplaceSnapshot(ctx context.Context, date time.Time, language string, trendingDevelopers []TrendingDeveloper, opts ...any) ([]RankChange, error) {
+	lang := LanguageSlug(language)
+	period := PeriodOrDefault(opt.ExtractOptions(opts...).Period)
 
-	scrapeAt := time.Now()
+	tx, err := tdr.db.BeginTx(ctx, nil)
 
-	if !trendingDeveloper.ScrapedAt.IsZero() {
-		scrapeAt = trendingDeveloper.ScrapedAt
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin replace trending developers transaction: %v", err)
 	}
 
-	result, err := tdr.db.ExecContext(
-		ctx,
-		query,
-		trendingDeveloper.Username,
-		trendingDeveloper.Language,
-		trendingDeveloper.Rank,
-		scrapeAt.Format(time.DateTime),
-		trendingDeveloper.TrendDate.Format("2006-01-02"),
-	)
+	defer tx.Rollback()
+
+	previous, err := tdr.findPreviousRanks(ctx, tx, date, lang, period)
 
 	if err != nil {
-		return fmt.Errorf("failed to exec insert trending_developers query to db language: %v, username: %s, error: %v", trendingDeveloper.Language, trendingDeveloper.Username, err)
+		return nil, err
 	}
 
-	_, err = result.RowsAffected()
+	unavailableDevelopers, err := tdr.findUnavailable(ctx, tx, "username", "unavailable", date, lang, period)
 
 	if err != nil {
-		return fmt.Errorf("trending_developers insert rows affected returns error: %v", err)
+		return nil, err
 	}
 
-	return nil
+	unavailableRepositories, err := tdr.findUnavailable(ctx, tx, "repo_full_name", "repo_unavailable", date, lang, period)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("DELETE FROM trending_developers")
+	qb.Where("trend_date = ?", date.Format("2006-01-02"))
+	whereTrendingDeveloperPage(qb, lang, period)
+	query, args := qb.GetQuery()
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to delete trending developers snapshot, language: %s, error: %v", lang, err)
+	}
+
+	current := make(map[string]int, len(trendingDevelopers))
+
+	for _, trendingDeveloper := range trendingDevelopers {
+		trendingDeveloper.Unavailable = unavailableDevelopers[trendingDeveloper.Username]
+		trendingDeveloper.RepoUnavailable = unavailableRepositories[trendingDeveloper.PopularRepoFullName.String]
+
+		if err := insertTrendingDeveloper(ctx, tx, trendingDeveloper); err != nil {
+			return nil, err
+		}
+
+		current[trendingDeveloper.Username] = trendingDeveloper.Rank
+	}
+
+	changes := DiffRanks(previous, current)
+
+	for i := range changes {
+		changes[i].Type = RankChangeDeveloper
+		changes[i].Language = dbutils.NewNullString(lang)
+		changes[i].Period = period
+		changes[i].TrendDate = date
+	}
+
+	if err := saveRankChanges(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit replace trending developers transaction: %v", err)
+	}
+
+	return changes, nil
+}
+
+func (tdr *TrendingDeveloperRepo) Save(ctx context.Context, trendingDeveloper TrendingDeveloper) error {
+	return insertTrendingDeveloper(ctx, tdr.db, trendingDeveloper)
 }
 
-func (tdr *TrendingDeveloperRepo) Update(ctx context.Context, trendingDeveloper TrendingDeveloper) error {
-	query := "UPDATE `trending_developers` SET `username` = ?, `rank` = ?, `language` = ?, `scraped_at` = ?, `trend_date` = ?, `developer_id` = ? WHERE `id` = ?"
+// Insert a trending developer and link it with the developer and the popular repository when they have been fetched from GitHub before.
+func insertTrendingDeveloper(ctx context.Context, db database.Execer, trendingDeveloper TrendingDeveloper) error {
+	query := "INSERT INTO `trending_developers` (`username`, `language`, `rank`, `scraped_at`, `trend_date`, `period`, `repo_full_name`, `unavailable`, `repo_unavailable`, `developer_id`, `repository_id`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT `id` FROM `developers` WHERE `username` = ?), (SELECT `id` FROM `repositories` WHERE `full_name` = ?))"
+
+	scrapeAt := time.Now()
+
+	if !trendingDeveloper.ScrapedAt.IsZero() {
+		scrapeAt = trendingDeveloper.ScrapedAt
+	}
 
-	result, err := tdr.db.ExecContext(
+	result, err := db.ExecContext(
 		ctx,
 		query,
 		trendingDeveloper.Username,
-		trendingDeveloper.Rank,
 		trendingDeveloper.Language,
-		trendingDeveloper.ScrapedAt.Format(time.DateTime),
+		trendingDeveloper.Rank,
+		scrapeAt.Format(time.DateTime),
 		trendingDeveloper.TrendDate.Format("2006-01-02"),
-		nil,
-		trendingDeveloper.Id,
+		PeriodOrDefault(trendingDeveloper.Period),
+		trendingDeveloper.PopularRepoFullName,
+		trendingDeveloper.Unavailable,
+		trendingDeveloper.RepoUnavailable,
+		trendingDeveloper.Username,
+		trendingDeveloper.PopularRepoFullName,
 	)
 
 	if err != nil {
-		return fmt.Errorf("failed to run trending_developers update query, trending developer id: %d, error: %v", trendingDeveloper.Id, err)
+		return fmt.Errorf("failed to exec insert trending_developers query to db language: %v, username: %s, error: %v", trendingDeveloper.Language, trendingDeveloper.Username, err)
 	}
 
 	_, err = result.RowsAffected()
 
 	if err != nil {
-		return fmt.Errorf("trending_developers update rows affected returns error: %v", err)
+		return fmt.Errorf("trending_developers insert rows affected returns error: %v", err)
 	}
 
 	return nil
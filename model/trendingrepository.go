@@ -1,17 +1,69 @@
 package model
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
+// A developer listed in the "Built by" section of a trending repository row.
+type Contributor struct {
+	Username  string `json:"username"`
+	AvatarUrl string `json:"avatar_url"`
+}
+
+// Contributors are saved as a json column.
+type Contributors []Contributor
+
+func (c Contributors) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	value, err := json.Marshal(c)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode contributors: %v", err)
+	}
+
+	return string(value), nil
+}
+
+func (c *Contributors) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported contributors type: %T", src)
+	}
+
+	return json.Unmarshal(data, c)
+}
+
 type TrendingRepository struct {
-	Id           int
-	RepoFullName string
-	Language     dbutils.NullString
-	Rank         int
-	ScrapedAt    time.Time
-	TrendDate    time.Time
-	RepositoryId dbutils.NullInt64
+	Id             int
+	RepoFullName   string
+	Language       dbutils.NullString
+	Rank           int
+	ScrapedAt      time.Time
+	TrendDate      time.Time
+	RepositoryId   dbutils.NullInt64
+	Period         string
+	SpokenLanguage dbutils.NullString
+	StarsToday     int                // stars gained during the trending period, e.g. "1,240 stars today".
+	Stars          int                // total stars shown on the trending page.
+	Forks          int                // total forks shown on the trending page.
+	RepoLanguage   dbutils.NullString // primary language shown on the trending page.
+	BuiltBy        Contributors
+	Unavailable    bool // the repository was not found or blocked on GitHub when it was linked.
 }
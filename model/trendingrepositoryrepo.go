@@ -2,15 +2,15 @@ package model
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
-type RankedTrendingRepository = map[int]TrendingRepository
-
 type TrendingRepositoryRepo struct {
 	db database.DB
 }
@@ -21,9 +21,10 @@ func NewTrendingRepositoryRepo(db database.DB) *TrendingRepositoryRepo {
 	}
 }
 
-// Get all repositories' full name when there is no repository_id set in the table.
+// Get all repositories' full name when there is no repository_id set in the table,
+// repositories that were not found or blocked on GitHub are left out.
 func (tr *TrendingRepositoryRepo) FindUnlinkedRepositories(ctx context.Context) ([]string, error) {
-	query := "select `full_name` from `trending_repositories` where `repository_id` is null group by `full_name`"
+	query := "select `full_name` from `trending_repositories` where `repository_id` is null group by `full_name` having max(`unavailable`) = 0"
 
 	rows, err := tr.db.QueryContext(ctx, query)
 
@@ -54,46 +55,187 @@ func (tr *TrendingRepositoryRepo) FindUnlinkedRepositories(ctx context.Context)
 	return unlinkedRepos, nil
 }
 
-func (tr *TrendingRepositoryRepo) FindRankedTrendingRepoByDate(ctx context.Context, date time.Time, language string) (RankedTrendingRepository, error) {
-	lang := strings.TrimSpace(language)
+// Add the criteria of a trending page to the query.
+func whereTrendingRepositoryPage(qb *dbutils.QueryBuilder, language, spokenLanguage, period string) {
+	qb.Where("period = ?", PeriodOrDefault(period))
+
+	if language != "" {
+		qb.Where("language = ?", language)
+	} else {
+		qb.Where("language is null", nil)
+	}
+
+	if spokenLanguage != "" {
+		qb.Where("spoken_language = ?", spokenLanguage)
+	} else {
+		qb.Where("spoken_language is null", nil)
+	}
+}
+
+// Find the ranks keyed by full name of the latest snapshot on or before the given date.
+func (tr *TrendingRepositoryRepo) findPreviousRanks(ctx context.Context, tx *sql.Tx, date time.Time, language, spokenLanguage, period string) (map[string]int, error) {
+	ranks := make(map[string]int)
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT max(trend_date) FROM trending_repositories")
+	qb.Where("trend_date <= ?", date.Format("2006-01-02"))
+	whereTrendingRepositoryPage(qb, language, spokenLanguage, period)
+	query, args := qb.GetQuery()
 
-	query := "SELECT * FROM trending_repositories WHERE trend_date = ? AND language is null"
-	args := []any{date.Format("2006-01-02")}
+	var previousDate sql.NullTime
+
+	if err := tx.QueryRowContext(ctx, query, args...).Scan(&previousDate); err != nil {
+		return nil, fmt.Errorf("failed to query previous trending repositories date: %v", err)
+	}
 
-	if lang != "" {
-		query = "SELECT * FROM trending_repositories WHERE trend_date = ? AND language = ?"
-		args = append(args, lang)
+	if !previousDate.Valid {
+		return ranks, nil
 	}
 
-	rows, err := tr.db.QueryContext(ctx, query, args...)
+	qb.Query("SELECT full_name, `rank` FROM trending_repositories")
+	qb.Where("trend_date = ?", previousDate.Time.Format("2006-01-02"))
+	whereTrendingRepositoryPage(qb, language, spokenLanguage, period)
+	query, args = qb.GetQuery()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to query previous trending repositories: %v", err)
 	}
 
 	defer rows.Close()
 
-	rankedTrendingRepositories := make(map[int]TrendingRepository, 0)
+	for rows.Next() {
+		var fullName string
+		var rank int
+
+		if err := rows.Scan(&fullName, &rank); err != nil {
+			return ranks, err
+		}
+
+		ranks[fullName] = rank
+	}
+
+	if err = rows.Err(); err != nil {
+		return ranks, err
+	}
+
+	return ranks, nil
+}
+
+// Find the repositories marked unavailable on the trending page of the given date, the marks are kept when the page is replaced.
+func (tr *TrendingRepositoryRepo) findUnavailable(ctx context.Context, tx *sql.Tx, date time.Time, language, spokenLanguage, period string) (map[string]bool, error) {
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("SELECT DISTINCT full_name FROM trending_repositories")
+	qb.Where("trend_date = ?", date.Format("2006-01-02"))
+	qb.Where("unavailable = ?", true)
+	whereTrendingRepositoryPage(qb, language, spokenLanguage, period)
+	query, args := qb.GetQuery()
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unavailable trending repositories: %v", err)
+	}
+
+	defer rows.Close()
+
+	unavailable := make(map[string]bool)
 
 	for rows.Next() {
-		var tr TrendingRepository
+		var fullName string
 
-		if err := rows.Scan(&tr.Id, &tr.RepoFullName, &tr.Language, &tr.Rank, &tr.ScrapedAt, &tr.TrendDate, &tr.RepositoryId); err != nil {
-			return rankedTrendingRepositories, err
+		if err := rows.Scan(&fullName); err != nil {
+			return unavailable, err
 		}
 
-		rankedTrendingRepositories[tr.Rank] = tr
+		unavailable[fullName] = true
 	}
 
 	if err = rows.Err(); err != nil {
-		return rankedTrendingRepositories, err
+		return unavailable, err
+	}
+
+	return unavailable, nil
+}
+
+// Replace the snapshot of a trending page for the given date in a single transaction,
+// it returns what entered, left or moved compared with the previous snapshot and saves them as rank changes.
+func (tr *TrendingRepositoryRepo) ReplaceSnapshot(ctx context.Context, date time.Time, language string, trendingRepositories []TrendingRepository, opts ...any) ([]RankChange, error) {
+	lang := LanguageSlug(language)
+	options := opt.ExtractOptions(opts...)
+	spokenLanguage, period := options.SpokenLanguage, PeriodOrDefault(options.Period)
+
+	tx, err := tr.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin replace trending repositories transaction: %v", err)
 	}
 
-	return rankedTrendingRepositories, nil
+	defer tx.Rollback()
+
+	previous, err := tr.findPreviousRanks(ctx, tx, date, lang, spokenLanguage, period)
+
+	if err != nil {
+		return nil, err
+	}
+
+	unavailable, err := tr.findUnavailable(ctx, tx, date, lang, spokenLanguage, period)
+
+	if err != nil {
+		return nil, err
+	}
+
+	qb := dbutils.NewQueryBuilder()
+	qb.Query("DELETE FROM trending_repositories")
+	qb.Where("trend_date = ?", date.Format("2006-01-02"))
+	whereTrendingRepositoryPage(qb, lang, spokenLanguage, period)
+	query, args := qb.GetQuery()
+
+	if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to delete trending repositories snapshot, language: %s, error: %v", lang, err)
+	}
+
+	current := make(map[string]int, len(trendingRepositories))
+
+	for _, trendingRepository := range trendingRepositories {
+		trendingRepository.Unavailable = unavailable[trendingRepository.RepoFullName]
+
+		if err := insertTrendingRepository(ctx, tx, trendingRepository); err != nil {
+			return nil, err
+		}
+
+		current[trendingRepository.RepoFullName] = trendingRepository.Rank
+	}
+
+	changes := DiffRanks(previous, current)
+
+	for i := range changes {
+		changes[i].Type = RankChangeRepository
+		changes[i].Language = dbutils.NewNullString(lang)
+		changes[i].SpokenLanguage = dbutils.NewNullString(spokenLanguage)
+		changes[i].Period = period
+		changes[i].TrendDate = date
+	}
+
+	if err := saveRankChanges(ctx, tx, changes); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit replace trending repositories transaction: %v", err)
+	}
+
+	return changes, nil
 }
 
 func (tr *TrendingRepositoryRepo) Save(ctx context.Context, trendingRepository TrendingRepository) error {
-	query := "INSERT INTO `trending_repositories` (`full_name`, `language`, `rank`, `scraped_at`, `trend_date`) VALUES (?, ?, ?, ?, ?)"
+	return insertTrendingRepository(ctx, tr.db, trendingRepository)
+}
+
+// Insert a trending repository and link it with the repository when it has been fetched from GitHub before.
+func insertTrendingRepository(ctx context.Context, db database.Execer, trendingRepository TrendingRepository) error {
+	query := "INSERT INTO `trending_repositories` (`full_name`, `language`, `rank`, `scraped_at`, `trend_date`, `period`, `spoken_language`, `stars_today`, `stars`, `forks`, `repository_language`, `built_by`, `unavailable`, `repository_id`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, (SELECT `id` FROM `repositories` WHERE `full_name` = ?))"
 
 	scrapeAt := time.Now()
 
@@ -101,7 +243,24 @@ func (tr *TrendingRepositoryRepo) Save(ctx context.Context, trendingRepository T
 		scrapeAt = trendingRepository.ScrapedAt
 	}
 
-	result, err := tr.db.ExecContext(ctx, query, trendingRepository.RepoFullName, trendingRepository.Language, trendingRepository.Rank, scrapeAt.Format(time.DateTime), trendingRepository.TrendDate.Format("2006-01-02"))
+	result, err := db.ExecContext(
+		ctx,
+		query,
+		trendingRepository.RepoFullName,
+		trendingRepository.Language,
+		trendingRepository.Rank,
+		scrapeAt.Format(time.DateTime),
+		trendingRepository.TrendDate.Format("2006-01-02"),
+		PeriodOrDefault(trendingRepository.Period),
+		trendingRepository.SpokenLanguage,
+		trendingRepository.StarsToday,
+		trendingRepository.Stars,
+		trendingRepository.Forks,
+		trendingRepository.RepoLanguage,
+		trendingRepository.BuiltBy,
+		trendingRepository.Unavailable,
+		trendingRepository.RepoFullName,
+	)
 
 	if err != nil {
 		return fmt.Errorf("failed to exec insert trending_repositories query to db language: %v, full name: %s, error: %v", trendingRepository.Language, trendingRepository.RepoFullName, err)
@@ -116,21 +275,12 @@ func (tr *TrendingRepositoryRepo) Save(ctx context.Context, trendingRepository T
 	return nil
 }
 
-// Update the trending repository as well as unlink the relationship between tranding repository and repository as the repository might be changed in the same rank.
-// Then we leave the linking service to re-link the repository properly.
-func (tr *TrendingRepositoryRepo) Update(ctx context.Context, trendingRepository TrendingRepository) error {
-	query := "UPDATE `trending_repositories` SET `full_name` = ?, `rank` = ?, `language` = ?, `scraped_at` = ?, `trend_date` = ?, `repository_id` = ? WHERE `id` = ?"
-
-	result, err := tr.db.ExecContext(ctx, query, trendingRepository.RepoFullName, trendingRepository.Rank, trendingRepository.Language, trendingRepository.ScrapedAt.Format(time.DateTime), trendingRepository.TrendDate.Format("2006-01-02"), nil, trendingRepository.Id)
+// Mark the repository as not found or blocked on GitHub, so it is not requested again by every link.
+func (tr *TrendingRepositoryRepo) MarkRepositoryUnavailable(ctx context.Context, fullName string) error {
+	query := "UPDATE `trending_repositories` SET unavailable = 1 WHERE full_name = ? AND repository_id IS NULL"
 
-	if err != nil {
-		return fmt.Errorf("failed to run trending_repositories update query, trending repository id: %d, error: %v", trendingRepository.Id, err)
-	}
-
-	_, err = result.RowsAffected()
-
-	if err != nil {
-		return fmt.Errorf("trending_repositories update rows affected returns error: %v", err)
+	if _, err := tr.db.ExecContext(ctx, query, fullName); err != nil {
+		return fmt.Errorf("failed to mark repository unavailable, repository: %s, error: %v", fullName, err)
 	}
 
 	return nil
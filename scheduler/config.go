@@ -0,0 +1,134 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+// Tasks that can be scheduled.
+const (
+	TaskScrape = "scrape"
+	TaskLink   = "link"
+	TaskSync   = "sync"
+	TaskSearch = "search"
+)
+
+// Duration is a time.Duration read from a string like "1h30m" in the config file.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"1h30m\": %v", err)
+	}
+
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+type JobConfig struct {
+	Name       string   `json:"name"`
+	Task       string   `json:"task"`
+	Action     string   `json:"action"`
+	Interval   Duration `json:"interval"`
+	Jitter     Duration `json:"jitter"`
+	RunOnStart bool     `json:"run_on_start"`
+	Since      string   `json:"since"`    // the trending period to scrape.
+	End        string   `json:"end"`      // the same as the --end option of the sync command, e.g. -2d.
+	Limit      int      `json:"limit"`    // the same as the --limit option of the sync command.
+	GraphQL    bool     `json:"graphql"`  // the same as the --graphql option of the link and sync commands.
+	Backfill   bool     `json:"backfill"` // the same as the --backfill option of the link command.
+}
+
+type Config struct {
+	ShutdownTimeout Duration    `json:"shutdown_timeout"`
+	Jobs            []JobConfig `json:"jobs"`
+}
+
+func (c JobConfig) validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+
+	if c.Interval <= 0 {
+		return fmt.Errorf("job %s requires a positive interval", c.Name)
+	}
+
+	if c.Jitter < 0 {
+		return fmt.Errorf("job %s has a negative jitter", c.Name)
+	}
+
+	if c.Task == TaskScrape && c.Since != "" && !model.IsValidPeriod(c.Since) {
+		return fmt.Errorf("job %s has an invalid since: %s, expected daily, weekly or monthly", c.Name, c.Since)
+	}
+
+	switch c.Task {
+	case TaskScrape, TaskLink, TaskSync:
+		if c.Action != "repository" && c.Action != "developer" {
+			return fmt.Errorf("job %s has an invalid action: %s, expected repository or developer", c.Name, c.Action)
+		}
+	case TaskSearch:
+		if c.Action != "sync" {
+			return fmt.Errorf("job %s has an invalid action: %s, expected sync", c.Name, c.Action)
+		}
+	default:
+		return fmt.Errorf("job %s has an invalid task: %s", c.Name, c.Task)
+	}
+
+	return nil
+}
+
+func (c Config) Validate() error {
+	if len(c.Jobs) == 0 {
+		return fmt.Errorf("no jobs configured")
+	}
+
+	names := make(map[string]bool, len(c.Jobs))
+
+	for _, job := range c.Jobs {
+		if err := job.validate(); err != nil {
+			return err
+		}
+
+		if names[job.Name] {
+			return fmt.Errorf("duplicate job name: %s", job.Name)
+		}
+
+		names[job.Name] = true
+	}
+
+	return nil
+}
+
+// Load and validate the scheduler config from a JSON file.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return config, fmt.Errorf("failed to read scheduler config: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse scheduler config: %v", err)
+	}
+
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = Duration(time.Minute)
+	}
+
+	return config, config.Validate()
+}
@@ -0,0 +1,90 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := writeConfig(t, `{
+		"jobs": [
+			{"name": "scrape", "task": "scrape", "action": "repository", "since": "weekly", "interval": "1h", "jitter": "5m", "run_on_start": true},
+			{"name": "sync", "task": "sync", "action": "developer", "end": "-2d", "limit": 500, "interval": "30m"}
+		]
+	}`)
+
+	config, err := LoadConfig(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{time.Duration(config.ShutdownTimeout), time.Minute},
+		{len(config.Jobs), 2},
+		{time.Duration(config.Jobs[0].Interval), time.Hour},
+		{time.Duration(config.Jobs[0].Jitter), 5 * time.Minute},
+		{config.Jobs[0].RunOnStart, true},
+		{config.Jobs[0].Since, "weekly"},
+		{config.Jobs[1].End, "-2d"},
+		{config.Jobs[1].Limit, 500},
+		{time.Duration(config.Jobs[1].Jitter), time.Duration(0)},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	configs := []string{
+		`{"jobs": []}`,
+		`{"jobs": [{"name": "scrape", "task": "scrape", "action": "repository", "interval": 60}]}`,
+		`{"jobs": [{"name": "scrape", "task": "scrape", "action": "repository"}]}`,
+		`{"jobs": [{"name": "scrape", "task": "scrape", "action": "topic", "interval": "1h"}]}`,
+		`{"jobs": [{"name": "scrape", "task": "scrape", "action": "repository", "since": "yearly", "interval": "1h"}]}`,
+		`{"jobs": [{"name": "scrape", "task": "crawl", "action": "repository", "interval": "1h"}]}`,
+		`{"jobs": [{"name": "search", "task": "search", "action": "delete", "interval": "1h"}]}`,
+		`{"jobs": [{"task": "link", "action": "repository", "interval": "1h"}]}`,
+		`{"jobs": [{"name": "link", "task": "link", "action": "repository", "interval": "1h", "jitter": "-1m"}]}`,
+		`{"jobs": [
+			{"name": "link", "task": "link", "action": "repository", "interval": "1h"},
+			{"name": "link", "task": "link", "action": "developer", "interval": "1h"}
+		]}`,
+	}
+
+	for _, content := range configs {
+		if _, err := LoadConfig(writeConfig(t, content)); err == nil {
+			t.Errorf("expected an error for config: %s", content)
+		}
+	}
+
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
+
+func TestExampleConfig(t *testing.T) {
+	if _, err := LoadConfig("../scheduler.example.json"); err != nil {
+		t.Errorf("expected the example config to be valid but got: %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package scheduler runs jobs on a fixed interval in a long-running process, so scrape, link and sync can run without cron.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Task func(ctx context.Context) error
+
+type Job struct {
+	Name       string
+	Interval   time.Duration
+	Jitter     time.Duration // a random delay up to Jitter is added to every run to avoid running all jobs at the same time.
+	RunOnStart bool
+	Task       Task
+
+	running atomic.Bool
+}
+
+type Scheduler struct {
+	jobs            []*Job
+	shutdownTimeout time.Duration
+	wg              sync.WaitGroup
+}
+
+// Create a scheduler, running jobs get shutdownTimeout to finish after the scheduler is stopped before they are cancelled.
+func New(shutdownTimeout time.Duration, jobs ...*Job) *Scheduler {
+	return &Scheduler{
+		jobs:            jobs,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// Start the job unless its previous run is still running, it returns false when the run is skipped.
+func (s *Scheduler) start(ctx context.Context, job *Job) bool {
+	if !job.running.CompareAndSwap(false, true) {
+		slog.Warn("skip job as the previous run is still running", slog.String("job", job.Name))
+		return false
+	}
+
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+		defer job.running.Store(false)
+
+		start := time.Now()
+		slog.Info("job started", slog.String("job", job.Name))
+
+		if err := job.Task(ctx); err != nil {
+			slog.Error("job failed", slog.String("job", job.Name), slog.Duration("duration", time.Since(start)), slog.Any("error", err))
+			return
+		}
+
+		slog.Info("job completed", slog.String("job", job.Name), slog.Duration("duration", time.Since(start)))
+	}()
+
+	return true
+}
+
+func (s *Scheduler) schedule(ctx, jobCtx context.Context, job *Job) {
+	if job.RunOnStart {
+		s.start(jobCtx, job)
+	}
+
+	for {
+		timer := time.NewTimer(job.Interval + jitter(job.Jitter))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.start(jobCtx, job)
+		}
+	}
+}
+
+// Run the jobs until ctx is done, then wait for the running jobs to finish.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			return fmt.Errorf("invalid interval of job %s: %s", job.Name, job.Interval)
+		}
+	}
+
+	// Running jobs are not cancelled right away when ctx is done, so they can finish gracefully.
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var loops sync.WaitGroup
+
+	for _, job := range s.jobs {
+		loops.Add(1)
+
+		go func(job *Job) {
+			defer loops.Done()
+			s.schedule(ctx, jobCtx, job)
+		}(job)
+	}
+
+	slog.Info(fmt.Sprintf("scheduler started with %d jobs.", len(s.jobs)))
+
+	<-ctx.Done()
+	loops.Wait()
+
+	slog.Info("scheduler stopping, waiting for running jobs...")
+
+	done := make(chan struct{})
+
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(s.shutdownTimeout):
+		slog.Warn("running jobs did not finish in time, cancelling them...")
+		cancelJobs()
+		<-done
+	}
+
+	slog.Info("scheduler stopped.")
+	return nil
+}
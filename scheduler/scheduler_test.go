@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsJobs(t *testing.T) {
+	var runs atomic.Int32
+
+	job := &Job{
+		Name:       "count",
+		Interval:   10 * time.Millisecond,
+		RunOnStart: true,
+		Task: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+
+	if err := New(time.Second, job).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if runs.Load() < 3 {
+		t.Errorf("expected the job to run at least 3 times but got: %d", runs.Load())
+	}
+}
+
+func TestSchedulerSkipsOverlappingRuns(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+
+	job := &Job{
+		Name:     "slow",
+		Interval: time.Hour,
+		Task: func(ctx context.Context) error {
+			runs.Add(1)
+			<-release
+			return nil
+		},
+	}
+
+	s := New(time.Second, job)
+	ctx := context.Background()
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{s.start(ctx, job), true},
+		{s.start(ctx, job), false},
+		{s.start(ctx, job), false},
+	}
+
+	close(release)
+	s.wg.Wait()
+
+	expcts = append(expcts, struct {
+		actual any
+		want   any
+	}{s.start(ctx, job), true})
+
+	s.wg.Wait()
+
+	expcts = append(expcts, struct {
+		actual any
+		want   any
+	}{runs.Load(), int32(2)})
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestSchedulerWaitsForRunningJobsOnShutdown(t *testing.T) {
+	var completed atomic.Bool
+
+	job := &Job{
+		Name:       "graceful",
+		Interval:   time.Hour,
+		RunOnStart: true,
+		Task: func(ctx context.Context) error {
+			select {
+			case <-time.After(50 * time.Millisecond):
+				completed.Store(true)
+			case <-ctx.Done():
+			}
+
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := New(time.Second, job).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !completed.Load() {
+		t.Error("expected the running job to complete before the scheduler stopped")
+	}
+}
+
+func TestSchedulerCancelsRunningJobsAfterShutdownTimeout(t *testing.T) {
+	var cancelled atomic.Bool
+
+	job := &Job{
+		Name:       "stuck",
+		Interval:   time.Hour,
+		RunOnStart: true,
+		Task: func(ctx context.Context) error {
+			<-ctx.Done()
+			cancelled.Store(true)
+			return ctx.Err()
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := New(20*time.Millisecond, job).Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if !cancelled.Load() {
+		t.Error("expected the running job to be cancelled after the shutdown timeout")
+	}
+}
+
+func TestJitter(t *testing.T) {
+	if jitter(0) != 0 {
+		t.Error("expected no jitter when it is not configured")
+	}
+
+	for range 100 {
+		if d := jitter(time.Minute); d < 0 || d >= time.Minute {
+			t.Fatalf("expected jitter within a minute but got: %s", d)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package archive stores the raw HTML of scraped trending pages so they can be parsed again later.
+//
+// Pages are gzip compressed and stored as <dir>/<type>/<language>/<spoken language>/<period>/<timestamp>.html.gz,
+// "all" stands for the trending page of all languages and "any" for any spoken language.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	allLanguages      = "all"
+	anySpokenLanguage = "any"
+	fileExtension     = ".html.gz"
+	timestampLayout   = "20060102T150405Z"
+)
+
+// Key identifies an archived trending page.
+type Key struct {
+	Type           string
+	Language       string
+	SpokenLanguage string
+	Period         string
+	ScrapedAt      time.Time
+}
+
+// Entry is an archived trending page found in the archive directory.
+type Entry struct {
+	Key
+	Path string
+}
+
+type Archive struct {
+	dir string
+}
+
+func New(dir string) *Archive {
+	return &Archive{
+		dir: dir,
+	}
+}
+
+func encodeSegment(value, empty string) string {
+	if value == "" {
+		return empty
+	}
+
+	return url.PathEscape(strings.ToLower(value))
+}
+
+func decodeSegment(segment, empty string) (string, error) {
+	if segment == empty {
+		return "", nil
+	}
+
+	return url.PathUnescape(segment)
+}
+
+func (a *Archive) path(key Key) string {
+	return filepath.Join(
+		a.dir,
+		encodeSegment(key.Type, ""),
+		encodeSegment(key.Language, allLanguages),
+		encodeSegment(key.SpokenLanguage, anySpokenLanguage),
+		encodeSegment(key.Period, ""),
+		key.ScrapedAt.UTC().Format(timestampLayout)+fileExtension,
+	)
+}
+
+// Write the compressed page to the archive and return the file path.
+func (a *Archive) Write(key Key, body []byte) (string, error) {
+	path := a.path(key)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+
+	if _, err := w.Write(body); err != nil {
+		return "", fmt.Errorf("failed to compress archived page: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress archived page: %v", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write archived page: %v", err)
+	}
+
+	return path, nil
+}
+
+// Read the decompressed page of an archived entry.
+func (a *Archive) Read(entry Entry) ([]byte, error) {
+	f, err := os.Open(entry.Path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archived page: %v", err)
+	}
+
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress archived page %s: %v", entry.Path, err)
+	}
+
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+func (a *Archive) parsePath(path string) (Key, error) {
+	var key Key
+
+	rel, err := filepath.Rel(a.dir, path)
+
+	if err != nil {
+		return key, err
+	}
+
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+
+	// type, language, spoken language, period and the timestamped file.
+	if len(segments) != 5 {
+		return key, fmt.Errorf("unexpected archive path: %s", path)
+	}
+
+	if key.Type, err = decodeSegment(segments[0], ""); err != nil {
+		return key, err
+	}
+
+	if key.Language, err = decodeSegment(segments[1], allLanguages); err != nil {
+		return key, err
+	}
+
+	if key.SpokenLanguage, err = decodeSegment(segments[2], anySpokenLanguage); err != nil {
+		return key, err
+	}
+
+	if key.Period, err = decodeSegment(segments[3], ""); err != nil {
+		return key, err
+	}
+
+	key.ScrapedAt, err = time.Parse(timestampLayout, strings.TrimSuffix(segments[4], fileExtension))
+
+	return key, err
+}
+
+// Find the archived pages of the given type scraped within [from, to), the oldest page comes first.
+// An empty type finds the pages of all types.
+func (a *Archive) Find(scrapeType string, from, to time.Time) ([]Entry, error) {
+	entries := make([]Entry, 0)
+
+	root := a.dir
+
+	if scrapeType != "" {
+		root = filepath.Join(a.dir, encodeSegment(scrapeType, ""))
+	}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !strings.HasSuffix(path, fileExtension) {
+			return nil
+		}
+
+		key, err := a.parsePath(path)
+
+		if err != nil {
+			return fmt.Errorf("failed to parse archive path: %v", err)
+		}
+
+		if key.ScrapedAt.Before(from) || !key.ScrapedAt.Before(to) {
+			return nil
+		}
+
+		entries = append(entries, Entry{Key: key, Path: path})
+
+		return nil
+	})
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return entries, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].ScrapedAt.Before(entries[j].ScrapedAt)
+	})
+
+	return entries, nil
+}
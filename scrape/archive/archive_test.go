@@ -0,0 +1,79 @@
+package archive
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteFindRead(t *testing.T) {
+	archive := New(t.TempDir())
+
+	day := time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC)
+
+	pages := []struct {
+		key  Key
+		body string
+	}{
+		{key: Key{Type: "repository", Language: "c++", SpokenLanguage: "zh", Period: "daily", ScrapedAt: day.Add(time.Hour)}, body: "<html>c++</html>"},
+		{key: Key{Type: "repository", Language: "", Period: "weekly", ScrapedAt: day}, body: "<html>all</html>"},
+		{key: Key{Type: "repository", Language: "c#", Period: "daily", ScrapedAt: day.AddDate(0, 0, 2)}, body: "<html>c#</html>"},
+		{key: Key{Type: "developer", Language: "go", Period: "monthly", ScrapedAt: day}, body: "<html>go</html>"},
+	}
+
+	for _, page := range pages {
+		if _, err := archive.Write(page.key, []byte(page.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := archive.Find("repository", day, day.AddDate(0, 0, 1))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expect 2 archived repository pages, actual got: %d", len(entries))
+	}
+
+	expcts := []struct {
+		key  Key
+		body string
+	}{pages[1], pages[0]}
+
+	for i, want := range expcts {
+		entry := entries[i]
+
+		if entry.Type != want.key.Type || entry.Language != want.key.Language || entry.SpokenLanguage != want.key.SpokenLanguage || entry.Period != want.key.Period || !entry.ScrapedAt.Equal(want.key.ScrapedAt) {
+			t.Errorf("expect key: %+v, actual got: %+v", want.key, entry.Key)
+		}
+
+		body, err := archive.Read(entry)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(body) != want.body {
+			t.Errorf("expect body: %s, actual got: %s", want.body, body)
+		}
+	}
+
+	entries, err = archive.Find("", day, day.AddDate(0, 0, 3))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 4 {
+		t.Errorf("expect 4 archived pages of all types, actual got: %d", len(entries))
+	}
+}
+
+func TestFindMissingDirectory(t *testing.T) {
+	entries, err := New(t.TempDir()+"/missing").Find("developer", time.Time{}, time.Now())
+
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expect no entries and no error for a missing archive, actual got: %v, %v", entries, err)
+	}
+}
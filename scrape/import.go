@@ -0,0 +1,347 @@
+package scrape
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"log/slog"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
+)
+
+var (
+	// Matches 2021-03-04, 2021_03_04 or 20210304 in file names.
+	fileDatePattern = regexp.MustCompile(`(\d{4})[-_]?(\d{2})[-_]?(\d{2})`)
+	// Matches the timestamp of web archive urls, e.g. https://web.archive.org/web/20210304120000/https://github.com/trending.
+	webArchiveTimestampPattern = regexp.MustCompile(`/web/(\d{14})`)
+)
+
+// A saved trending page to import, the body is read again when it is imported to keep memory usage low.
+type importPage struct {
+	key  archive.Key
+	path string
+}
+
+func isImportFile(path string) bool {
+	name := strings.ToLower(path)
+
+	return strings.HasSuffix(name, ".html") || strings.HasSuffix(name, ".htm") || strings.HasSuffix(name, ".html.gz")
+}
+
+func readImportFile(path string) ([]byte, error) {
+	body, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return body, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(body))
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// Find the url of a saved page from the og:url meta tag or the canonical link.
+func pageUrl(body []byte) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+
+	if err != nil {
+		return ""
+	}
+
+	if content, ok := doc.Find(`meta[property="og:url"]`).Attr("content"); ok && content != "" {
+		return content
+	}
+
+	href, _ := doc.Find(`link[rel="canonical"]`).Attr("href")
+
+	return href
+}
+
+// Infer the type, language, spoken language and period from a trending page url.
+// Urls of web archives are supported as long as they contain the GitHub trending url.
+func inferFromUrl(key *archive.Key, pageUrl string) bool {
+	index := strings.Index(pageUrl, "github.com/trending")
+
+	if index < 0 {
+		return false
+	}
+
+	u, err := url.Parse("https://" + pageUrl[index:])
+
+	if err != nil {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(u.EscapedPath(), "/trending"), "/"), "/")
+
+	key.Type = repository
+
+	if segments[0] == "developers" {
+		key.Type = developer
+		segments = segments[1:]
+	}
+
+	if len(segments) > 0 && segments[0] != "" {
+		language, err := url.PathUnescape(segments[0])
+
+		if err != nil {
+			return false
+		}
+
+		key.Language = model.LanguageSlug(language)
+	}
+
+	if since := u.Query().Get("since"); model.IsValidPeriod(since) {
+		key.Period = since
+	}
+
+	key.SpokenLanguage = strings.ToLower(u.Query().Get("spoken_language_code"))
+
+	return true
+}
+
+// The language of a file name token, it is only accepted when it is a known language slug.
+func knownLanguage(token string, languages map[string]bool) string {
+	language, err := url.PathUnescape(token)
+
+	if err != nil {
+		return ""
+	}
+
+	if language = model.LanguageSlug(language); languages[language] {
+		return language
+	}
+
+	return ""
+}
+
+// Infer the type, language and period from a file name like trending-developers-go-weekly-2021-03-04.html.
+// Unknown tokens are ignored, consecutive tokens are joined back with - to match slugs like objective-c.
+func inferFromFileName(key *archive.Key, name string, languages map[string]bool) {
+	name = fileDatePattern.ReplaceAllString(name, "")
+
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// Words and extensions of the usual file names, the .html extension is not the html language.
+		if token == "trending" || token == "html" || token == "htm" || token == "gz" || token == "repositories" || token == "repository" {
+			continue
+		}
+
+		if token == "developers" || token == "developer" {
+			key.Type = developer
+			continue
+		}
+
+		if model.IsValidPeriod(token) {
+			key.Period = token
+			continue
+		}
+
+		if key.Language != "" {
+			continue
+		}
+
+		for j := len(tokens); j > i; j-- {
+			if language := knownLanguage(strings.Join(tokens[i:j], "-"), languages); language != "" {
+				key.Language = language
+				i = j - 1
+				break
+			}
+		}
+	}
+}
+
+// Infer the date a page was saved from the file name, the web archive timestamp of the page url or the file modification time.
+func inferDate(name, pageUrl string, modTime time.Time) time.Time {
+	if match := fileDatePattern.FindStringSubmatch(name); match != nil {
+		if date, err := time.ParseInLocation("20060102", match[1]+match[2]+match[3], time.Local); err == nil {
+			return date
+		}
+	}
+
+	if match := webArchiveTimestampPattern.FindStringSubmatch(pageUrl); match != nil {
+		if date, err := time.Parse("20060102150405", match[1]); err == nil {
+			return date
+		}
+	}
+
+	return modTime
+}
+
+// Infer what a saved trending page is, the url of the page takes precedence over the file name.
+func inferImportKey(name string, body []byte, modTime time.Time, languages map[string]bool) archive.Key {
+	key := archive.Key{Type: repository, Period: model.DailyPeriod}
+	pageUrl := pageUrl(body)
+
+	if !inferFromUrl(&key, pageUrl) {
+		inferFromFileName(&key, name, languages)
+	}
+
+	key.ScrapedAt = inferDate(name, pageUrl, modTime)
+
+	return key
+}
+
+func findImportPages(dir string, languages map[string]bool) ([]importPage, error) {
+	pages := make([]importPage, 0)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || !isImportFile(path) {
+			return nil
+		}
+
+		info, err := d.Info()
+
+		if err != nil {
+			return err
+		}
+
+		body, err := readImportFile(path)
+
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", path, err)
+		}
+
+		pages = append(pages, importPage{
+			key:  inferImportKey(d.Name(), body, info.ModTime(), languages),
+			path: path,
+		})
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(pages, func(i, j int) bool {
+		return pages[i].key.ScrapedAt.Before(pages[j].key.ScrapedAt)
+	})
+
+	return pages, nil
+}
+
+// The language slugs that a file name may contain, they are the tracked languages including the disabled ones.
+func (s *ScrapeHandler) importLanguages(ctx context.Context) (map[string]bool, error) {
+	tracked, err := s.repositories.LanguageRepo.FindAll(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find languages: %v", err)
+	}
+
+	languages := make(map[string]bool, len(tracked))
+
+	for _, language := range tracked {
+		languages[model.LanguageSlug(language.Slug)] = true
+	}
+
+	return languages, nil
+}
+
+// Import saved GitHub trending pages from dir into the trending tables, then link the repositories and developers with GitHub.
+func (s *ScrapeHandler) Import(ctx context.Context, dir string) error {
+	languages, err := s.importLanguages(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	pages, err := findImportPages(dir, languages)
+
+	if err != nil {
+		return fmt.Errorf("failed to find trending pages to import: %v", err)
+	}
+
+	slog.Info(fmt.Sprintf("importing %d trending pages...", len(pages)))
+
+	scrapers := make(map[string]Scraper)
+	imported := make(map[string]int)
+	var failed int
+
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		scraper, ok := scrapers[page.key.Type]
+
+		if !ok {
+			scraper, err = s.newScraper(page.key.Type)
+
+			if err != nil {
+				return err
+			}
+
+			scrapers[page.key.Type] = scraper
+		}
+
+		body, err := readImportFile(page.path)
+
+		if err == nil {
+			err = scraper.Reparse(ctx, page.key, body)
+		}
+
+		if err != nil {
+			failed++
+			slog.Error("failed to import trending page", slog.String("path", page.path), slog.Any("error", err))
+			continue
+		}
+
+		imported[page.key.Type]++
+	}
+
+	if imported[repository] > 0 {
+		slog.Info("linking repositories...")
+
+		if err := s.githubFetcher.FetchRepositories(ctx); err != nil {
+			return fmt.Errorf("failed to fetch and link imported repositories: %v", err)
+		}
+	}
+
+	if imported[developer] > 0 {
+		slog.Info("linking developers...")
+
+		if err := s.githubFetcher.FetchDevelopers(ctx); err != nil {
+			return fmt.Errorf("failed to fetch and link imported developers: %v", err)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to import %d of %d trending pages", failed, len(pages))
+	}
+
+	slog.Info("import completed.")
+	return nil
+}
@@ -0,0 +1,120 @@
+package scrape
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func page(ogUrl string) []byte {
+	return []byte(`<html><head><meta property="og:url" content="` + ogUrl + `"></head><body></body></html>`)
+}
+
+var importLanguages = map[string]bool{"go": true, "rust": true, "c#": true, "objective-c": true, "html": true}
+
+func TestInferImportKey(t *testing.T) {
+	modTime := time.Date(2020, 2, 3, 10, 0, 0, 0, time.Local)
+
+	expcts := []struct {
+		name, body                                   string
+		scrapeType, language, spokenLanguage, period string
+		date                                         string
+	}{
+		{
+			name:       "2021-03-04.html",
+			body:       string(page("https://github.com/trending/developers/go?since=weekly")),
+			scrapeType: developer, language: "go", period: "weekly", date: "2021-03-04",
+		},
+		{
+			name:       "page.html",
+			body:       string(page("https://github.com/trending/c%2B%2B?since=monthly&spoken_language_code=zh")),
+			scrapeType: repository, language: "c++", spokenLanguage: "zh", period: "monthly", date: "2020-02-03",
+		},
+		{
+			name:       "index.html",
+			body:       `<html><head><link rel="canonical" href="https://web.archive.org/web/20190105120000/https://github.com/trending"></head></html>`,
+			scrapeType: repository, period: "daily", date: "2019-01-05",
+		},
+		{
+			name:       "trending-developers-rust-monthly-20180708.html",
+			body:       "<html></html>",
+			scrapeType: developer, language: "rust", period: "monthly", date: "2018-07-08",
+		},
+		{
+			name:       "trending_c%23_2017_01_02.html.gz",
+			body:       "<html></html>",
+			scrapeType: repository, language: "c#", period: "daily", date: "2017-01-02",
+		},
+		{
+			name:       "github-trending-2021-03-04.html",
+			body:       "<html></html>",
+			scrapeType: repository, period: "daily", date: "2021-03-04",
+		},
+		{
+			name:       "page.html",
+			body:       "<html></html>",
+			scrapeType: repository, period: "daily", date: "2020-02-03",
+		},
+		{
+			name:       "trending-objective-c-weekly-2016-01-01.html",
+			body:       "<html></html>",
+			scrapeType: repository, language: "objective-c", period: "weekly", date: "2016-01-01",
+		},
+	}
+
+	for _, test := range expcts {
+		key := inferImportKey(test.name, []byte(test.body), modTime, importLanguages)
+
+		if key.Type != test.scrapeType || key.Language != test.language || key.SpokenLanguage != test.spokenLanguage || key.Period != test.period {
+			t.Errorf("%s: expect %s, %q, %q, %s, actual got: %+v", test.name, test.scrapeType, test.language, test.spokenLanguage, test.period, key)
+		}
+
+		if date := key.ScrapedAt.Local().Format(time.DateOnly); date != test.date {
+			t.Errorf("%s: expect date %s, actual got: %s", test.name, test.date, date)
+		}
+	}
+}
+
+func TestFindImportPages(t *testing.T) {
+	dir := t.TempDir()
+
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	w.Write(page("https://github.com/trending/go?since=daily"))
+	w.Close()
+
+	files := map[string][]byte{
+		"2022-05-02.html":           page("https://github.com/trending/developers"),
+		"nested/2022-05-01.html.gz": compressed.Bytes(),
+		"notes.txt":                 []byte("not a trending page"),
+	}
+
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, body, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pages, err := findImportPages(dir, importLanguages)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pages) != 2 {
+		t.Fatalf("expect 2 trending pages, actual got: %d", len(pages))
+	}
+
+	if pages[0].key.Type != repository || pages[0].key.Language != "go" || pages[1].key.Type != developer {
+		t.Errorf("expect the go repository page before the developer page, actual got: %+v, %+v", pages[0].key, pages[1].key)
+	}
+}
@@ -5,14 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"log/slog"
 
 	"github.com/liweiyi88/trendshift-backend/config"
 	"github.com/liweiyi88/trendshift-backend/github"
 	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
 	"github.com/liweiyi88/trendshift-backend/scrape/scraper"
 	"github.com/liweiyi88/trendshift-backend/search"
+	"github.com/liweiyi88/trendshift-backend/tagging"
 	"github.com/liweiyi88/trendshift-backend/trending"
 	"golang.org/x/sync/errgroup"
 )
@@ -22,8 +27,12 @@ const (
 	developer  = "developer"
 )
 
+// Avoid sending too many requests to GitHub at the same time as every language is scraped for every spoken language.
+const maxConcurrentScrapes = 15
+
 type Scraper interface {
-	Scrape(ctx context.Context, language string) error
+	Scrape(ctx context.Context, language string, opts ...any) error
+	Reparse(ctx context.Context, key archive.Key, body []byte) error
 	GetType() string
 }
 
@@ -33,7 +42,7 @@ type ScrapeHandler struct {
 	githubFetcher *trending.GithubFetcher
 }
 
-func NewScrapeHandler(repositories *global.Repositories, search search.Search, gh *github.Client) *ScrapeHandler {
+func NewScrapeHandler(repositories *global.Repositories, search search.Search, gh github.Details) *ScrapeHandler {
 	return &ScrapeHandler{
 		repositories:  repositories,
 		search:        search,
@@ -41,21 +50,63 @@ func NewScrapeHandler(repositories *global.Repositories, search search.Search, g
 	}
 }
 
-func (s *ScrapeHandler) Handle(ctx context.Context, action string) error {
+// Tag the repositories linked after scraping from their GitHub topics.
+func (s *ScrapeHandler) WithTagger(tagger *tagging.Tagger) *ScrapeHandler {
+	s.githubFetcher.WithTagger(tagger)
+	return s
+}
+
+// Fetch the READMEs of the repositories linked after scraping.
+func (s *ScrapeHandler) WithReadmes(readmes *github.ReadmeFetcher) *ScrapeHandler {
+	s.githubFetcher.WithReadmes(readmes)
+	return s
+}
+
+func (s *ScrapeHandler) Handle(ctx context.Context, action string, opts ...any) error {
 	switch action {
 	case repository:
-		return s.saveTrendingRepositories(ctx)
+		return s.saveTrendingRepositories(ctx, opts...)
 	case developer:
-		return s.saveTrendingDevelopers(ctx)
+		return s.saveTrendingDevelopers(ctx, opts...)
 	default:
 		return errors.New("invalid search action")
 	}
 }
 
-func (s *ScrapeHandler) saveTrendingRepositories(ctx context.Context) error {
-	scraper := scraper.NewTrendingRepositoryScraper(s.repositories.TrendingRepositoryRepo)
+// Archive the fetched trending pages when an archive directory is configured.
+func scraperOptions() []scraper.Option {
+	if config.ScrapeArchiveDir == "" {
+		return nil
+	}
 
-	err := save(scraper, ctx)
+	return []scraper.Option{scraper.WithArchive(archive.New(config.ScrapeArchiveDir))}
+}
+
+func (s *ScrapeHandler) newScraper(scrapeType string) (Scraper, error) {
+	switch scrapeType {
+	case repository:
+		return scraper.NewTrendingRepositoryScraper(s.repositories.TrendingRepositoryRepo, s.repositories.ScrapeRunRepo, scraperOptions()...), nil
+	case developer:
+		return scraper.NewTrendingDeveloperScraper(s.repositories.TrendingDeveloperRepo, s.repositories.ScrapeRunRepo, scraperOptions()...), nil
+	default:
+		return nil, fmt.Errorf("invalid scrape type: %s", scrapeType)
+	}
+}
+
+func (s *ScrapeHandler) saveTrendingRepositories(ctx context.Context, opts ...any) error {
+	scraper, err := s.newScraper(repository)
+
+	if err != nil {
+		return err
+	}
+
+	languages, err := s.languagesToScrape(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	err = save(scraper, ctx, languages, config.SpokenLanguageToScrape, opts...)
 
 	if err != nil {
 		return err
@@ -73,10 +124,21 @@ func (s *ScrapeHandler) saveTrendingRepositories(ctx context.Context) error {
 	return nil
 }
 
-func (s *ScrapeHandler) saveTrendingDevelopers(ctx context.Context) error {
-	scraper := scraper.NewTrendingDeveloperScraper(s.repositories.TrendingDeveloperRepo)
+func (s *ScrapeHandler) saveTrendingDevelopers(ctx context.Context, opts ...any) error {
+	scraper, err := s.newScraper(developer)
+
+	if err != nil {
+		return err
+	}
+
+	languages, err := s.languagesToScrape(ctx)
+
+	if err != nil {
+		return err
+	}
 
-	err := save(scraper, ctx)
+	// The trending developers page can not be filtered by spoken language.
+	err = save(scraper, ctx, languages, []string{""}, opts...)
 
 	if err != nil {
 		return err
@@ -94,17 +156,39 @@ func (s *ScrapeHandler) saveTrendingDevelopers(ctx context.Context) error {
 	return nil
 }
 
+// Get the slugs of the enabled languages, the empty string means the trending page of all languages.
+func (s *ScrapeHandler) languagesToScrape(ctx context.Context) ([]string, error) {
+	languages, err := s.repositories.LanguageRepo.FindEnabled(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to find enabled languages: %v", err)
+	}
+
+	slugs := []string{""}
+
+	for _, language := range languages {
+		slugs = append(slugs, language.Slug)
+	}
+
+	return slugs, nil
+}
+
 // Scrape repositories or developers rank from GitHub Trending page and save them in DB.
-func save(scraper Scraper, ctx context.Context) error {
+func save(scraper Scraper, ctx context.Context, languages, spokenLanguages []string, opts ...any) error {
 	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentScrapes)
+
+	period := model.PeriodOrDefault(opt.ExtractOptions(opts...).Period)
 
-	slog.Info(fmt.Sprintf("Scraping %s for languages: %s...", scraper.GetType(), strings.Join(config.LanguageToScrape, ",")))
+	slog.Info(fmt.Sprintf("Scraping %s %s for languages: %s...", period, scraper.GetType(), strings.Join(languages, ",")))
 
-	for _, language := range config.LanguageToScrape {
-		language := language
-		group.Go(func() error {
-			return scraper.Scrape(groupCtx, language)
-		})
+	for _, language := range languages {
+		for _, spokenLanguage := range spokenLanguages {
+			language, spokenLanguage := language, spokenLanguage
+			group.Go(func() error {
+				return scraper.Scrape(groupCtx, language, opt.Period(period), opt.SpokenLanguage(spokenLanguage))
+			})
+		}
 	}
 
 	if err := group.Wait(); err != nil {
@@ -113,3 +197,60 @@ func save(scraper Scraper, ctx context.Context) error {
 
 	return nil
 }
+
+// Rebuild the trending rows from the pages archived in dir within [from, to) without visiting GitHub.
+// An empty scrape type re-parses both repository and developer pages.
+func (s *ScrapeHandler) Reparse(ctx context.Context, scrapeType, dir string, from, to time.Time) error {
+	if scrapeType != "" && scrapeType != repository && scrapeType != developer {
+		return fmt.Errorf("invalid scrape type: %s", scrapeType)
+	}
+
+	pages := archive.New(dir)
+	entries, err := pages.Find(scrapeType, from, to)
+
+	if err != nil {
+		return fmt.Errorf("failed to find archived pages: %v", err)
+	}
+
+	slog.Info(fmt.Sprintf("re-parsing %d archived pages...", len(entries)))
+
+	scrapers := make(map[string]Scraper)
+	var failed int
+
+	// Re-parse in the scraped order, so the last page of a day wins as it did when it was scraped.
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		scraper, ok := scrapers[entry.Type]
+
+		if !ok {
+			scraper, err = s.newScraper(entry.Type)
+
+			if err != nil {
+				return err
+			}
+
+			scrapers[entry.Type] = scraper
+		}
+
+		body, err := pages.Read(entry)
+
+		if err == nil {
+			err = scraper.Reparse(ctx, entry.Key, body)
+		}
+
+		if err != nil {
+			failed++
+			slog.Error("failed to re-parse archived page", slog.String("path", entry.Path), slog.Any("error", err))
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to re-parse %d of %d archived pages", failed, len(entries))
+	}
+
+	slog.Info("re-parse completed.")
+	return nil
+}
@@ -4,128 +4,168 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
 	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
 type TrendingDeveloperScraper struct {
 	url, path             string
+	options               options
 	trendingDeveloperRepo *model.TrendingDeveloperRepo
+	scrapeRunRepo         *model.ScrapeRunRepo
 }
 
-func NewTrendingDeveloperScraper(trendingDeveloperRepo *model.TrendingDeveloperRepo) *TrendingDeveloperScraper {
+func NewTrendingDeveloperScraper(trendingDeveloperRepo *model.TrendingDeveloperRepo, scrapeRunRepo *model.ScrapeRunRepo, opts ...Option) *TrendingDeveloperScraper {
+	options := newOptions(opts...)
+
 	return &TrendingDeveloperScraper{
-		url:                   ghTrendScrapeBaseURL + "/developers",
-		path:                  ghTrendScrapePath,
+		url:                   options.baseURL + "/developers",
+		path:                  ghTrendRowPath,
+		options:               options,
 		trendingDeveloperRepo: trendingDeveloperRepo,
+		scrapeRunRepo:         scrapeRunRepo,
 	}
 }
 
 // Get the trending developer page for scraping.
-func (ds *TrendingDeveloperScraper) getTrendPageUrl(language string) string {
+func (ds *TrendingDeveloperScraper) getTrendPageUrl(language, period string) string {
 	language = strings.TrimSpace(language)
 
 	if language != "" {
-		return fmt.Sprintf("%s/%s?since=daily", ds.url, url.QueryEscape(language))
+		return fmt.Sprintf("%s/%s?since=%s", ds.url, url.QueryEscape(language), period)
 	}
 
-	return ds.url
+	return fmt.Sprintf("%s?since=%s", ds.url, period)
 }
 
-// Scrape the trending developer data from GitHub
-func (ds *TrendingDeveloperScraper) scrape(language string) []string {
-	c := colly.NewCollector()
+// Parse a single trending developer row, it returns false if the row does not contain a developer link.
+func parseDeveloperRow(e *colly.HTMLElement) (model.TrendingDeveloper, bool) {
+	var developer model.TrendingDeveloper
 
-	developers := make([]string, 0)
+	link := strings.TrimLeft(e.ChildAttr(ghTrendLinkPath, "href"), "/")
 
-	c.OnHTML(ds.path, func(e *colly.HTMLElement) {
-		link := e.Attr("href")
+	if link == "" {
+		return developer, false
+	}
 
-		if strings.HasPrefix(link, "/") {
-			link = strings.TrimLeft(link, "/")
+	developer.Username = link
+
+	if popularRepo := strings.Trim(e.ChildAttr(ghTrendPopularRepoPath, "href"), "/"); popularRepo != "" {
+		developer.PopularRepoFullName = dbutils.NullString{
+			NullString: sql.NullString{String: popularRepo,
+				Valid: true,
+			},
 		}
+	}
 
-		developers = append(developers, link)
-	})
+	return developer, true
+}
 
-	c.OnRequest(func(r *colly.Request) {
-		// fmt.Printf("scraping: %s \n", r.URL.String())
-	})
+// Parse the trending developers of a fetched or archived trending developer page.
+func (ds *TrendingDeveloperScraper) parse(body []byte) ([]model.TrendingDeveloper, pageStats) {
+	developers := make([]model.TrendingDeveloper, 0)
 
-	c.Visit(ds.getTrendPageUrl(language))
+	stats := parsePage(body, ds.path, func(e *colly.HTMLElement) bool {
+		developer, ok := parseDeveloperRow(e)
 
-	return developers
+		if ok {
+			developers = append(developers, developer)
+		}
+
+		return ok
+	})
+
+	return developers, stats
 }
 
-// Save trending developers to DB.
-func (ds *TrendingDeveloperScraper) saveDevelopers(ctx context.Context, language string, developers []string) error {
-	now := time.Now()
+// Scrape the trending developer data from GitHub
+func (ds *TrendingDeveloperScraper) scrape(language, period string) ([]model.TrendingDeveloper, pageStats) {
+	key := archive.Key{Type: ds.GetType(), Language: language, Period: period}
 
-	rankedTrendingDevelopers, err := ds.trendingDeveloperRepo.FindRankedTrendingDevelopersByDate(ctx, now, language)
+	body, err := fetchPage(ds.options, ds.getTrendPageUrl(language, period), key)
 
 	if err != nil {
-		return fmt.Errorf("failed to retrieve ranked trending developers: %v", err)
+		return make([]model.TrendingDeveloper, 0), pageStats{err: err}
 	}
 
+	return ds.parse(body)
+}
+
+// Replace the snapshot of the trending developer page on the day of scrapedAt with the scraped developers and log the rank changes.
+func (ds *TrendingDeveloperScraper) saveDevelopers(ctx context.Context, scrapedAt time.Time, language, period string, developers []model.TrendingDeveloper) error {
+	trendingDevelopers := make([]model.TrendingDeveloper, 0, len(developers))
+
 	for index, developer := range developers {
-		rank := index + 1
+		trendingDeveloper := developer
+		trendingDeveloper.ScrapedAt = scrapedAt
+		trendingDeveloper.TrendDate = scrapedAt
+		trendingDeveloper.Rank = index + 1
+		trendingDeveloper.Period = period
+		trendingDeveloper.Language = dbutils.NewNullString(model.LanguageSlug(language))
+
+		trendingDevelopers = append(trendingDevelopers, trendingDeveloper)
+	}
 
-		trendingDeveloper, ok := rankedTrendingDevelopers[rank]
+	changes, err := ds.trendingDeveloperRepo.ReplaceSnapshot(ctx, scrapedAt, language, trendingDevelopers, opt.Period(period))
 
-		if ok {
-			// if trending developer exist, do update.
-			trendingDeveloper.Username = developer
-			trendingDeveloper.ScrapedAt, trendingDeveloper.TrendDate = now, now
-
-			ds.trendingDeveloperRepo.Update(ctx, trendingDeveloper)
-		} else {
-			// trending developer does not exist, do insert.
-			trendingDeveloper := model.TrendingDeveloper{
-				Username:  developer,
-				ScrapedAt: now,
-				TrendDate: now,
-				Rank:      rank,
-			}
-
-			if language != "" {
-				trendingDeveloper.Language = dbutils.NullString{
-					NullString: sql.NullString{String: strings.ToLower(language),
-						Valid: true,
-					},
-				}
-			} else {
-				trendingDeveloper.Language = dbutils.NullString{
-					NullString: sql.NullString{
-						String: "",
-						Valid:  false,
-					}}
-			}
-
-			err = ds.trendingDeveloperRepo.Save(ctx, trendingDeveloper)
-
-			if err != nil {
-				return fmt.Errorf("failed to save trending developer: %s to db: %v", trendingDeveloper.Username, err)
-			}
-		}
+	if err != nil {
+		return fmt.Errorf("failed to save trending developers, language: %s, period: %s, error: %v", language, period, err)
 	}
 
+	jobrun.Count(ctx).Inserted(len(trendingDevelopers))
+	logRankChanges(ds.GetType(), language, "", period, changes)
+
 	return nil
 }
 
 // Scrape and save trending developers to DB.
-func (ds *TrendingDeveloperScraper) Scrape(ctx context.Context, language string) error {
-	developers := ds.scrape(language)
+func (ds *TrendingDeveloperScraper) Scrape(ctx context.Context, language string, opts ...any) error {
+	period := model.PeriodOrDefault(opt.ExtractOptions(opts...).Period)
+	developers, stats := ds.scrape(language, period)
+	jobrun.Count(ctx).Scraped(len(developers))
+
+	if err := ds.scrapeRunRepo.Save(ctx, stats.toRun(ds.GetType(), language, "", period)); err != nil {
+		slog.Error("failed to save scrape run", slog.Any("error", err))
+	}
+
+	if err := stats.check(); err != nil {
+		jobrun.Count(ctx).Failed(1)
+		return fmt.Errorf("could not scrape trending developers for language: %s, period: %s, error: %v", language, period, err)
+	}
+
+	if len(developers) == 0 {
+		slog.Info("no trending developer data.", slog.Any("language", language), slog.Any("period", period))
+		return nil
+	}
+
+	return ds.saveDevelopers(ctx, time.Now(), language, period, developers)
+}
+
+// Rebuild the trending developers of an archived page without visiting GitHub.
+func (ds *TrendingDeveloperScraper) Reparse(ctx context.Context, key archive.Key, body []byte) error {
+	period := model.PeriodOrDefault(key.Period)
+	developers, stats := ds.parse(body)
+	jobrun.Count(ctx).Scraped(len(developers))
+
+	if err := stats.check(); err != nil {
+		jobrun.Count(ctx).Failed(1)
+		return fmt.Errorf("could not parse archived trending developers for language: %s, period: %s, error: %v", key.Language, period, err)
+	}
 
 	if len(developers) == 0 {
-		return fmt.Errorf("could not scrape any trending developer data for language: %s ", language)
+		return nil
 	}
 
-	return ds.saveDevelopers(ctx, language, developers)
+	return ds.saveDevelopers(ctx, key.ScrapedAt.Local(), key.Language, period, developers)
 }
 
 // Get the scraper type.
@@ -1,19 +1,16 @@
 package scraper
 
 import (
-	"context"
-	"fmt"
 	"testing"
 
-	"github.com/liweiyi88/trendshift-backend/config"
 	"github.com/liweiyi88/trendshift-backend/model"
-	"golang.org/x/sync/errgroup"
 )
 
 func TestGetTrendPageUrl(t *testing.T) {
-	scraper := NewTrendingDeveloperScraper(&model.TrendingDeveloperRepo{})
+	scraper := NewTrendingDeveloperScraper(&model.TrendingDeveloperRepo{}, &model.ScrapeRunRepo{})
 
-	all, golang, php := scraper.getTrendPageUrl(""), scraper.getTrendPageUrl("Go"), scraper.getTrendPageUrl("PHP")
+	all, golang, php := scraper.getTrendPageUrl("", "daily"), scraper.getTrendPageUrl("Go", "weekly"), scraper.getTrendPageUrl("PHP", "monthly")
+	cpp := scraper.getTrendPageUrl("c++", "daily")
 
 	expcts := []struct {
 		actual any
@@ -21,15 +18,19 @@ func TestGetTrendPageUrl(t *testing.T) {
 	}{
 		{
 			actual: all,
-			want:   "https://github.com/trending/developers",
+			want:   "https://github.com/trending/developers?since=daily",
 		},
 		{
 			actual: golang,
-			want:   "https://github.com/trending/developers/Go?since=daily",
+			want:   "https://github.com/trending/developers/Go?since=weekly",
 		},
 		{
 			actual: php,
-			want:   "https://github.com/trending/developers/PHP?since=daily",
+			want:   "https://github.com/trending/developers/PHP?since=monthly",
+		},
+		{
+			actual: cpp,
+			want:   "https://github.com/trending/developers/c%2B%2B?since=daily",
 		},
 	}
 
@@ -38,23 +39,55 @@ func TestGetTrendPageUrl(t *testing.T) {
 			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
 		}
 	}
+}
+
+func TestScrapeDevelopersFixture(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingDeveloperScraper(&model.TrendingDeveloperRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"))
+
+	developers, _ := scraper.scrape("Go", "weekly")
+
+	expcts := []struct {
+		username    string
+		popularRepo string
+	}{
+		{username: "jmorganca", popularRepo: "ollama/ollama"},
+		{username: "liweiyi88"},
+		{username: "mxyng", popularRepo: "mxyng/discollama"},
+	}
 
-	group, _ := errgroup.WithContext(context.Background())
+	if len(developers) != len(expcts) {
+		t.Fatalf("expect %d trending developers, actual got: %d", len(expcts), len(developers))
+	}
+
+	for i, want := range expcts {
+		developer := developers[i]
+
+		if developer.Username != want.username {
+			t.Errorf("expect developer %d to be %s, actual got: %s", i+1, want.username, developer.Username)
+		}
+
+		if developer.PopularRepoFullName.Valid != (want.popularRepo != "") || developer.PopularRepoFullName.String != want.popularRepo {
+			t.Errorf("expect popular repository of %s to be %q, actual got: %q", want.username, want.popularRepo, developer.PopularRepoFullName.String)
+		}
+	}
+
+	if requests := server.requested(); len(requests) != 1 || requests[0] != "/trending/developers/Go?since=weekly" {
+		t.Errorf("expect a single request to /trending/developers/Go?since=weekly, actual got: %v", requests)
+	}
+}
 
-	for _, language := range config.LanguageToScrape {
-		language := language
-		group.Go(func() error {
-			developers := scraper.scrape(language)
+func TestScrapeDevelopersFixtureEmptyPage(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingDeveloperScraper(&model.TrendingDeveloperRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"))
 
-			if len(developers) == 0 {
-				return fmt.Errorf("could not scrape trending developers from GitHub, language: %s", language)
-			}
+	developers, stats := scraper.scrape("zig", "daily")
 
-			return nil
-		})
+	if len(developers) != 0 {
+		t.Errorf("expect no trending developers for an empty page, actual got: %d", len(developers))
 	}
 
-	if err := group.Wait(); err != nil {
-		t.Error(err)
+	if status := stats.status(); status != model.ScrapeRunEmpty {
+		t.Errorf("expect scrape status to be %s, actual got: %s", model.ScrapeRunEmpty, status)
 	}
 }
@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
+)
+
+// GitHub shows a blank slate instead of rows when there is no trending data for the page.
+const ghTrendBlankPath = ".blankslate"
+
+// What a trending page looked like when it was scraped, it is used to tell a page without trending data from a layout change.
+type pageStats struct {
+	rows   int
+	parsed int
+	blank  bool
+	err    error
+}
+
+func (p pageStats) status() string {
+	switch {
+	case p.err != nil:
+		return model.ScrapeRunFailed
+	case p.rows == 0 && p.blank:
+		return model.ScrapeRunEmpty
+	case p.rows == 0 || p.parsed < p.rows:
+		return model.ScrapeRunLayoutChanged
+	default:
+		return model.ScrapeRunOk
+	}
+}
+
+// Check whether the selectors still match the page, it returns nil for pages with rows or without trending data.
+func (p pageStats) check() error {
+	switch p.status() {
+	case model.ScrapeRunFailed:
+		return fmt.Errorf("failed to visit trending page: %v", p.err)
+	case model.ScrapeRunLayoutChanged:
+		if p.rows == 0 {
+			return errors.New("trending page layout has changed, neither trending rows nor the blank slate were found")
+		}
+
+		return fmt.Errorf("trending page layout has changed, %d rows were found but only %d could be parsed", p.rows, p.parsed)
+	default:
+		return nil
+	}
+}
+
+func (p pageStats) toRun(scrapeType, language, spokenLanguage, period string) model.ScrapeRun {
+	run := model.ScrapeRun{
+		Type:           scrapeType,
+		Language:       strings.ToLower(strings.TrimSpace(language)),
+		SpokenLanguage: spokenLanguage,
+		Period:         period,
+		Rows:           p.rows,
+		Parsed:         p.parsed,
+		Status:         p.status(),
+	}
+
+	if err := p.check(); err != nil {
+		run.Error = dbutils.NullString{
+			NullString: sql.NullString{String: err.Error(),
+				Valid: true,
+			},
+		}
+	}
+
+	return run
+}
@@ -0,0 +1,65 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// trendingServer serves the checked-in trending page fixtures and records the requested urls.
+type trendingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (ts *trendingServer) requested() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return append([]string(nil), ts.requests...)
+}
+
+// Start a test server that serves the empty fixture for the "zig" language, the fixture with a changed layout
+// for the "cobol" language and the populated fixture otherwise. Paths outside of /trending are not found.
+func newTrendingServer(t *testing.T) *trendingServer {
+	t.Helper()
+
+	ts := &trendingServer{}
+
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.mu.Lock()
+		ts.requests = append(ts.requests, r.URL.RequestURI())
+		ts.mu.Unlock()
+
+		if !strings.HasPrefix(r.URL.Path, "/trending") {
+			http.NotFound(w, r)
+			return
+		}
+
+		fixture := "trending_repositories"
+
+		if strings.HasPrefix(r.URL.Path, "/trending/developers") {
+			fixture = "trending_developers"
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/zig") {
+			fixture += "_empty"
+		}
+
+		if strings.HasSuffix(r.URL.Path, "/cobol") {
+			fixture += "_changed"
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		http.ServeFile(w, r, filepath.Join("testdata", fixture+".html"))
+	}))
+
+	t.Cleanup(ts.Close)
+
+	return ts
+}
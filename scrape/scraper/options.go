@@ -0,0 +1,60 @@
+package scraper
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
+)
+
+type options struct {
+	baseURL   string
+	transport http.RoundTripper
+	archive   *archive.Archive
+}
+
+type Option func(*options)
+
+// Scrape the trending pages from another host, e.g. a local test server.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// Send the scraping requests through the given transport instead of the default one.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *options) {
+		o.transport = transport
+	}
+}
+
+// Write every fetched trending page to the archive so it can be parsed again later.
+func WithArchive(archive *archive.Archive) Option {
+	return func(o *options) {
+		o.archive = archive
+	}
+}
+
+func newOptions(opts ...Option) options {
+	o := options{
+		baseURL: ghTrendScrapeBaseURL,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func (o options) newCollector() *colly.Collector {
+	c := colly.NewCollector()
+
+	if o.transport != nil {
+		c.WithTransport(o.transport)
+	}
+
+	return c
+}
@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"bytes"
+	"log/slog"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly/v2"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
+)
+
+// Fetch a trending page and archive it when an archive is configured.
+func fetchPage(o options, pageUrl string, key archive.Key) ([]byte, error) {
+	c := o.newCollector()
+
+	var body []byte
+	var fetchErr error
+
+	c.OnResponse(func(r *colly.Response) {
+		body = r.Body
+	})
+
+	c.OnError(func(_ *colly.Response, err error) {
+		fetchErr = err
+	})
+
+	if err := c.Visit(pageUrl); err != nil && fetchErr == nil {
+		fetchErr = err
+	}
+
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+
+	if o.archive != nil {
+		key.ScrapedAt = time.Now()
+
+		if _, err := o.archive.Write(key, body); err != nil {
+			slog.Error("failed to archive trending page", slog.String("url", pageUrl), slog.Any("error", err))
+		}
+	}
+
+	return body, nil
+}
+
+// Parse every row of a trending page, parseRow returns false when the row could not be parsed.
+// The same parsing is used for fetched and archived pages.
+func parsePage(body []byte, rowPath string, parseRow func(e *colly.HTMLElement) bool) pageStats {
+	var stats pageStats
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+
+	if err != nil {
+		stats.err = err
+		return stats
+	}
+
+	stats.blank = doc.Find(ghTrendBlankPath).Length() > 0
+
+	// The row parsers do not use the response, an empty one avoids nil pointers in colly.
+	response := &colly.Response{}
+
+	doc.Find(rowPath).Each(func(i int, s *goquery.Selection) {
+		stats.rows++
+
+		if parseRow(colly.NewHTMLElementFromSelectionNode(response, s, s.Get(0), i)) {
+			stats.parsed++
+		}
+	})
+
+	return stats
+}
@@ -6,128 +6,236 @@ import (
 	"fmt"
 	"log/slog"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gocolly/colly/v2"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
 	"github.com/liweiyi88/trendshift-backend/utils/dbutils"
 )
 
-const ghTrendScrapePath = ".Box-row .h3.lh-condensed a[href]"
+const ghTrendRowPath = ".Box-row"
+const ghTrendLinkPath = ".h3.lh-condensed a[href]"
+const ghTrendPopularRepoPath = ".h4.lh-condensed a[href]"
 const ghTrendScrapeBaseURL = "https://github.com/trending"
 
 type TrendingRepositoryScraper struct {
-	url, path string
-	trendRepo *model.TrendingRepositoryRepo
+	url, path     string
+	options       options
+	trendRepo     *model.TrendingRepositoryRepo
+	scrapeRunRepo *model.ScrapeRunRepo
 }
 
-func NewTrendingRepositoryScraper(trendRepo *model.TrendingRepositoryRepo) *TrendingRepositoryScraper {
+func NewTrendingRepositoryScraper(trendRepo *model.TrendingRepositoryRepo, scrapeRunRepo *model.ScrapeRunRepo, opts ...Option) *TrendingRepositoryScraper {
+	options := newOptions(opts...)
+
 	return &TrendingRepositoryScraper{
-		url:       ghTrendScrapeBaseURL,
-		path:      ghTrendScrapePath,
-		trendRepo: trendRepo,
+		url:           options.baseURL,
+		path:          ghTrendRowPath,
+		options:       options,
+		trendRepo:     trendRepo,
+		scrapeRunRepo: scrapeRunRepo,
 	}
 }
 
-func (gh *TrendingRepositoryScraper) getTrendPageUrl(language string) string {
-	language = strings.TrimSpace(language)
+func (gh *TrendingRepositoryScraper) getTrendPageUrl(language, spokenLanguage, period string) string {
+	language, spokenLanguage = strings.TrimSpace(language), strings.TrimSpace(spokenLanguage)
+
+	pageUrl := fmt.Sprintf("%s?since=%s", gh.url, period)
 
 	if language != "" {
-		return fmt.Sprintf("%s/%s?since=daily", gh.url, url.QueryEscape(language))
+		pageUrl = fmt.Sprintf("%s/%s?since=%s", gh.url, url.QueryEscape(language), period)
+	}
+
+	if spokenLanguage != "" {
+		pageUrl = fmt.Sprintf("%s&spoken_language_code=%s", pageUrl, url.QueryEscape(spokenLanguage))
+	}
+
+	return pageUrl
+}
+
+// Parse the leading number of a text like "1,240 stars today" or "12,345".
+func parseCount(text string) int {
+	fields := strings.Fields(text)
+
+	if len(fields) == 0 {
+		return 0
 	}
 
-	return gh.url
+	count, err := strconv.Atoi(strings.ReplaceAll(fields[0], ",", ""))
+
+	if err != nil {
+		return 0
+	}
+
+	return count
 }
 
-func (gh *TrendingRepositoryScraper) scrape(language string) []string {
-	c := colly.NewCollector()
+// Parse a single trending repository row, it returns false if the row does not contain a repository link.
+func parseRepositoryRow(e *colly.HTMLElement) (model.TrendingRepository, bool) {
+	var repo model.TrendingRepository
 
-	repos := make([]string, 0)
+	link := strings.TrimLeft(e.ChildAttr(ghTrendLinkPath, "href"), "/")
 
-	c.OnHTML(gh.path, func(e *colly.HTMLElement) {
-		link := e.Attr("href")
+	if link == "" {
+		return repo, false
+	}
 
-		if strings.HasPrefix(link, "/") {
-			link = strings.TrimLeft(link, "/")
+	repo.RepoFullName = link
+	repo.Stars = parseCount(e.ChildText(`a[href$="/stargazers"]`))
+	repo.Forks = parseCount(e.ChildText(`a[href$="/forks"], a[href$="/network/members"]`))
+	repo.StarsToday = parseCount(e.ChildText("span.float-sm-right"))
+
+	if language := strings.TrimSpace(e.ChildText(`[itemprop="programmingLanguage"]`)); language != "" {
+		repo.RepoLanguage = dbutils.NullString{
+			NullString: sql.NullString{String: language,
+				Valid: true,
+			},
 		}
+	}
 
-		repos = append(repos, link)
-	})
+	repo.BuiltBy = make(model.Contributors, 0)
+
+	e.ForEach(`a[data-hovercard-type="user"]`, func(_ int, a *colly.HTMLElement) {
+		username := strings.Trim(a.Attr("href"), "/")
 
-	c.OnRequest(func(r *colly.Request) {
-		// fmt.Printf("scraping: %s \n", r.URL.String())
+		if username == "" {
+			return
+		}
+
+		repo.BuiltBy = append(repo.BuiltBy, model.Contributor{
+			Username:  username,
+			AvatarUrl: a.ChildAttr("img", "src"),
+		})
 	})
 
-	c.Visit(gh.getTrendPageUrl(language))
+	return repo, true
+}
+
+// Parse the trending repositories of a fetched or archived trending page.
+func (gh *TrendingRepositoryScraper) parse(body []byte) ([]model.TrendingRepository, pageStats) {
+	repos := make([]model.TrendingRepository, 0)
+
+	stats := parsePage(body, gh.path, func(e *colly.HTMLElement) bool {
+		repo, ok := parseRepositoryRow(e)
+
+		if ok {
+			repos = append(repos, repo)
+		}
+
+		return ok
+	})
 
-	return repos
+	return repos, stats
 }
 
-func (gh *TrendingRepositoryScraper) saveRepositories(ctx context.Context, language string, repositories []string) error {
-	now := time.Now()
-	rankedTrendingRepo, err := gh.trendRepo.FindRankedTrendingRepoByDate(ctx, now, language)
+func (gh *TrendingRepositoryScraper) scrape(language, spokenLanguage, period string) ([]model.TrendingRepository, pageStats) {
+	key := archive.Key{Type: gh.GetType(), Language: language, SpokenLanguage: spokenLanguage, Period: period}
+
+	body, err := fetchPage(gh.options, gh.getTrendPageUrl(language, spokenLanguage, period), key)
 
 	if err != nil {
-		return fmt.Errorf("failed to retrieve ranked trending repositoris: %v", err)
+		return make([]model.TrendingRepository, 0), pageStats{err: err}
 	}
 
+	return gh.parse(body)
+}
+
+// Replace the snapshot of the trending page on the day of scrapedAt with the scraped repositories and log the rank changes.
+func (gh *TrendingRepositoryScraper) saveRepositories(ctx context.Context, scrapedAt time.Time, language, spokenLanguage, period string, repositories []model.TrendingRepository) error {
+	trendingRepositories := make([]model.TrendingRepository, 0, len(repositories))
+
 	for index, repo := range repositories {
-		rank := index + 1
-
-		trendingRepo, ok := rankedTrendingRepo[rank]
-
-		if ok && trendingRepo.RepoFullName != "" {
-			// if trending repo exist, do update.
-			trendingRepo.RepoFullName = repo
-			trendingRepo.ScrapedAt, trendingRepo.TrendDate = now, now
-
-			gh.trendRepo.Update(ctx, trendingRepo)
-		} else {
-			// trending repo does not exist, do insert.
-			trendingRepo := model.TrendingRepository{
-				RepoFullName: repo,
-				ScrapedAt:    now,
-				TrendDate:    now,
-				Rank:         rank,
-			}
-
-			if language != "" {
-				trendingRepo.Language = dbutils.NullString{
-					NullString: sql.NullString{String: strings.ToLower(language),
-						Valid: true,
-					},
-				}
-			} else {
-				trendingRepo.Language = dbutils.NullString{
-					NullString: sql.NullString{
-						String: "",
-						Valid:  false,
-					}}
-			}
-
-			err = gh.trendRepo.Save(ctx, trendingRepo)
-
-			if err != nil {
-				return fmt.Errorf("failed to save trending repository: %s to db: %v", trendingRepo.RepoFullName, err)
-			}
-		}
+		trendingRepo := repo
+		trendingRepo.ScrapedAt = scrapedAt
+		trendingRepo.TrendDate = scrapedAt
+		trendingRepo.Rank = index + 1
+		trendingRepo.Period = period
+		trendingRepo.Language = dbutils.NewNullString(model.LanguageSlug(language))
+		trendingRepo.SpokenLanguage = dbutils.NewNullString(spokenLanguage)
+
+		trendingRepositories = append(trendingRepositories, trendingRepo)
+	}
+
+	changes, err := gh.trendRepo.ReplaceSnapshot(ctx, scrapedAt, language, trendingRepositories, opt.Period(period), opt.SpokenLanguage(spokenLanguage))
+
+	if err != nil {
+		return fmt.Errorf("failed to save trending repositories, language: %s, spoken language: %s, period: %s, error: %v", language, spokenLanguage, period, err)
 	}
 
+	jobrun.Count(ctx).Inserted(len(trendingRepositories))
+	logRankChanges(gh.GetType(), language, spokenLanguage, period, changes)
+
 	return nil
 }
 
-func (gh *TrendingRepositoryScraper) Scrape(ctx context.Context, language string) error {
-	repos := gh.scrape(language)
+func (gh *TrendingRepositoryScraper) Scrape(ctx context.Context, language string, opts ...any) error {
+	options := opt.ExtractOptions(opts...)
+	period, spokenLanguage := model.PeriodOrDefault(options.Period), options.SpokenLanguage
+
+	repos, stats := gh.scrape(language, spokenLanguage, period)
+	jobrun.Count(ctx).Scraped(len(repos))
+
+	if err := gh.scrapeRunRepo.Save(ctx, stats.toRun(gh.GetType(), language, spokenLanguage, period)); err != nil {
+		slog.Error("failed to save scrape run", slog.Any("error", err))
+	}
+
+	if err := stats.check(); err != nil {
+		jobrun.Count(ctx).Failed(1)
+		return fmt.Errorf("could not scrape trending repositories for language: %s, spoken language: %s, period: %s, error: %v", language, spokenLanguage, period, err)
+	}
 
 	if len(repos) == 0 {
-		slog.Error("could not scrape any trending repository data.", slog.Any("language", language))
+		slog.Info("no trending repository data.", slog.Any("language", language), slog.Any("spoken language", spokenLanguage), slog.Any("period", period))
 		return nil
 	}
 
-	return gh.saveRepositories(ctx, language, repos)
+	return gh.saveRepositories(ctx, time.Now(), language, spokenLanguage, period, repos)
+}
+
+// Rebuild the trending repositories of an archived page without visiting GitHub.
+func (gh *TrendingRepositoryScraper) Reparse(ctx context.Context, key archive.Key, body []byte) error {
+	period := model.PeriodOrDefault(key.Period)
+	repos, stats := gh.parse(body)
+	jobrun.Count(ctx).Scraped(len(repos))
+
+	if err := stats.check(); err != nil {
+		jobrun.Count(ctx).Failed(1)
+		return fmt.Errorf("could not parse archived trending repositories for language: %s, spoken language: %s, period: %s, error: %v", key.Language, key.SpokenLanguage, period, err)
+	}
+
+	if len(repos) == 0 {
+		return nil
+	}
+
+	return gh.saveRepositories(ctx, key.ScrapedAt.Local(), key.Language, key.SpokenLanguage, period, repos)
 }
 
 func (gh *TrendingRepositoryScraper) GetType() string {
 	return "repository"
 }
+
+// Log a summary of the rank changes, every change is logged at debug level.
+func logRankChanges(scrapeType, language, spokenLanguage, period string, changes []model.RankChange) {
+	entered, left, moved := model.CountRankChanges(changes)
+
+	slog.Info(fmt.Sprintf("trending %s ranks changed.", scrapeType),
+		slog.String("language", language),
+		slog.String("spoken language", spokenLanguage),
+		slog.String("period", period),
+		slog.Int("entered", entered),
+		slog.Int("left", left),
+		slog.Int("moved", moved))
+
+	for _, change := range changes {
+		slog.Debug(fmt.Sprintf("trending %s %s.", scrapeType, change.Change),
+			slog.String("name", change.Name),
+			slog.String("language", language),
+			slog.Int64("previous rank", change.PreviousRank.Int64),
+			slog.Int64("rank", change.Rank.Int64))
+	}
+}
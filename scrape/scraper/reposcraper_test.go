@@ -1,18 +1,20 @@
 package scraper
 
 import (
-	"context"
+	"errors"
 	"testing"
+	"time"
 
-	"github.com/liweiyi88/trendshift-backend/config"
 	"github.com/liweiyi88/trendshift-backend/model"
-	"golang.org/x/sync/errgroup"
+	"github.com/liweiyi88/trendshift-backend/scrape/archive"
 )
 
 func TestScrape(t *testing.T) {
-	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{})
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{})
 
-	all, golang, php := scraper.getTrendPageUrl(""), scraper.getTrendPageUrl("Go"), scraper.getTrendPageUrl("PHP")
+	all, golang, php := scraper.getTrendPageUrl("", "", "daily"), scraper.getTrendPageUrl("Go", "", "weekly"), scraper.getTrendPageUrl("PHP", "", "monthly")
+	chinese, japaneseRust := scraper.getTrendPageUrl("", "zh", "daily"), scraper.getTrendPageUrl("rust", "ja", "weekly")
+	cpp, csharp := scraper.getTrendPageUrl("c++", "", "daily"), scraper.getTrendPageUrl(" c# ", "", "daily")
 
 	expcts := []struct {
 		actual any
@@ -20,15 +22,31 @@ func TestScrape(t *testing.T) {
 	}{
 		{
 			actual: all,
-			want:   "https://github.com/trending",
+			want:   "https://github.com/trending?since=daily",
 		},
 		{
 			actual: golang,
-			want:   "https://github.com/trending/Go?since=daily",
+			want:   "https://github.com/trending/Go?since=weekly",
 		},
 		{
 			actual: php,
-			want:   "https://github.com/trending/PHP?since=daily",
+			want:   "https://github.com/trending/PHP?since=monthly",
+		},
+		{
+			actual: chinese,
+			want:   "https://github.com/trending?since=daily&spoken_language_code=zh",
+		},
+		{
+			actual: japaneseRust,
+			want:   "https://github.com/trending/rust?since=weekly&spoken_language_code=ja",
+		},
+		{
+			actual: cpp,
+			want:   "https://github.com/trending/c%2B%2B?since=daily",
+		},
+		{
+			actual: csharp,
+			want:   "https://github.com/trending/c%23?since=daily",
 		},
 	}
 
@@ -37,23 +55,272 @@ func TestScrape(t *testing.T) {
 			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
 		}
 	}
+}
+
+func TestScrapeFixture(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending/"))
+
+	repositories, stats := scraper.scrape("", "", "daily")
+
+	if status := stats.status(); status != model.ScrapeRunOk {
+		t.Errorf("expect scrape status to be %s, actual got: %s", model.ScrapeRunOk, status)
+	}
+
+	if len(repositories) != 3 {
+		t.Fatalf("expect 3 trending repositories, actual got: %d", len(repositories))
+	}
+
+	expcts := []struct {
+		fullName   string
+		language   string
+		stars      int
+		forks      int
+		starsToday int
+		builtBy    []string
+	}{
+		{
+			fullName:   "ollama/ollama",
+			language:   "Go",
+			stars:      78412,
+			forks:      5731,
+			starsToday: 1240,
+			builtBy:    []string{"jmorganca", "mxyng"},
+		},
+		{
+			fullName:   "liweiyi88/onedump",
+			language:   "Go",
+			stars:      684,
+			forks:      31,
+			starsToday: 87,
+			builtBy:    []string{"liweiyi88"},
+		},
+		{
+			fullName:   "awesome/awesome-lists",
+			stars:      3002,
+			forks:      120,
+			starsToday: 15,
+			builtBy:    []string{},
+		},
+	}
 
-	group, _ := errgroup.WithContext(context.Background())
+	// The trending rank is derived from the row order, so the order must follow the page.
+	for i, want := range expcts {
+		repo := repositories[i]
 
-	for _, language := range config.LanguageToScrape {
-		language := language
-		group.Go(func() error {
-			repositories := scraper.scrape(language)
+		if repo.RepoFullName != want.fullName {
+			t.Errorf("expect repository %d to be %s, actual got: %s", i+1, want.fullName, repo.RepoFullName)
+		}
 
-			if len(repositories) == 0 {
-				t.Logf("could not scrape trending repositories from GitHub, language: %s", language)
+		if repo.RepoLanguage.Valid != (want.language != "") || repo.RepoLanguage.String != want.language {
+			t.Errorf("expect language of %s to be %q, actual got: %q", want.fullName, want.language, repo.RepoLanguage.String)
+		}
+
+		if repo.Stars != want.stars || repo.Forks != want.forks || repo.StarsToday != want.starsToday {
+			t.Errorf("expect %s to have %d stars, %d forks and %d stars today, actual got: %d, %d and %d",
+				want.fullName, want.stars, want.forks, want.starsToday, repo.Stars, repo.Forks, repo.StarsToday)
+		}
+
+		if len(repo.BuiltBy) != len(want.builtBy) {
+			t.Fatalf("expect %s to be built by %v, actual got: %v", want.fullName, want.builtBy, repo.BuiltBy)
+		}
+
+		for j, username := range want.builtBy {
+			if repo.BuiltBy[j].Username != username || repo.BuiltBy[j].AvatarUrl == "" {
+				t.Errorf("expect %s to be built by %s with an avatar, actual got: %v", want.fullName, username, repo.BuiltBy[j])
 			}
+		}
+	}
+
+	if requests := server.requested(); len(requests) != 1 || requests[0] != "/trending?since=daily" {
+		t.Errorf("expect a single request to /trending?since=daily, actual got: %v", requests)
+	}
+}
+
+func TestScrapeFixtureLanguages(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"))
+
+	if repositories, _ := scraper.scrape("c++", "zh", "weekly"); len(repositories) != 3 {
+		t.Errorf("expect 3 trending repositories for c++, actual got: %d", len(repositories))
+	}
+
+	if repositories, _ := scraper.scrape("c#", "", "monthly"); len(repositories) != 3 {
+		t.Errorf("expect 3 trending repositories for c#, actual got: %d", len(repositories))
+	}
+
+	want := []string{
+		"/trending/c%2B%2B?since=weekly&spoken_language_code=zh",
+		"/trending/c%23?since=monthly",
+	}
+
+	requests := server.requested()
+
+	if len(requests) != len(want) {
+		t.Fatalf("expect requests: %v, actual got: %v", want, requests)
+	}
+
+	for i := range want {
+		if requests[i] != want[i] {
+			t.Errorf("expect request: %s, actual got: %s", want[i], requests[i])
+		}
+	}
+}
+
+func TestScrapeFixtureEmptyPage(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"))
+
+	repositories, stats := scraper.scrape("zig", "ja", "daily")
+
+	if len(repositories) != 0 {
+		t.Errorf("expect no trending repositories for an empty page, actual got: %d", len(repositories))
+	}
+
+	if status := stats.status(); status != model.ScrapeRunEmpty {
+		t.Errorf("expect scrape status to be %s, actual got: %s", model.ScrapeRunEmpty, status)
+	}
+
+	if err := stats.check(); err != nil {
+		t.Errorf("expect an empty page to pass the selector check, actual got: %v", err)
+	}
+}
+
+func TestScrapeFixtureLayoutChanged(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"))
+
+	repositories, stats := scraper.scrape("cobol", "", "daily")
+
+	if len(repositories) != 0 {
+		t.Errorf("expect no trending repositories when the link selector does not match, actual got: %d", len(repositories))
+	}
+
+	if stats.rows != 3 || stats.status() != model.ScrapeRunLayoutChanged || stats.check() == nil {
+		t.Errorf("expect a layout change with 3 rows, actual got: %+v", stats)
+	}
+
+	run := stats.toRun(scraper.GetType(), "COBOL", "", "daily")
 
-			return nil
-		})
+	if run.Language != "cobol" || run.Status != model.ScrapeRunLayoutChanged || !run.Error.Valid {
+		t.Errorf("expect a layout changed run for cobol with an error, actual got: %+v", run)
 	}
+}
+
+func TestScrapeFixtureServerError(t *testing.T) {
+	server := newTrendingServer(t)
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/missing"))
+
+	_, stats := scraper.scrape("", "", "daily")
+
+	if stats.status() != model.ScrapeRunFailed || stats.check() == nil {
+		t.Errorf("expect a failed scrape when the page can not be fetched, actual got: %+v", stats)
+	}
+}
+
+func TestPageStatsStatus(t *testing.T) {
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{
+			actual: pageStats{rows: 25, parsed: 25}.status(),
+			want:   model.ScrapeRunOk,
+		},
+		{
+			actual: pageStats{blank: true}.status(),
+			want:   model.ScrapeRunEmpty,
+		},
+		{
+			actual: pageStats{}.status(),
+			want:   model.ScrapeRunLayoutChanged,
+		},
+		{
+			actual: pageStats{rows: 25, parsed: 20}.status(),
+			want:   model.ScrapeRunLayoutChanged,
+		},
+		{
+			actual: pageStats{rows: 25, parsed: 25, err: errors.New("timeout")}.status(),
+			want:   model.ScrapeRunFailed,
+		},
+	}
+
+	for _, test := range expcts {
+		if test.actual != test.want {
+			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
+		}
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{
+			actual: parseCount("1,240 stars today"),
+			want:   1240,
+		},
+		{
+			actual: parseCount("\n      12,345\n    "),
+			want:   12345,
+		},
+		{
+			actual: parseCount("87 stars this week"),
+			want:   87,
+		},
+		{
+			actual: parseCount(""),
+			want:   0,
+		},
+		{
+			actual: parseCount("Built by"),
+			want:   0,
+		},
+	}
+
+	for _, test := range expcts {
+		if test.actual != test.want {
+			t.Errorf("expect: %v, actual got: %v", test.want, test.actual)
+		}
+	}
+}
+
+func TestScrapeArchivesPage(t *testing.T) {
+	server := newTrendingServer(t)
+	pages := archive.New(t.TempDir())
+	scraper := NewTrendingRepositoryScraper(&model.TrendingRepositoryRepo{}, &model.ScrapeRunRepo{}, WithBaseURL(server.URL+"/trending"), WithArchive(pages))
+
+	before := time.Now().Add(-time.Second)
+
+	if repositories, _ := scraper.scrape("Go", "zh", "weekly"); len(repositories) != 3 {
+		t.Fatalf("expect 3 trending repositories, actual got: %d", len(repositories))
+	}
+
+	entries, err := pages.Find(scraper.GetType(), before, time.Now().Add(time.Second))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expect 1 archived page, actual got: %d", len(entries))
+	}
+
+	if key := entries[0].Key; key.Language != "go" || key.SpokenLanguage != "zh" || key.Period != "weekly" {
+		t.Errorf("expect the archived page to be keyed by go, zh and weekly, actual got: %+v", key)
+	}
+
+	body, err := pages.Read(entries[0])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parsing the archived page must give the same result as the fetched page.
+	repositories, stats := scraper.parse(body)
 
-	if err := group.Wait(); err != nil {
-		t.Error(err)
+	if len(repositories) != 3 || repositories[0].RepoFullName != "ollama/ollama" || stats.status() != model.ScrapeRunOk {
+		t.Errorf("expect the archived page to parse into 3 repositories, actual got: %d, %s", len(repositories), stats.status())
 	}
 }
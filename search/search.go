@@ -8,6 +8,7 @@ import (
 	"log/slog"
 
 	"github.com/liweiyi88/trendshift-backend/database"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
 )
 
@@ -78,6 +79,7 @@ func (h *SearchHandler) sync(ctx context.Context) error {
 		return fmt.Errorf("could not import repositories to full text search: %v", err)
 	}
 
+	jobrun.Count(ctx).Updated(len(repositories))
 	slog.Info("repositories have been imported")
 	return nil
 }
@@ -0,0 +1,155 @@
+// Package tagging assigns tags to repositories from their GitHub topics, e.g. the llm and large-language-models topics are tagged as LLM.
+// The tags from topics are saved apart from the tags assigned by editors, so syncing never overrides the manual tags.
+package tagging
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+// Mapping from a lower case topic to the name of a tag.
+type Mapping map[string]string
+
+// Load the mapping from a JSON file of tags and their topics, e.g. {"LLM": ["llm", "large-language-models"]}.
+func LoadMapping(path string) (Mapping, error) {
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read topic tags: %v", err)
+	}
+
+	var tags map[string][]string
+
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, fmt.Errorf("failed to parse topic tags: %v", err)
+	}
+
+	return NewMapping(tags)
+}
+
+// Create the mapping from tags and their topics, a topic can only be mapped to a single tag.
+func NewMapping(tags map[string][]string) (Mapping, error) {
+	mapping := make(Mapping)
+
+	for tag, topics := range tags {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "" {
+			return nil, errors.New("topic tags has an empty tag name")
+		}
+
+		for _, topic := range topics {
+			topic = strings.ToLower(strings.TrimSpace(topic))
+
+			if existing, ok := mapping[topic]; ok && existing != tag {
+				return nil, fmt.Errorf("topic %s is mapped to both %s and %s", topic, existing, tag)
+			}
+
+			mapping[topic] = tag
+		}
+	}
+
+	return mapping, nil
+}
+
+// Get the sorted names of the tags mapped from the topics.
+func (m Mapping) Tags(topics []string) []string {
+	tags := make([]string, 0)
+
+	for _, topic := range topics {
+		tag, ok := m[strings.ToLower(topic)]
+
+		if ok && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	slices.Sort(tags)
+
+	return tags
+}
+
+// TagStore finds and creates tags, see model.TagRepo.
+type TagStore interface {
+	FindByName(ctx context.Context, name string) (model.Tag, error)
+	Save(ctx context.Context, tag model.Tag) (int, error)
+}
+
+// RepositoryStore saves the tags from topics, see model.GhRepositoryRepo.
+type RepositoryStore interface {
+	SaveTopicTags(ctx context.Context, ghRepo model.GhRepository, tags []model.Tag) error
+}
+
+type Tagger struct {
+	mapping      Mapping
+	tags         TagStore
+	repositories RepositoryStore
+
+	mu    sync.Mutex
+	cache map[string]model.Tag
+}
+
+func NewTagger(mapping Mapping, tags TagStore, repositories RepositoryStore) *Tagger {
+	return &Tagger{
+		mapping:      mapping,
+		tags:         tags,
+		repositories: repositories,
+		cache:        make(map[string]model.Tag),
+	}
+}
+
+// Find the tag by name, a tag that does not exist yet is created.
+func (t *Tagger) tag(ctx context.Context, name string) (model.Tag, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if tag, ok := t.cache[name]; ok {
+		return tag, nil
+	}
+
+	tag, err := t.tags.FindByName(ctx, strings.ToLower(name))
+
+	if errors.Is(err, sql.ErrNoRows) {
+		tag = model.Tag{Name: name}
+		tag.Id, err = t.tags.Save(ctx, tag)
+	}
+
+	if err != nil {
+		return tag, fmt.Errorf("failed to find or create tag %s: %v", name, err)
+	}
+
+	t.cache[name] = tag
+
+	return tag, nil
+}
+
+// Replace the tags from topics of a saved repository with the tags mapped from its current topics.
+func (t *Tagger) Apply(ctx context.Context, repository model.GhRepository) error {
+	names := t.mapping.Tags(repository.Topics)
+	tags := make([]model.Tag, 0, len(names))
+
+	for _, name := range names {
+		tag, err := t.tag(ctx, name)
+
+		if err != nil {
+			return err
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := t.repositories.SaveTopicTags(ctx, repository, tags); err != nil {
+		return fmt.Errorf("failed to save topic tags of repository %s: %v", repository.FullName, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package tagging
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+type fakeTagStore struct {
+	tags    map[string]model.Tag
+	lookups int
+}
+
+func (f *fakeTagStore) FindByName(ctx context.Context, name string) (model.Tag, error) {
+	f.lookups++
+
+	for _, tag := range f.tags {
+		if strings.ToLower(tag.Name) == name {
+			return tag, nil
+		}
+	}
+
+	return model.Tag{}, sql.ErrNoRows
+}
+
+func (f *fakeTagStore) Save(ctx context.Context, tag model.Tag) (int, error) {
+	tag.Id = len(f.tags) + 1
+	f.tags[tag.Name] = tag
+
+	return tag.Id, nil
+}
+
+type fakeRepositoryStore struct {
+	saved map[string][]model.Tag
+}
+
+func (f *fakeRepositoryStore) SaveTopicTags(ctx context.Context, ghRepo model.GhRepository, tags []model.Tag) error {
+	f.saved[ghRepo.FullName] = tags
+
+	return nil
+}
+
+func TestMappingTags(t *testing.T) {
+	mapping, err := NewMapping(map[string][]string{
+		"LLM":      {"llm", " Large-Language-Models "},
+		"Database": {"database"},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{strings.Join(mapping.Tags([]string{"llm", "large-language-models", "database", "go"}), ","), "Database,LLM"},
+		{strings.Join(mapping.Tags([]string{"LLM"}), ","), "LLM"},
+		{len(mapping.Tags([]string{"go"})), 0},
+		{len(mapping.Tags(nil)), 0},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
+
+func TestNewMappingErrors(t *testing.T) {
+	if _, err := NewMapping(map[string][]string{"LLM": {"llm"}, "AI": {"LLM"}}); err == nil {
+		t.Error("expected a topic mapped to two tags to be rejected")
+	}
+
+	if _, err := NewMapping(map[string][]string{" ": {"llm"}}); err == nil {
+		t.Error("expected an empty tag name to be rejected")
+	}
+}
+
+func TestLoadMapping(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topic-tags.json")
+
+	if err := os.WriteFile(path, []byte(`{"LLM": ["llm", "gpt"]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	mapping, err := LoadMapping(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mapping["gpt"] != "LLM" {
+		t.Errorf("expect gpt to be mapped to LLM but got %s", mapping["gpt"])
+	}
+
+	if _, err := LoadMapping(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected a missing file to be rejected")
+	}
+}
+
+func TestTaggerApply(t *testing.T) {
+	mapping, err := NewMapping(map[string][]string{"LLM": {"llm", "gpt"}, "Database": {"database"}})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags := &fakeTagStore{tags: map[string]model.Tag{"llm": {Id: 1, Name: "llm"}}}
+	repositories := &fakeRepositoryStore{saved: make(map[string][]model.Tag)}
+	tagger := NewTagger(mapping, tags, repositories)
+	ctx := context.Background()
+
+	first := tagger.Apply(ctx, model.GhRepository{FullName: "owner/first", Topics: model.Topics{"gpt", "database"}})
+	second := tagger.Apply(ctx, model.GhRepository{FullName: "owner/second", Topics: model.Topics{"llm", "go"}})
+	untagged := tagger.Apply(ctx, model.GhRepository{FullName: "owner/untagged"})
+
+	names := func(tags []model.Tag) []string {
+		names := make([]string, 0, len(tags))
+
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+
+		return names
+	}
+
+	_, untaggedSaved := repositories.saved["owner/untagged"]
+
+	expcts := []struct {
+		actual any
+		want   any
+	}{
+		{first, nil},
+		{second, nil},
+		{untagged, nil},
+		{strings.Join(names(repositories.saved["owner/first"]), ","), "Database,llm"},
+		{repositories.saved["owner/first"][1].Id, 1},
+		{repositories.saved["owner/first"][0].Id, 2},
+		{strings.Join(names(repositories.saved["owner/second"]), ","), "llm"},
+		{untaggedSaved, true},
+		{len(repositories.saved["owner/untagged"]), 0},
+		{len(tags.tags), 2},
+		{tags.lookups, 2},
+	}
+
+	for _, expct := range expcts {
+		if expct.actual != expct.want {
+			t.Errorf("expect %v but got %v", expct.want, expct.actual)
+		}
+	}
+}
@@ -3,27 +3,48 @@ package trending
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"strings"
 
 	"github.com/liweiyi88/trendshift-backend/github"
 	"github.com/liweiyi88/trendshift-backend/global"
+	"github.com/liweiyi88/trendshift-backend/jobrun"
 	"github.com/liweiyi88/trendshift-backend/model"
 	"github.com/liweiyi88/trendshift-backend/search"
-	"golang.org/x/sync/errgroup"
+	"github.com/liweiyi88/trendshift-backend/tagging"
 )
 
 type GithubFetcher struct {
-	gh           *github.Client
+	gh           github.Details
 	search       search.Search
 	repositories global.Repositories
+	tagger       *tagging.Tagger        // tags the new repositories from their topics when set.
+	readmes      *github.ReadmeFetcher  // fetches the READMEs of the new repositories when set.
+	backfiller   *github.StarBackfiller // backfills the star history of the new trending repositories when set.
 }
 
-func NewGithubFetcher(gh *github.Client, search search.Search, repositories global.Repositories) *GithubFetcher {
+func NewGithubFetcher(gh github.Details, search search.Search, repositories global.Repositories) *GithubFetcher {
 	return &GithubFetcher{
-		gh, search, repositories,
+		gh: gh, search: search, repositories: repositories,
 	}
 }
 
+func (fetcher *GithubFetcher) WithTagger(tagger *tagging.Tagger) *GithubFetcher {
+	fetcher.tagger = tagger
+	return fetcher
+}
+
+func (fetcher *GithubFetcher) WithReadmes(readmes *github.ReadmeFetcher) *GithubFetcher {
+	fetcher.readmes = readmes
+	return fetcher
+}
+
+// Backfill the star history of the repositories first linked by FetchRepositories from their stargazers.
+func (fetcher *GithubFetcher) WithBackfill(backfiller *github.StarBackfiller) *GithubFetcher {
+	fetcher.backfiller = backfiller
+	return fetcher
+}
+
 func (fetcher *GithubFetcher) FetchDevelopers(ctx context.Context) error {
 	tdr, dr := fetcher.repositories.TrendingDeveloperRepo, fetcher.repositories.DeveloperRepo
 
@@ -54,6 +75,8 @@ func (fetcher *GithubFetcher) FetchDevelopers(ctx context.Context) error {
 				if err != nil {
 					return err
 				}
+
+				jobrun.Count(ctx).Linked(1)
 			}
 		}
 
@@ -62,48 +85,77 @@ func (fetcher *GithubFetcher) FetchDevelopers(ctx context.Context) error {
 		}
 	}
 
-	group, ctx := errgroup.WithContext(ctx)
+	ghDevelopers, err := fetcher.gh.GetDevelopers(ctx, devNamesNotExist)
 
-	developersNotExist := make([]model.Developer, 0)
+	if err != nil {
+		return fmt.Errorf("failed to fetch github developer details: %v", err)
+	}
 
-	for _, dev := range devNamesNotExist {
-		devName := dev
+	developersNotExist := make([]model.Developer, 0, len(ghDevelopers))
 
-		group.Go(func() error {
-			developer, err := fetcher.gh.GetDeveloper(ctx, devName)
+	for _, devName := range devNamesNotExist {
+		developer, ok := ghDevelopers[devName]
 
-			if err != nil {
+		// A developer might have been deleted or renamed since it was scraped, mark it rather than failing the whole linking.
+		if !ok {
+			slog.Info(fmt.Sprintf("skip linking developer: %s, not found on GitHub", devName))
+			jobrun.Count(ctx).Failed(1)
+
+			if err := tdr.MarkDeveloperUnavailable(ctx, devName); err != nil {
 				return err
 			}
 
-			lastInsertId, err := dr.Save(ctx, developer)
-			developer.Id = int(lastInsertId)
+			continue
+		}
+
+		lastInsertId, err := dr.Save(ctx, developer)
+		developer.Id = int(lastInsertId)
 
-			if err != nil {
-				return fmt.Errorf("failed to save developer: %v", err)
-			}
+		if err != nil {
+			return fmt.Errorf("failed to save developer: %v", err)
+		}
 
-			err = tdr.LinkDeveloper(ctx, developer)
+		err = tdr.LinkDeveloper(ctx, developer)
 
-			if err != nil {
-				return fmt.Errorf("failed to link developer: %v", err)
-			}
+		if err != nil {
+			return fmt.Errorf("failed to link developer: %v", err)
+		}
+
+		jobrun.Count(ctx).Inserted(1)
+		jobrun.Count(ctx).Linked(1)
 
-			developersNotExist = append(developersNotExist, developer)
-			return nil
-		})
+		developersNotExist = append(developersNotExist, developer)
 	}
 
-	if err := group.Wait(); err != nil {
-		return fmt.Errorf("failed to fetch and save github developer details: %v", err)
+	if err := fetcher.search.UpsertDevelopers(developersNotExist...); err != nil {
+		return err
 	}
 
-	return fetcher.search.UpsertDevelopers(developersNotExist...)
+	return fetcher.fetchPopularRepositories(ctx)
+}
+
+// Fetch the popular repositories of trending developers and save the relationship between trending_developers and repositories.
+func (fetcher *GithubFetcher) fetchPopularRepositories(ctx context.Context) error {
+	tdr := fetcher.repositories.TrendingDeveloperRepo
+
+	unlinkedRepositories, err := tdr.FindUnlinkedPopularRepositories(ctx)
+
+	if err != nil {
+		return fmt.Errorf("failed to query unlinked popular repositories: %v", err)
+	}
+
+	repositoriesNotExist, err := fetcher.linkRepositories(ctx, unlinkedRepositories, tdr.LinkPopularRepository, tdr.MarkPopularRepositoryUnavailable)
+
+	if err != nil {
+		return fmt.Errorf("failed to link popular repositories: %v", err)
+	}
+
+	return fetcher.search.UpsertRepositories(repositoriesNotExist...)
 }
 
 // Fetch repositories details from github rest api and save the relationship between trending_repositories and repositories.
 func (fetcher *GithubFetcher) FetchRepositories(ctx context.Context) error {
-	trr, grr := fetcher.repositories.TrendingRepositoryRepo, fetcher.repositories.GhRepositoryRepo
+	trr := fetcher.repositories.TrendingRepositoryRepo
 
 	unlinkedRepositories, err := trr.FindUnlinkedRepositories(ctx)
 
@@ -111,10 +163,48 @@ func (fetcher *GithubFetcher) FetchRepositories(ctx context.Context) error {
 		return fmt.Errorf("failed to query unlinked repositories: %v", err)
 	}
 
+	repositoriesNotExist, err := fetcher.linkRepositories(ctx, unlinkedRepositories, trr.LinkRepository, trr.MarkRepositoryUnavailable)
+
+	if err != nil {
+		return err
+	}
+
+	if err := fetcher.search.UpsertRepositories(repositoriesNotExist...); err != nil {
+		return err
+	}
+
+	return fetcher.backfillStarHistory(ctx, repositoriesNotExist)
+}
+
+// The star history is nice to have, a repository that fails to backfill does not fail the linking.
+func (fetcher *GithubFetcher) backfillStarHistory(ctx context.Context, repositories []model.GhRepository) error {
+	if fetcher.backfiller == nil {
+		return nil
+	}
+
+	for _, repository := range repositories {
+		if err := fetcher.backfiller.Backfill(ctx, repository); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			slog.Error("failed to backfill star history", slog.String("repository", repository.FullName), slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// Link the repositories by names, repositories that do not exist in DB are fetched from GitHub and saved first.
+// A repository might have been deleted or blocked since it was scraped, it is passed to unavailable to be marked rather than failing the whole linking.
+// It returns the newly saved repositories.
+func (fetcher *GithubFetcher) linkRepositories(ctx context.Context, unlinkedRepositories []string, link func(context.Context, model.GhRepository) error, unavailable func(context.Context, string) error) ([]model.GhRepository, error) {
+	grr := fetcher.repositories.GhRepositoryRepo
+
 	repos, err := grr.FindRepositoriesByNames(ctx, unlinkedRepositories)
 
 	if err != nil {
-		return fmt.Errorf("failed to query repositories by names: %v", err)
+		return nil, fmt.Errorf("failed to query repositories by names: %v", err)
 	}
 
 	repoNamesNotExist := make([]string, 0)
@@ -126,11 +216,13 @@ func (fetcher *GithubFetcher) FetchRepositories(ctx context.Context) error {
 		for _, repo := range repos {
 			if strings.EqualFold(repo.FullName, unlinkedRepo) {
 				exist = true
-				err := trr.LinkRepository(ctx, repo)
+				err := link(ctx, repo)
 
 				if err != nil {
-					return err
+					return nil, err
 				}
+
+				jobrun.Count(ctx).Linked(1)
 			}
 		}
 
@@ -139,41 +231,58 @@ func (fetcher *GithubFetcher) FetchRepositories(ctx context.Context) error {
 		}
 	}
 
-	group, ctx := errgroup.WithContext(ctx)
+	ghRepositories, err := fetcher.gh.GetRepositories(ctx, repoNamesNotExist)
 
-	repositoriesNotExist := make([]model.GhRepository, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch github repository details: %v", err)
+	}
+
+	repositoriesNotExist := make([]model.GhRepository, 0, len(ghRepositories))
 
 	for _, repo := range repoNamesNotExist {
-		repo := repo
+		repository, ok := ghRepositories[repo]
 
-		group.Go(func() error {
-			repository, err := fetcher.gh.GetRepository(ctx, repo)
+		if !ok {
+			slog.Info(fmt.Sprintf("skip linking repository: %s, not found or blocked on GitHub", repo))
+			jobrun.Count(ctx).Failed(1)
 
-			if err != nil {
-				return err
+			if err := unavailable(ctx, repo); err != nil {
+				return nil, err
 			}
 
-			lastInsertId, err := grr.Save(ctx, repository)
-			repository.Id = int(lastInsertId)
+			continue
+		}
 
-			if err != nil {
-				return fmt.Errorf("failed to save repository: %v", err)
-			}
+		lastInsertId, err := grr.Save(ctx, repository)
+		repository.Id = int(lastInsertId)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to save repository: %v", err)
+		}
 
-			err = trr.LinkRepository(ctx, repository)
+		err = link(ctx, repository)
 
-			if err != nil {
-				return fmt.Errorf("failed to link repository: %v", err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link repository: %v", err)
+		}
+
+		if fetcher.tagger != nil {
+			if err := fetcher.tagger.Apply(ctx, repository); err != nil {
+				return nil, err
 			}
+		}
 
-			repositoriesNotExist = append(repositoriesNotExist, repository)
-			return nil
-		})
-	}
+		if fetcher.readmes != nil {
+			if err := fetcher.readmes.Refresh(ctx, repository); err != nil {
+				return nil, err
+			}
+		}
 
-	if err := group.Wait(); err != nil {
-		return fmt.Errorf("failed to fetch and save github repository details: %v", err)
+		jobrun.Count(ctx).Inserted(1)
+		jobrun.Count(ctx).Linked(1)
+
+		repositoriesNotExist = append(repositoriesNotExist, repository)
 	}
 
-	return fetcher.search.UpsertRepositories(repositoriesNotExist...)
+	return repositoriesNotExist, nil
 }
@@ -3,12 +3,20 @@ package dbutils
 import (
 	"database/sql"
 	"encoding/json"
+	"time"
 )
 
 type NullString struct {
 	sql.NullString
 }
 
+// Create a NullString that is null when the string is empty.
+func NewNullString(value string) NullString {
+	return NullString{
+		NullString: sql.NullString{String: value, Valid: value != ""},
+	}
+}
+
 func (v NullString) MarshalJSON() ([]byte, error) {
 	if v.Valid {
 		return json.Marshal(v.String)
@@ -60,3 +68,31 @@ func (v *NullInt64) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+type NullTime struct {
+	sql.NullTime
+}
+
+func (v NullTime) MarshalJSON() ([]byte, error) {
+	if v.Valid {
+		return json.Marshal(v.Time)
+	} else {
+		return json.Marshal(nil)
+	}
+}
+
+func (v *NullTime) UnmarshalJSON(data []byte) error {
+	// Unmarshalling into a pointer will let us detect null
+	var x *time.Time
+	if err := json.Unmarshal(data, &x); err != nil {
+		return err
+	}
+	if x != nil {
+		v.Valid = true
+		v.Time = *x
+	} else {
+		v.Valid = false
+	}
+
+	return nil
+}
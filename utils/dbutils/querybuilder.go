@@ -50,6 +50,22 @@ func (qb *QueryBuilder) Where(query string, value any) *QueryBuilder {
 	return qb
 }
 
+// Add a "column IN (?, ...)" criteria with a placeholder per value, it is skipped when there are no values.
+func (qb *QueryBuilder) WhereIn(column string, values ...any) *QueryBuilder {
+	if len(values) == 0 {
+		return qb
+	}
+
+	qb.mu.Lock()
+	defer qb.mu.Unlock()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	qb.criteria = append(qb.criteria, column+" IN ("+placeholders+")")
+	qb.args = append(qb.args, values...)
+
+	return qb
+}
+
 func (qb *QueryBuilder) GroupBy(condition string) *QueryBuilder {
 	qb.mu.Lock()
 	defer qb.mu.Unlock()
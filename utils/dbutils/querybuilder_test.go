@@ -34,6 +34,26 @@ func TestQueryBuilder(t *testing.T) {
 	}
 }
 
+func TestWhereIn(t *testing.T) {
+	qb := NewQueryBuilder()
+
+	qb.Query("select * from repositories")
+	qb.Where("`archived` = ?", false)
+	qb.WhereIn("`license`", "MIT", "Apache-2.0")
+	qb.WhereIn("`language`")
+
+	query, args := qb.GetQuery()
+
+	want := "select * from repositories WHERE `archived` = ? AND `license` IN (?, ?)"
+	if query != want {
+		t.Errorf("want: %s, but got: %s", want, query)
+	}
+
+	if len(args) != 3 || args[0] != false || args[1] != "MIT" || args[2] != "Apache-2.0" {
+		t.Errorf("unexpected args: %v", args...)
+	}
+}
+
 func TestReset(t *testing.T) {
 	qb := NewQueryBuilder()
 
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/liweiyi88/trendshift-backend/model"
@@ -15,10 +16,11 @@ import (
 
 type DeveloperController struct {
 	dr *model.DeveloperRepo
+	mr *model.MetricRepo
 }
 
-func NewDeveloperController(dr *model.DeveloperRepo) *DeveloperController {
-	return &DeveloperController{dr}
+func NewDeveloperController(dr *model.DeveloperRepo, mr *model.MetricRepo) *DeveloperController {
+	return &DeveloperController{dr, mr}
 }
 
 func (dc *DeveloperController) Get(c *gin.Context) {
@@ -46,10 +48,43 @@ func (dc *DeveloperController) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, developer)
 }
 
+// Get the followers of a developer over time.
+func (dc *DeveloperController) GetMetrics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	query, err := parseMetricsQuery(c)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	metrics, err := dc.mr.FindDeveloperMetrics(c, id, query.from, query.to, query.interval)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
 func (dc *DeveloperController) GetTrendingDevelopers(c *gin.Context) {
 	language, _ := url.QueryUnescape(c.Query("language"))
 	limitQuery, _ := url.QueryUnescape(c.Query("limit"))
 	dateRangeQuery := c.Query("range")
+	period := strings.ToLower(strings.TrimSpace(c.Query("period")))
+
+	if period != "" && !model.IsValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
 
 	var limit int
 	var dateRange int
@@ -78,6 +113,7 @@ func (dc *DeveloperController) GetTrendingDevelopers(c *gin.Context) {
 		opt.Language(language),
 		opt.Limit(limit),
 		opt.DateRange(dateRange),
+		opt.Period(period),
 	)
 
 	if err != nil {
@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+)
+
+type JobController struct {
+	jr *model.JobRunRepo
+}
+
+func NewJobController(jr *model.JobRunRepo) *JobController {
+	return &JobController{
+		jr: jr,
+	}
+}
+
+// List the latest job runs, optionally filtered by type and status.
+func (jc *JobController) List(c *gin.Context) {
+	status := c.Query("status")
+
+	if status != "" && !model.IsValidJobRunStatus(status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+
+	var limit int
+
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+
+		if err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+			return
+		}
+	}
+
+	runs, err := jc.jr.FindAll(c, c.Query("type"), status, opt.Limit(limit))
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+func (jc *JobController) Get(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+
+	run, err := jc.jr.FindById(c, id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+type LanguageController struct {
+	lr *model.LanguageRepo
+}
+
+type CreateLanguageRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Slug         string `json:"slug" binding:"required"`
+	Enabled      *bool  `json:"enabled"`
+	ExpectedRows int    `json:"expected_rows" binding:"min=0"`
+}
+
+type UpdateLanguageRequest struct {
+	Name         string `json:"name"`
+	Enabled      *bool  `json:"enabled"`
+	ExpectedRows *int   `json:"expected_rows" binding:"omitempty,min=0"`
+}
+
+func NewLanguageController(lr *model.LanguageRepo) *LanguageController {
+	return &LanguageController{
+		lr: lr,
+	}
+}
+
+// List the enabled languages for the website.
+func (lc *LanguageController) List(c *gin.Context) {
+	languages, err := lc.lr.FindEnabled(c)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, languages)
+}
+
+// List all languages including the disabled ones.
+func (lc *LanguageController) ListAll(c *gin.Context) {
+	languages, err := lc.lr.FindAll(c)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, languages)
+}
+
+func (lc *LanguageController) Save(c *gin.Context) {
+	var request CreateLanguageRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	language, err := lc.lr.FindBySlug(c, request.Slug)
+
+	if err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "language already exists"})
+		return
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	language.Name, language.Slug, language.Enabled = strings.TrimSpace(request.Name), model.LanguageSlug(request.Slug), true
+	language.ExpectedRows = request.ExpectedRows
+
+	if request.Enabled != nil {
+		language.Enabled = *request.Enabled
+	}
+
+	language.Id, err = lc.lr.Save(c, language)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	language, err = lc.lr.FindById(c, language.Id)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, language)
+}
+
+// Rename, enable or disable a language, or change the rows expected on its trending page.
+func (lc *LanguageController) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+
+	var request UpdateLanguageRequest
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	language, err := lc.lr.FindById(c, id)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not Found"})
+		return
+	}
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	if name := strings.TrimSpace(request.Name); name != "" {
+		language.Name = name
+	}
+
+	if request.Enabled != nil {
+		language.Enabled = *request.Enabled
+	}
+
+	if request.ExpectedRows != nil {
+		language.ExpectedRows = *request.ExpectedRows
+	}
+
+	if err := lc.lr.Update(c, language); err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	language, err = lc.lr.FindById(c, id)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, language)
+}
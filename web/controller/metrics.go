@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+// Metrics cover the last 30 days when the range is not set.
+const defaultMetricsDays = 30
+
+type metricsQuery struct {
+	from     time.Time
+	to       time.Time // exclusive, the day after the requested end date.
+	interval string
+}
+
+// Parse the from and to dates (inclusive, YYYY-MM-DD) and the interval of a metrics request.
+func parseMetricsQuery(c *gin.Context) (metricsQuery, error) {
+	query := metricsQuery{interval: c.DefaultQuery("interval", model.DayInterval)}
+
+	if !model.IsValidInterval(query.interval) {
+		return query, errors.New("invalid interval, expected day or week")
+	}
+
+	end, err := time.Parse(time.DateOnly, c.DefaultQuery("to", time.Now().Format(time.DateOnly)))
+
+	if err != nil {
+		return query, err
+	}
+
+	query.to = end.AddDate(0, 0, 1)
+	query.from = query.to.AddDate(0, 0, -defaultMetricsDays)
+
+	if from := c.Query("from"); from != "" {
+		query.from, err = time.Parse(time.DateOnly, from)
+
+		if err != nil {
+			return query, err
+		}
+	}
+
+	if !query.from.Before(query.to) {
+		return query, errors.New("from must not be after to")
+	}
+
+	return query, nil
+}
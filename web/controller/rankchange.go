@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liweiyi88/trendshift-backend/model"
+	"github.com/liweiyi88/trendshift-backend/model/opt"
+)
+
+// Default number of rank changes returned when the limit is not set.
+const defaultRankChangesLimit = 100
+
+type RankChangeController struct {
+	rcr *model.RankChangeRepo
+}
+
+func NewRankChangeController(rcr *model.RankChangeRepo) *RankChangeController {
+	return &RankChangeController{
+		rcr: rcr,
+	}
+}
+
+func (rc *RankChangeController) List(c *gin.Context) {
+	changeType := c.DefaultQuery("type", model.RankChangeRepository)
+	name, _ := url.QueryUnescape(c.Query("name"))
+	language, _ := url.QueryUnescape(c.Query("language"))
+	period := strings.ToLower(strings.TrimSpace(c.Query("period")))
+
+	if changeType != model.RankChangeRepository && changeType != model.RankChangeDeveloper {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+
+	if period != "" && !model.IsValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
+
+	limit, dateRange := defaultRankChangesLimit, 0
+	var err error
+
+	if rangeQuery := c.Query("range"); rangeQuery != "" {
+		dateRange, err = strconv.Atoi(rangeQuery)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+			return
+		}
+	}
+
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		limit, err = strconv.Atoi(limitQuery)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+			return
+		}
+	}
+
+	changes, err := rc.rcr.Find(c, changeType, name, opt.Language(model.LanguageSlug(language)), opt.Period(period), opt.DateRange(dateRange), opt.Limit(limit))
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"log/slog"
 
@@ -14,8 +15,14 @@ import (
 	"github.com/liweiyi88/trendshift-backend/model/opt"
 )
 
+// Trending days within 3 days after a release are marked as near the release when the window is not set.
+const defaultReleaseDays = 3
+
 type RepositoryController struct {
 	grr *model.GhRepositoryRepo
+	rrr *model.RepositoryReadmeRepo
+	mr  *model.MetricRepo
+	rlr *model.ReleaseRepo
 }
 
 type AttachTagsRequest struct {
@@ -23,14 +30,55 @@ type AttachTagsRequest struct {
 	Name string `json:"name" binding:"required"`
 }
 
-func NewRepositoryController(grr *model.GhRepositoryRepo) *RepositoryController {
+func NewRepositoryController(grr *model.GhRepositoryRepo, rrr *model.RepositoryReadmeRepo, mr *model.MetricRepo, rlr *model.ReleaseRepo) *RepositoryController {
 	return &RepositoryController{
 		grr,
+		rrr,
+		mr,
+		rlr,
+	}
+}
+
+// Parse the filters by GitHub metadata, e.g. exclude_forks=true&exclude_archived=true&license=MIT,Apache-2.0
+func parseRepositoryFilters(c *gin.Context) ([]any, error) {
+	filters := make([]any, 0, 3)
+
+	if excludeForksQuery := c.Query("exclude_forks"); excludeForksQuery != "" {
+		excludeForks, err := strconv.ParseBool(excludeForksQuery)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, opt.ExcludeForks(excludeForks))
+	}
+
+	if excludeArchivedQuery := c.Query("exclude_archived"); excludeArchivedQuery != "" {
+		excludeArchived, err := strconv.ParseBool(excludeArchivedQuery)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filters = append(filters, opt.ExcludeArchived(excludeArchived))
+	}
+
+	if license := c.Query("license"); license != "" {
+		filters = append(filters, opt.Licenses(strings.Split(license, ",")...))
 	}
+
+	return filters, nil
 }
 
 func (rc *RepositoryController) List(c *gin.Context) {
-	ghRepositories, err := rc.grr.FindAllWithTags(c, c.Query("q"))
+	filters, err := parseRepositoryFilters(c)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	ghRepositories, err := rc.grr.FindAllWithTags(c, c.Query("q"), filters...)
 	if err != nil {
 		slog.Error(err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
@@ -44,6 +92,13 @@ func (rc *RepositoryController) GetTrendingRepositories(c *gin.Context) {
 	language, _ := url.QueryUnescape(c.Query("language"))
 	limitQuery, _ := url.QueryUnescape(c.Query("limit"))
 	dateRangeQuery := c.Query("range")
+	period := strings.ToLower(strings.TrimSpace(c.Query("period")))
+	spokenLanguage, _ := url.QueryUnescape(c.Query("spoken_language"))
+
+	if period != "" && !model.IsValidPeriod(period) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+		return
+	}
 
 	var limit int
 	var dateRange int
@@ -67,11 +122,22 @@ func (rc *RepositoryController) GetTrendingRepositories(c *gin.Context) {
 		}
 	}
 
+	filters, err := parseRepositoryFilters(c)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
 	repositories, err := rc.grr.FindTrendingRepositories(
 		c,
-		opt.Language(language),
-		opt.Limit(limit),
-		opt.DateRange(dateRange),
+		append(filters,
+			opt.Language(language),
+			opt.Limit(limit),
+			opt.DateRange(dateRange),
+			opt.Period(period),
+			opt.SpokenLanguage(spokenLanguage),
+		)...,
 	)
 
 	if err != nil {
@@ -92,6 +158,17 @@ func (rc *RepositoryController) Get(c *gin.Context) {
 		return
 	}
 
+	releaseDays := defaultReleaseDays
+
+	if releaseDaysQuery := c.Query("release_days"); releaseDaysQuery != "" {
+		releaseDays, err = strconv.Atoi(releaseDaysQuery)
+
+		if err != nil || releaseDays < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+			return
+		}
+	}
+
 	repository, err := rc.grr.FindById(c, id)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -105,9 +182,89 @@ func (rc *RepositoryController) Get(c *gin.Context) {
 		return
 	}
 
+	readme, err := rc.rrr.Find(c, id)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	if err == nil {
+		repository.Readme = &readme
+	}
+
+	releases, err := rc.rlr.FindByRepositoryId(c, id)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	model.MarkReleases(repository.Trendings, releases, releaseDays)
+
 	c.JSON(http.StatusOK, repository)
 }
 
+// Get the tracked releases of a repository, the latest release comes first.
+func (rc *RepositoryController) GetReleases(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	var limit int
+
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		limit, err = strconv.Atoi(limitQuery)
+
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+			return
+		}
+	}
+
+	releases, err := rc.rlr.FindByRepositoryId(c, id, opt.Limit(limit))
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, releases)
+}
+
+// Get the stars and forks of a repository over time.
+func (rc *RepositoryController) GetMetrics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	query, err := parseMetricsQuery(c)
+
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bad request"})
+		return
+	}
+
+	metrics, err := rc.mr.FindRepositoryMetrics(c, id, query.from, query.to, query.interval)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, metrics)
+}
+
 func (rc *RepositoryController) SaveTags(c *gin.Context) {
 	repositoryId, err := strconv.Atoi(c.Param("id"))
 
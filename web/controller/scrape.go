@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/liweiyi88/trendshift-backend/config"
+	"github.com/liweiyi88/trendshift-backend/model"
+)
+
+type ScrapeController struct {
+	sr *model.ScrapeRunRepo
+	lr *model.LanguageRepo
+}
+
+func NewScrapeController(sr *model.ScrapeRunRepo, lr *model.LanguageRepo) *ScrapeController {
+	return &ScrapeController{
+		sr: sr,
+		lr: lr,
+	}
+}
+
+func (sc *ScrapeController) GetHealth(c *gin.Context) {
+	days := config.ScrapeHealthDays
+
+	if value := c.Query("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+
+		if err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Bad Request"})
+			return
+		}
+	}
+
+	runs, err := sc.sr.FindSince(c, time.Now().AddDate(0, 0, -days))
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	languages, err := sc.lr.FindAll(c)
+
+	if err != nil {
+		slog.Error(err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, model.CheckScrapeHealth(runs, languages))
+}
@@ -13,9 +13,11 @@ import (
 	"github.com/liweiyi88/trendshift-backend/jwttoken"
 )
 
+const claimKey = "claim"
+
 func JwtAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		err := verifyToken(c)
+		claim, err := verifyToken(c)
 
 		if err != nil {
 			slog.Error("authentication failed", slog.Any("error", err))
@@ -24,17 +26,35 @@ func JwtAuth() gin.HandlerFunc {
 			return
 		}
 
+		c.Set(claimKey, claim)
+		c.Next()
+	}
+}
+
+// Only allow users with the given role, it must be used after JwtAuth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(claimKey)
+		claim, ok := value.(*jwttoken.AppClaim)
+
+		if !ok || !claim.HasRole(role) {
+			slog.Error("authorization failed", slog.String("role", role))
+			c.String(http.StatusForbidden, "Forbidden")
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
 
-func verifyToken(c *gin.Context) error {
+func verifyToken(c *gin.Context) (*jwttoken.AppClaim, error) {
 	authHeader := c.Request.Header.Get("Authorization")
 
 	bearerString := strings.Split(authHeader, " ")
 
 	if len(bearerString) != 2 {
-		return errors.New("incorrectly formatted authorization header")
+		return nil, errors.New("incorrectly formatted authorization header")
 	}
 
 	tokenString := bearerString[1]
@@ -42,14 +62,14 @@ func verifyToken(c *gin.Context) error {
 	token, err := jwttoken.NewTokenService(config.SignIngKey).Verify(tokenString)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, ok := token.Claims.(*jwttoken.AppClaim)
+	claim, ok := token.Claims.(*jwttoken.AppClaim)
 
 	if ok && token.Valid {
-		return nil
+		return claim, nil
 	} else {
-		return fmt.Errorf("invalid token string: %v", tokenString)
+		return nil, fmt.Errorf("invalid token string: %v", tokenString)
 	}
 }
@@ -28,16 +28,24 @@ type Controllers struct {
 	securityController   *controller.SecurityController
 	statsController      *controller.StatsController
 	searchController     *controller.SearchController
+	scrapeController     *controller.ScrapeController
+	languageController   *controller.LanguageController
+	rankChangeController *controller.RankChangeController
+	jobController        *controller.JobController
 }
 
 func initControllers(repositories *global.Repositories) *Controllers {
 	return &Controllers{
-		developerController:  controller.NewDeveloperController(repositories.DeveloperRepo),
-		repositoryController: controller.NewRepositoryController(repositories.GhRepositoryRepo),
+		developerController:  controller.NewDeveloperController(repositories.DeveloperRepo, repositories.MetricRepo),
+		repositoryController: controller.NewRepositoryController(repositories.GhRepositoryRepo, repositories.RepositoryReadmeRepo, repositories.MetricRepo, repositories.ReleaseRepo),
 		tagController:        controller.NewTagController(repositories.TagRepo),
 		securityController:   controller.NewSecurityController(repositories.UserRepo),
 		statsController:      controller.NewStatsController(repositories.StatsRepo),
 		searchController:     controller.NewSearchController(),
+		scrapeController:     controller.NewScrapeController(repositories.ScrapeRunRepo, repositories.LanguageRepo),
+		languageController:   controller.NewLanguageController(repositories.LanguageRepo),
+		rankChangeController: controller.NewRankChangeController(repositories.RankChangeRepo),
+		jobController:        controller.NewJobController(repositories.JobRunRepo),
 	}
 }
 
@@ -66,9 +74,14 @@ func setupRouter(ctx context.Context) (*gin.Engine, *sql.DB) {
 	router.GET("/api/trending-developers", controllers.developerController.GetTrendingDevelopers)
 	router.GET("/api/trending-repositories", controllers.repositoryController.GetTrendingRepositories)
 	router.GET("/api/developers/:id", controllers.developerController.Get)
+	router.GET("/api/developers/:id/metrics", controllers.developerController.GetMetrics)
 	router.GET("/api/repositories", controllers.repositoryController.List)
 	router.GET("/api/repositories/:id", controllers.repositoryController.Get)
+	router.GET("/api/repositories/:id/metrics", controllers.repositoryController.GetMetrics)
+	router.GET("/api/repositories/:id/releases", controllers.repositoryController.GetReleases)
 	router.GET("/api/tags", controllers.tagController.List)
+	router.GET("/api/languages", controllers.languageController.List)
+	router.GET("/api/rank-changes", controllers.rankChangeController.List)
 	router.GET("/api/stats/trending-topics", controllers.statsController.GetTrendingTopicsStats)
 
 	// Protected routes.
@@ -77,6 +90,16 @@ func setupRouter(ctx context.Context) (*gin.Engine, *sql.DB) {
 	auth.POST("/tags", controllers.tagController.Save)
 	auth.PUT("/repositories/:id/tags", controllers.repositoryController.SaveTags)
 
+	// Admin routes.
+	admin := auth.Group("/admin")
+	admin.Use(middleware.RequireRole("admin"))
+	admin.GET("/scrape-health", controllers.scrapeController.GetHealth)
+	admin.GET("/languages", controllers.languageController.ListAll)
+	admin.POST("/languages", controllers.languageController.Save)
+	admin.PUT("/languages/:id", controllers.languageController.Update)
+	admin.GET("/jobs", controllers.jobController.List)
+	admin.GET("/jobs/:id", controllers.jobController.Get)
+
 	return router, db
 }
 